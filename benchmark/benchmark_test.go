@@ -0,0 +1,282 @@
+// © 2019-present nextmv.io inc
+
+package benchmark_test
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/nextmv-io/nextroute/benchmark"
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+const solomon = `C-TEST
+
+VEHICLE
+NUMBER     CAPACITY
+  2          20
+
+CUSTOMER
+CUST NO.  XCOORD.   YCOORD.    DEMAND   READY TIME  DUE DATE   SERVICE   TIME
+
+    0      0          0          0          0       1000          0
+    1      3          4         10          0        100         10
+    2      6          8         10          0        200         10
+    3      0          5          5         50        300         10
+`
+
+const liLim = `2	20	1
+0	0	0	0	0	1000	0	0	0
+1	3	4	5	0	100	10	0	2
+2	6	8	-5	0	200	10	1	0
+`
+
+const cvrp = `NAME : T-n4-k2
+COMMENT : "test instance"
+TYPE : CVRP
+DIMENSION : 4
+EDGE_WEIGHT_TYPE : EUC_2D
+CAPACITY : 10
+NODE_COORD_SECTION
+1 0 0
+2 3 4
+3 6 8
+4 1 1
+DEMAND_SECTION
+1 0
+2 5
+3 5
+4 4
+DEPOT_SECTION
+1
+-1
+EOF
+`
+
+const explicit = `NAME: T-n4
+TYPE: TSP
+DIMENSION: 4
+EDGE_WEIGHT_TYPE: EXPLICIT
+EDGE_WEIGHT_FORMAT: LOWER_ROW
+EDGE_WEIGHT_SECTION
+1
+2 3
+4 5
+6
+EOF
+`
+
+func TestReadSolomon(t *testing.T) {
+	input, err := benchmark.ReadSolomon(
+		strings.NewReader(solomon),
+		benchmark.Options{Rounding: benchmark.RoundingNone},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(input.Stops) != 3 || len(input.Vehicles) != 2 {
+		t.Fatalf(
+			"expected 3 stops and 2 vehicles, got %v and %v",
+			len(input.Stops),
+			len(input.Vehicles),
+		)
+	}
+	if input.Stops[0].ID != "1" || input.Stops[0].Quantity != -10.0 {
+		t.Errorf("unexpected first stop %v", input.Stops[0])
+	}
+	if input.Vehicles[0].Capacity != 20.0 {
+		t.Errorf("expected capacity 20, got %v", input.Vehicles[0].Capacity)
+	}
+	window := input.Stops[2].StartTimeWindow.([]any)
+	if window[0] != "2000-01-01T00:50:00Z" || window[1] != "2000-01-01T05:00:00Z" {
+		t.Errorf("unexpected time window %v", window)
+	}
+
+	distance := *input.DistanceMatrix
+	// Stop 1 to stop 2 and the start of vehicle 1 (the depot) to stop 1.
+	if distance[0][1] != 5 || distance[3][0] != 5 {
+		t.Errorf("unexpected distances %v", distance)
+	}
+	if len(distance) != 3+2*2 {
+		t.Errorf("expected a matrix of size 7, got %v", len(distance))
+	}
+
+	if _, err := factory.NewModel(input, factory.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	solution := schema.SolutionOutput{
+		Vehicles: []schema.VehicleOutput{
+			{
+				ID: input.Vehicles[0].ID,
+				Route: []schema.PlannedStopOutput{
+					{Stop: schema.StopOutput{ID: input.Vehicles[0].ID + "-start"}},
+					{Stop: schema.StopOutput{ID: "1"}},
+					{Stop: schema.StopOutput{ID: "2"}},
+					{Stop: schema.StopOutput{ID: input.Vehicles[0].ID + "-end"}},
+				},
+			},
+			{ID: input.Vehicles[1].ID},
+		},
+		Unplanned: []schema.StopOutput{{ID: "3"}},
+	}
+	var buffer bytes.Buffer
+	if err := benchmark.WriteSolution(&buffer, input, solution); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Route 1 : 1 2\nVehicles : 1\nDistance : 20.00\nUnplanned : 3\n"
+	if buffer.String() != expected {
+		t.Errorf("expected solution\n%s\ngot\n%s", expected, buffer.String())
+	}
+}
+
+func TestReadLiLim(t *testing.T) {
+	input, err := benchmark.ReadLiLim(
+		strings.NewReader(liLim),
+		"lc-test",
+		benchmark.Options{Rounding: benchmark.RoundingOneDecimal},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Stops[0].Precedes != "2" {
+		t.Errorf("expected pickup 1 to precede 2, got %v", input.Stops[0].Precedes)
+	}
+	if input.Stops[1].Precedes != nil {
+		t.Errorf("expected delivery to precede nothing, got %v", input.Stops[1].Precedes)
+	}
+	if input.Stops[0].Quantity != -5.0 || input.Stops[1].Quantity != 5.0 {
+		t.Errorf(
+			"expected quantities -5 and 5, got %v and %v",
+			input.Stops[0].Quantity,
+			input.Stops[1].Quantity,
+		)
+	}
+	if _, err := factory.NewModel(input, factory.Options{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadCVRPLIB(t *testing.T) {
+	input, err := benchmark.ReadCVRPLIB(strings.NewReader(cvrp), benchmark.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(input.Stops) != 3 || len(input.Vehicles) != 2 {
+		t.Fatalf(
+			"expected 3 stops and 2 vehicles, got %v and %v",
+			len(input.Stops),
+			len(input.Vehicles),
+		)
+	}
+	if input.Stops[0].ID != "2" || input.Stops[0].Quantity != -5.0 {
+		t.Errorf("unexpected first stop %v", input.Stops[0])
+	}
+	if input.CustomData.(benchmark.InputCustomData).Rounding != "nearest" {
+		t.Errorf("expected nearest rounding, got %v", input.CustomData)
+	}
+
+	distance := *input.DistanceMatrix
+	// The depot to node 4 is sqrt(2), rounded to 1.
+	if distance[3][2] != 1 || distance[0][1] != 5 {
+		t.Errorf("unexpected distances %v", distance)
+	}
+	if _, err := factory.NewModel(input, factory.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	solution := schema.SolutionOutput{
+		Vehicles: []schema.VehicleOutput{
+			{
+				ID: input.Vehicles[0].ID,
+				Route: []schema.PlannedStopOutput{
+					{Stop: schema.StopOutput{ID: "2"}},
+					{Stop: schema.StopOutput{ID: "3"}},
+				},
+			},
+			{
+				ID:    input.Vehicles[1].ID,
+				Route: []schema.PlannedStopOutput{{Stop: schema.StopOutput{ID: "4"}}},
+			},
+		},
+	}
+	var buffer bytes.Buffer
+	if err := benchmark.WriteCVRPLIBSolution(&buffer, input, solution); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Route #1: 1 2\nRoute #2: 3\nCost 22\n"
+	if buffer.String() != expected {
+		t.Errorf("expected solution\n%s\ngot\n%s", expected, buffer.String())
+	}
+
+	solution.Unplanned = []schema.StopOutput{{ID: "4"}}
+	if err := benchmark.WriteCVRPLIBSolution(&buffer, input, solution); err == nil {
+		t.Error("expected an error for unplanned stops")
+	}
+
+	input, err = benchmark.ReadCVRPLIB(
+		strings.NewReader(cvrp),
+		benchmark.Options{Rounding: benchmark.RoundingNone, Vehicles: 3},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(input.Vehicles) != 3 || (*input.DistanceMatrix)[3][2] != math.Sqrt(2) {
+		t.Errorf("expected 3 vehicles and exact distances, got %v", input)
+	}
+}
+
+func TestReadCVRPLIBExplicit(t *testing.T) {
+	input, err := benchmark.ReadCVRPLIB(strings.NewReader(explicit), benchmark.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(input.Stops) != 3 || len(input.Vehicles) != 1 {
+		t.Fatalf(
+			"expected 3 stops and 1 vehicle, got %v and %v",
+			len(input.Stops),
+			len(input.Vehicles),
+		)
+	}
+	distance := *input.DistanceMatrix
+	// Node 3 to node 4 is the last weight, the start (depot) to node 2 the
+	// first one.
+	if distance[1][2] != 6 || distance[2][1] != 6 || distance[3][0] != 1 {
+		t.Errorf("unexpected distances %v", distance)
+	}
+
+	_, err = benchmark.ReadCVRPLIB(
+		strings.NewReader(strings.Replace(explicit, "6\n", "", 1)),
+		benchmark.Options{},
+	)
+	if err == nil {
+		t.Error("expected an error for missing edge weights")
+	}
+}
+
+func TestRounding(t *testing.T) {
+	tests := []struct {
+		rounding benchmark.Rounding
+		expected float64
+	}{
+		{benchmark.RoundingDefault, math.Sqrt(2)},
+		{benchmark.RoundingNone, math.Sqrt(2)},
+		{benchmark.RoundingNearest, 1},
+		{benchmark.RoundingTruncate, 1},
+		{benchmark.RoundingOneDecimal, 1.4},
+		{benchmark.RoundingCeil, 2},
+	}
+	for _, test := range tests {
+		if actual := test.rounding.Round(math.Sqrt(2)); actual != test.expected {
+			t.Errorf("%v: expected %v, got %v", test.rounding, test.expected, actual)
+		}
+		parsed, err := benchmark.ParseRounding(test.rounding.String())
+		if err != nil || parsed != test.rounding {
+			t.Errorf("expected to parse %v, got %v, %v", test.rounding, parsed, err)
+		}
+	}
+}
@@ -0,0 +1,374 @@
+// © 2019-present nextmv.io inc
+
+package benchmark
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// vehiclesInName matches the number of vehicles in a CVRPLIB instance name,
+// for example 25 in X-n101-k25.
+var vehiclesInName = regexp.MustCompile(`-k(\d+)`)
+
+// ReadCVRPLIB reads a CVRPLIB or TSPLIB instance of type CVRP, ACVRP, TSP or
+// ATSP. Supported are the edge weight types EUC_2D, CEIL_2D and EXPLICIT with
+// the formats FULL_MATRIX, UPPER_ROW, LOWER_ROW, UPPER_DIAG_ROW and
+// LOWER_DIAG_ROW, and the NODE_COORD_SECTION, DISPLAY_DATA_SECTION,
+// EDGE_WEIGHT_SECTION, DEMAND_SECTION and DEPOT_SECTION sections. A single
+// depot is supported, if none is given the first node is the depot.
+//
+// The default rounding follows TSPLIB: nearest integer for EUC_2D, up for
+// CEIL_2D and none for explicit weights. The number of vehicles is taken from
+// [Options.Vehicles], the VEHICLES specification or the -k suffix of the name,
+// in that order. If none is given, each customer gets a vehicle. A TSP has a
+// single vehicle.
+func ReadCVRPLIB(reader io.Reader, options Options) (schema.Input, error) {
+	specification := map[string]string{}
+	coordinates := map[int][2]float64{}
+	display := map[int][2]float64{}
+	demands := map[int]float64{}
+	var depots []int
+	var weights []float64
+
+	section := ""
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		upper := strings.ToUpper(text)
+		if upper == "EOF" {
+			break
+		}
+
+		if unicode.IsLetter(rune(text[0])) {
+			if key, value, ok := strings.Cut(text, ":"); ok {
+				specification[strings.ToUpper(strings.TrimSpace(key))] = strings.Trim(
+					strings.TrimSpace(value),
+					`"`,
+				)
+				section = ""
+				continue
+			}
+			switch upper {
+			case "NODE_COORD_SECTION",
+				"DISPLAY_DATA_SECTION",
+				"EDGE_WEIGHT_SECTION",
+				"DEMAND_SECTION",
+				"DEPOT_SECTION":
+				section = upper
+			default:
+				return schema.Input{}, fmt.Errorf(
+					"line %v: unsupported section %s",
+					line,
+					text,
+				)
+			}
+			continue
+		}
+
+		values, ok := fields(text)
+		if !ok {
+			return schema.Input{}, fmt.Errorf("line %v: expected numbers", line)
+		}
+		switch section {
+		case "NODE_COORD_SECTION", "DISPLAY_DATA_SECTION":
+			if len(values) != 3 {
+				return schema.Input{}, fmt.Errorf(
+					"line %v: expected node number, x and y, got %v values",
+					line,
+					len(values),
+				)
+			}
+			if section == "NODE_COORD_SECTION" {
+				coordinates[int(values[0])] = [2]float64{values[1], values[2]}
+			} else {
+				display[int(values[0])] = [2]float64{values[1], values[2]}
+			}
+		case "EDGE_WEIGHT_SECTION":
+			weights = append(weights, values...)
+		case "DEMAND_SECTION":
+			if len(values) != 2 {
+				return schema.Input{}, fmt.Errorf(
+					"line %v: expected node number and demand, got %v values",
+					line,
+					len(values),
+				)
+			}
+			demands[int(values[0])] = values[1]
+		case "DEPOT_SECTION":
+			for _, value := range values {
+				if value == -1 {
+					section = ""
+					break
+				}
+				depots = append(depots, int(value))
+			}
+		default:
+			return schema.Input{}, fmt.Errorf(
+				"line %v: values outside of a section",
+				line,
+			)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return schema.Input{}, err
+	}
+
+	return cvrplibInstance(
+		specification,
+		coordinates,
+		display,
+		demands,
+		depots,
+		weights,
+		options,
+	)
+}
+
+// cvrplibInstance converts the parsed sections of a CVRPLIB or TSPLIB
+// instance to an input.
+func cvrplibInstance(
+	specification map[string]string,
+	coordinates map[int][2]float64,
+	display map[int][2]float64,
+	demands map[int]float64,
+	depots []int,
+	weights []float64,
+	options Options,
+) (schema.Input, error) {
+	dimension, err := strconv.Atoi(specification["DIMENSION"])
+	if err != nil || dimension < 1 {
+		return schema.Input{}, fmt.Errorf(
+			"invalid DIMENSION %q",
+			specification["DIMENSION"],
+		)
+	}
+
+	instance := instance{
+		name:  specification["NAME"],
+		speed: 1,
+	}
+	switch kind := strings.ToUpper(specification["TYPE"]); kind {
+	case "CVRP", "ACVRP":
+		instance.kind = "cvrplib"
+		if value, ok := specification["CAPACITY"]; ok {
+			if instance.capacity, err = strconv.ParseFloat(value, 64); err != nil {
+				return schema.Input{}, fmt.Errorf("invalid CAPACITY %q", value)
+			}
+		} else if len(demands) > 0 {
+			return schema.Input{}, errors.New("CAPACITY is required with demands")
+		}
+		instance.vehicles = dimension - 1
+		if value, ok := specification["VEHICLES"]; ok {
+			if instance.vehicles, err = strconv.Atoi(value); err != nil {
+				return schema.Input{}, fmt.Errorf("invalid VEHICLES %q", value)
+			}
+		} else if match := vehiclesInName.FindStringSubmatch(instance.name); match != nil {
+			instance.vehicles, _ = strconv.Atoi(match[1])
+		}
+	case "TSP", "ATSP":
+		instance.kind = "tsplib"
+		instance.vehicles = 1
+	default:
+		return schema.Input{}, fmt.Errorf("unsupported TYPE %q", kind)
+	}
+
+	depot := 1
+	switch len(depots) {
+	case 0:
+	case 1:
+		depot = depots[0]
+	default:
+		return schema.Input{}, fmt.Errorf(
+			"%v depots given, a single depot is supported",
+			len(depots),
+		)
+	}
+	if depot < 1 || depot > dimension {
+		return schema.Input{}, fmt.Errorf("depot %v is not a node", depot)
+	}
+
+	// The depot comes first, followed by the customers in the order of their
+	// numbers.
+	numbers := make([]int, 0, dimension)
+	numbers = append(numbers, depot)
+	for number := 1; number <= dimension; number++ {
+		if number != depot {
+			numbers = append(numbers, number)
+		}
+	}
+
+	switch edgeWeightType := strings.ToUpper(specification["EDGE_WEIGHT_TYPE"]); edgeWeightType {
+	case "EUC_2D", "CEIL_2D":
+		instance.rounding = RoundingNearest
+		if edgeWeightType == "CEIL_2D" {
+			instance.rounding = RoundingCeil
+		}
+		if len(coordinates) != dimension {
+			return schema.Input{}, fmt.Errorf(
+				"expected %v node coordinates, got %v",
+				dimension,
+				len(coordinates),
+			)
+		}
+	case "EXPLICIT":
+		instance.rounding = RoundingNone
+		matrix, err := explicitWeights(
+			strings.ToUpper(specification["EDGE_WEIGHT_FORMAT"]),
+			weights,
+			dimension,
+		)
+		if err != nil {
+			return schema.Input{}, err
+		}
+		instance.weights = make([][]float64, dimension)
+		for from, fromNumber := range numbers {
+			instance.weights[from] = make([]float64, dimension)
+			for to, toNumber := range numbers {
+				instance.weights[from][to] = matrix[fromNumber-1][toNumber-1]
+			}
+		}
+		if len(coordinates) == 0 {
+			coordinates = display
+		}
+	default:
+		return schema.Input{}, fmt.Errorf(
+			"unsupported EDGE_WEIGHT_TYPE %q",
+			edgeWeightType,
+		)
+	}
+
+	instance.nodes = make([]node, dimension)
+	for idx, number := range numbers {
+		instance.nodes[idx] = node{
+			id:     strconv.Itoa(number),
+			x:      coordinates[number][0],
+			y:      coordinates[number][1],
+			demand: demands[number],
+		}
+	}
+	instance.nodes[0].demand = 0
+
+	return instance.toInput(options)
+}
+
+// explicitWeights returns the full matrix of explicit edge weights given in
+// the format of the EDGE_WEIGHT_FORMAT specification.
+func explicitWeights(
+	format string,
+	weights []float64,
+	dimension int,
+) ([][]float64, error) {
+	matrix := make([][]float64, dimension)
+	for idx := range matrix {
+		matrix[idx] = make([]float64, dimension)
+	}
+
+	// columns returns the range of columns given for a row.
+	var columns func(row int) (int, int)
+	symmetric := true
+	switch format {
+	case "FULL_MATRIX":
+		symmetric = false
+		columns = func(int) (int, int) { return 0, dimension }
+	case "UPPER_ROW", "LOWER_COL":
+		columns = func(row int) (int, int) { return row + 1, dimension }
+	case "UPPER_DIAG_ROW", "LOWER_DIAG_COL":
+		columns = func(row int) (int, int) { return row, dimension }
+	case "LOWER_ROW", "UPPER_COL":
+		columns = func(row int) (int, int) { return 0, row }
+	case "LOWER_DIAG_ROW", "UPPER_DIAG_COL":
+		columns = func(row int) (int, int) { return 0, row + 1 }
+	default:
+		return nil, fmt.Errorf("unsupported EDGE_WEIGHT_FORMAT %q", format)
+	}
+
+	expected := 0
+	for row := 0; row < dimension; row++ {
+		from, to := columns(row)
+		expected += to - from
+	}
+	if len(weights) != expected {
+		return nil, fmt.Errorf(
+			"expected %v edge weights for %s, got %v",
+			expected,
+			format,
+			len(weights),
+		)
+	}
+
+	next := 0
+	for row := 0; row < dimension; row++ {
+		from, to := columns(row)
+		for column := from; column < to; column++ {
+			matrix[row][column] = weights[next]
+			if symmetric {
+				matrix[column][row] = weights[next]
+			}
+			next++
+		}
+	}
+	return matrix, nil
+}
+
+// WriteCVRPLIBSolution writes a solution of an input read by [ReadCVRPLIB] in
+// the CVRPLIB .sol format: a line per non-empty route listing the customers
+// in the order they are visited, followed by the total cost. Customers are
+// numbered from 1 in the order of the instance, skipping the depot. The cost
+// is written as an integer if it is one, as is the case with the integer
+// rounding conventions. The format has no notion of unplanned customers, a
+// solution with unplanned stops results in an error.
+//
+//	Route #1: 21 31 19 17 13 7 26
+//	Route #2: 12 1 16 30
+//	Cost 27591
+func WriteCVRPLIBSolution(
+	writer io.Writer,
+	input schema.Input,
+	solution schema.SolutionOutput,
+) error {
+	if len(solution.Unplanned) > 0 {
+		return fmt.Errorf(
+			"solution has %v unplanned stops",
+			len(solution.Unplanned),
+		)
+	}
+	routes, err := routes(input, solution)
+	if err != nil {
+		return err
+	}
+	customers := make(map[string]int, len(input.Stops))
+	for idx, stop := range input.Stops {
+		customers[stop.ID] = idx + 1
+	}
+
+	var sb strings.Builder
+	total := 0.0
+	for idx, r := range routes {
+		numbers := make([]string, len(r.stops))
+		for s, stop := range r.stops {
+			numbers[s] = strconv.Itoa(customers[stop])
+		}
+		fmt.Fprintf(&sb, "Route #%d: %s\n", idx+1, strings.Join(numbers, " "))
+		total += r.distance
+	}
+	precision := 2
+	if total == float64(int64(total)) {
+		precision = 0
+	}
+	fmt.Fprintf(&sb, "Cost %s\n", strconv.FormatFloat(total, 'f', precision, 64))
+
+	_, err = io.WriteString(writer, sb.String())
+	return err
+}
@@ -0,0 +1,26 @@
+// © 2019-present nextmv.io inc
+
+/*
+Package benchmark reads academic benchmark instances into a [schema.Input]
+and writes solutions in the notation used to report results on them.
+
+Supported are the Solomon and Homberger & Gehring VRPTW instances
+([ReadSolomon]), the Li & Lim PDPTW instances ([ReadLiLim]) and the CVRPLIB
+and TSPLIB instances ([ReadCVRPLIB]). Travel distances and durations are
+Euclidean or explicit, rounded as configured in [Options].
+Time values of an instance are interpreted in [Options.TimeUnit] after
+[Options.Epoch]. The coordinates are kept in the custom data of the stops,
+the locations are scaled down to stay valid longitudes and latitudes.
+
+Benchmarks usually minimize the number of vehicles first and the travel
+distance second, this is approximated with a high vehicle activation
+penalty and the travel duration objective, for example:
+
+	-model.objectives.vehicleactivationpenalty 100000 \
+	-model.objectives.travelduration 1 \
+	-model.objectives.vehiclesduration 0
+
+Use [WriteSolution] to report a solution, [WriteCVRPLIBSolution] to write
+it in the CVRPLIB .sol format.
+*/
+package benchmark
@@ -0,0 +1,222 @@
+// © 2019-present nextmv.io inc
+
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// node is a depot or customer of an instance.
+type node struct {
+	id       string
+	x        float64
+	y        float64
+	demand   float64
+	ready    float64
+	due      float64
+	service  float64
+	precedes string
+}
+
+// instance is the intermediate representation of a benchmark instance. The
+// first node is the depot.
+type instance struct {
+	name       string
+	kind       string
+	nodes      []node
+	vehicles   int
+	capacity   float64
+	speed      float64
+	hasWindows bool
+	// rounding is the convention of the instance format, used unless the
+	// options set another one.
+	rounding Rounding
+	// weights are explicit distances between the nodes, if nil the Euclidean
+	// distance between the coordinates is used.
+	weights [][]float64
+}
+
+// CustomData is the custom data of a stop of a benchmark instance.
+type CustomData struct {
+	// X is the x coordinate of the stop in the instance.
+	X float64 `json:"x"`
+	// Y is the y coordinate of the stop in the instance.
+	Y float64 `json:"y"`
+}
+
+// InputCustomData is the custom data of an input read from a benchmark
+// instance.
+type InputCustomData struct {
+	// Name of the instance.
+	Name string `json:"name"`
+	// Type of the instance, for example `solomon`.
+	Type string `json:"type"`
+	// Rounding is the rounding convention of the distances.
+	Rounding string `json:"rounding"`
+}
+
+func (i instance) distance(from, to int, rounding Rounding) float64 {
+	if from == to {
+		return 0
+	}
+	if i.weights != nil {
+		return rounding.Round(i.weights[from][to])
+	}
+	return rounding.Round(math.Hypot(
+		i.nodes[from].x-i.nodes[to].x,
+		i.nodes[from].y-i.nodes[to].y,
+	))
+}
+
+// toInput converts the instance to an input. The stops are the customers in
+// the order of the instance, each vehicle starts and ends at the depot.
+func (i instance) toInput(options Options) (schema.Input, error) {
+	if len(i.nodes) < 1 {
+		return schema.Input{}, fmt.Errorf("instance %s has no depot", i.name)
+	}
+	vehicles := i.vehicles
+	if options.Vehicles > 0 {
+		vehicles = options.Vehicles
+	}
+	if vehicles < 1 {
+		return schema.Input{}, fmt.Errorf("instance %s has no vehicles", i.name)
+	}
+	rounding := options.rounding(i.rounding)
+	speed := i.speed
+	if speed <= 0 {
+		speed = 1
+	}
+	epoch := options.epoch()
+	unit := options.timeUnit()
+
+	maxAbs := 0.0
+	for _, n := range i.nodes {
+		maxAbs = math.Max(maxAbs, math.Max(math.Abs(n.x), math.Abs(n.y)))
+	}
+	scale := 0.001
+	if maxAbs*scale > 1 {
+		scale = 1 / maxAbs
+	}
+	location := func(n node) schema.Location {
+		return schema.Location{Lon: n.x * scale, Lat: n.y * scale}
+	}
+	at := func(value float64) time.Time {
+		return epoch.Add(time.Duration(value * float64(unit)))
+	}
+
+	depot := i.nodes[0]
+	customers := i.nodes[1:]
+
+	input := schema.Input{
+		CustomData: InputCustomData{
+			Name:     i.name,
+			Type:     i.kind,
+			Rounding: rounding.String(),
+		},
+		Stops:    make([]schema.Stop, len(customers)),
+		Vehicles: make([]schema.Vehicle, vehicles),
+	}
+
+	for idx, c := range customers {
+		stop := schema.Stop{
+			ID:         c.id,
+			Location:   location(c),
+			CustomData: CustomData{X: c.x, Y: c.y},
+		}
+		if c.demand != 0 {
+			stop.Quantity = -c.demand
+		}
+		if c.service > 0 {
+			duration := int(math.Round(c.service * unit.Seconds()))
+			stop.Duration = &duration
+		}
+		if i.hasWindows {
+			stop.StartTimeWindow = []any{
+				at(c.ready).Format(time.RFC3339),
+				at(c.due).Format(time.RFC3339),
+			}
+		}
+		if c.precedes != "" {
+			stop.Precedes = c.precedes
+		}
+		input.Stops[idx] = stop
+	}
+
+	depotLocation := location(depot)
+	digits := len(strconv.Itoa(vehicles))
+	for v := range input.Vehicles {
+		vehicle := schema.Vehicle{
+			ID:            fmt.Sprintf("vehicle-%0*d", digits, v+1),
+			StartLocation: &depotLocation,
+			EndLocation:   &depotLocation,
+		}
+		if i.capacity > 0 {
+			vehicle.Capacity = i.capacity
+		}
+		if i.hasWindows {
+			start, end := at(depot.ready), at(depot.due)
+			vehicle.StartTime = &start
+			vehicle.EndTime = &end
+		}
+		input.Vehicles[v] = vehicle
+	}
+
+	// The matrix indices are the customers followed by the start and end of
+	// each vehicle, both at the depot (node 0).
+	nodeIndices := make([]int, len(customers)+2*vehicles)
+	for idx := range customers {
+		nodeIndices[idx] = idx + 1
+	}
+
+	distances := make([][]float64, len(i.nodes))
+	for from := range i.nodes {
+		distances[from] = make([]float64, len(i.nodes))
+		for to := range i.nodes {
+			distances[from][to] = i.distance(from, to, rounding)
+		}
+	}
+
+	distance := make([][]float64, len(nodeIndices))
+	duration := make([][]float64, len(nodeIndices))
+	for from, fromNode := range nodeIndices {
+		distance[from] = make([]float64, len(nodeIndices))
+		duration[from] = make([]float64, len(nodeIndices))
+		for to, toNode := range nodeIndices {
+			distance[from][to] = distances[fromNode][toNode]
+			duration[from][to] = distances[fromNode][toNode] / speed * unit.Seconds()
+		}
+	}
+	input.DistanceMatrix = &distance
+	input.DurationMatrix = &duration
+
+	return input, nil
+}
+
+// fields splits a line into numbers. Returns false if the line is empty or
+// contains something that is not a number.
+func fields(line string) ([]float64, bool) {
+	parts := strings.Fields(line)
+	if len(parts) == 0 {
+		return nil, false
+	}
+	values := make([]float64, len(parts))
+	for idx, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return nil, false
+		}
+		values[idx] = value
+	}
+	return values, true
+}
+
+// formatID formats a node number of an instance as a stop ID.
+func formatID(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,96 @@
+// © 2019-present nextmv.io inc
+
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// ReadLiLim reads a Li & Lim PDPTW instance. The first line holds the number
+// of vehicles, their capacity and speed. Each following line is a task:
+// number, x, y, demand, earliest time, latest time, service time, pickup
+// sibling and delivery sibling. The first task is the depot. A pickup has a
+// delivery sibling and precedes it on the same route.
+func ReadLiLim(reader io.Reader, name string, options Options) (schema.Input, error) {
+	instance := instance{
+		name:       name,
+		kind:       "lilim",
+		rounding:   RoundingNone,
+		hasWindows: true,
+	}
+
+	var deliveries []int
+	positions := map[int]int{}
+	scanner := bufio.NewScanner(reader)
+	header := true
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		values, ok := fields(text)
+		if !ok {
+			return schema.Input{}, fmt.Errorf("line %v: expected numbers", line)
+		}
+
+		if header {
+			if len(values) < 2 {
+				return schema.Input{}, fmt.Errorf(
+					"line %v: expected number of vehicles, capacity and speed",
+					line,
+				)
+			}
+			instance.vehicles = int(values[0])
+			instance.capacity = values[1]
+			if len(values) > 2 {
+				instance.speed = values[2]
+			}
+			header = false
+			continue
+		}
+
+		if len(values) != 9 {
+			return schema.Input{}, fmt.Errorf(
+				"line %v: expected 9 values for a task, got %v",
+				line,
+				len(values),
+			)
+		}
+		instance.nodes = append(instance.nodes, node{
+			id:      formatID(values[0]),
+			x:       values[1],
+			y:       values[2],
+			demand:  values[3],
+			ready:   values[4],
+			due:     values[5],
+			service: values[6],
+		})
+		positions[int(values[0])] = len(instance.nodes) - 1
+		deliveries = append(deliveries, int(values[8]))
+	}
+	if err := scanner.Err(); err != nil {
+		return schema.Input{}, err
+	}
+
+	for idx, delivery := range deliveries {
+		if idx == 0 || delivery == 0 {
+			continue
+		}
+		position, ok := positions[delivery]
+		if !ok || position == 0 {
+			return schema.Input{}, fmt.Errorf(
+				"task %s has delivery sibling %v which does not exist",
+				instance.nodes[idx].id,
+				delivery,
+			)
+		}
+		instance.nodes[idx].precedes = instance.nodes[position].id
+	}
+
+	return instance.toInput(options)
+}
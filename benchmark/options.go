@@ -0,0 +1,121 @@
+// © 2019-present nextmv.io inc
+
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Rounding is the convention used to round the Euclidean distances of an
+// instance. Published best-known solutions are computed with a specific
+// convention, the same has to be used to compare costs.
+type Rounding int
+
+const (
+	// RoundingDefault uses the convention of the instance format: exact
+	// distances for Solomon, Homberger and Li & Lim, the convention of the
+	// edge weight type for TSPLIB and CVRPLIB.
+	RoundingDefault Rounding = iota
+	// RoundingNone keeps the exact distances, used for the Solomon,
+	// Homberger and Li & Lim best-known solutions.
+	RoundingNone
+	// RoundingNearest rounds to the nearest integer, the TSPLIB `nint`
+	// convention used by CVRPLIB.
+	RoundingNearest
+	// RoundingTruncate rounds down to the next integer.
+	RoundingTruncate
+	// RoundingOneDecimal truncates to one decimal, a convention used in
+	// parts of the VRPTW literature.
+	RoundingOneDecimal
+	// RoundingCeil rounds up to the next integer.
+	RoundingCeil
+)
+
+// Round applies the rounding convention to a value.
+func (r Rounding) Round(value float64) float64 {
+	switch r {
+	case RoundingNearest:
+		return math.Floor(value + 0.5)
+	case RoundingTruncate:
+		return math.Floor(value)
+	case RoundingOneDecimal:
+		return math.Floor(value*10) / 10
+	case RoundingCeil:
+		return math.Ceil(value)
+	}
+	return value
+}
+
+// String returns the name of the rounding convention.
+func (r Rounding) String() string {
+	switch r {
+	case RoundingDefault:
+		return "default"
+	case RoundingNone:
+		return "none"
+	case RoundingNearest:
+		return "nearest"
+	case RoundingTruncate:
+		return "truncate"
+	case RoundingOneDecimal:
+		return "one_decimal"
+	case RoundingCeil:
+		return "ceil"
+	}
+	return fmt.Sprintf("Rounding(%d)", int(r))
+}
+
+// ParseRounding returns the rounding convention with the given name, see
+// [Rounding.String].
+func ParseRounding(name string) (Rounding, error) {
+	for r := RoundingDefault; r <= RoundingCeil; r++ {
+		if r.String() == name {
+			return r, nil
+		}
+	}
+	return RoundingDefault, fmt.Errorf("unknown rounding %s", name)
+}
+
+// Options configure how a benchmark instance is converted to an input.
+type Options struct {
+	// Epoch is the time that corresponds to time zero of the instance. If
+	// zero, 2000-01-01T00:00:00Z is used.
+	Epoch time.Time
+	// TimeUnit is the duration of a time unit of the instance, travel and
+	// service times are multiplied by it. If zero, a minute is used. Time
+	// windows must be on a minute boundary, so a smaller unit only works for
+	// instances with time windows on multiples of a minute.
+	TimeUnit time.Duration
+	// Rounding is the convention used to round the distances. If
+	// [RoundingDefault], the convention of the instance format is used.
+	Rounding Rounding
+	// Vehicles overrides the number of vehicles of the instance if positive.
+	// CVRPLIB instances state the minimal number of vehicles at most, without
+	// it each customer gets a vehicle.
+	Vehicles int
+}
+
+var defaultEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (o Options) epoch() time.Time {
+	if o.Epoch.IsZero() {
+		return defaultEpoch
+	}
+	return o.Epoch
+}
+
+func (o Options) rounding(instance Rounding) Rounding {
+	if o.Rounding == RoundingDefault {
+		return instance
+	}
+	return o.Rounding
+}
+
+func (o Options) timeUnit() time.Duration {
+	if o.TimeUnit <= 0 {
+		return time.Minute
+	}
+	return o.TimeUnit
+}
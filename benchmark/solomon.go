@@ -0,0 +1,94 @@
+// © 2019-present nextmv.io inc
+
+package benchmark
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// ReadSolomon reads a Solomon or Homberger & Gehring VRPTW instance. The
+// instance starts with its name followed by a VEHICLE section holding the
+// number of vehicles and their capacity, and a CUSTOMER section with a line
+// per customer: number, x, y, demand, ready time, due date and service
+// time. The first customer is the depot.
+func ReadSolomon(reader io.Reader, options Options) (schema.Input, error) {
+	instance := instance{
+		kind:       "solomon",
+		rounding:   RoundingNone,
+		hasWindows: true,
+		speed:      1,
+	}
+
+	section := ""
+	scanner := bufio.NewScanner(reader)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if instance.name == "" {
+			instance.name = text
+			continue
+		}
+
+		switch upper := strings.ToUpper(text); {
+		case strings.HasPrefix(upper, "VEHICLE"):
+			section = "vehicle"
+			continue
+		case strings.HasPrefix(upper, "CUSTOMER"):
+			section = "customer"
+			continue
+		}
+
+		values, ok := fields(text)
+		if !ok {
+			// Column headers such as NUMBER CAPACITY.
+			continue
+		}
+
+		switch section {
+		case "vehicle":
+			if len(values) != 2 {
+				return schema.Input{}, fmt.Errorf(
+					"line %v: expected number of vehicles and capacity, got %v values",
+					line,
+					len(values),
+				)
+			}
+			instance.vehicles = int(values[0])
+			instance.capacity = values[1]
+		case "customer":
+			if len(values) != 7 {
+				return schema.Input{}, fmt.Errorf(
+					"line %v: expected 7 values for a customer, got %v",
+					line,
+					len(values),
+				)
+			}
+			instance.nodes = append(instance.nodes, node{
+				id:      formatID(values[0]),
+				x:       values[1],
+				y:       values[2],
+				demand:  values[3],
+				ready:   values[4],
+				due:     values[5],
+				service: values[6],
+			})
+		default:
+			return schema.Input{}, fmt.Errorf(
+				"line %v: values outside of the VEHICLE and CUSTOMER sections",
+				line,
+			)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return schema.Input{}, err
+	}
+
+	return instance.toInput(options)
+}
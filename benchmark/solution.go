@@ -0,0 +1,111 @@
+// © 2019-present nextmv.io inc
+
+package benchmark
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// route is a non-empty route of a solution.
+type route struct {
+	stops    []string
+	distance float64
+}
+
+// routes returns the non-empty routes of the solution and their distance
+// using the distance matrix of the input. The input must have been read by
+// this package, the matrix is indexed by the stops followed by the start and
+// end of each vehicle.
+func routes(
+	input schema.Input,
+	solution schema.SolutionOutput,
+) ([]route, error) {
+	if input.DistanceMatrix == nil {
+		return nil, errors.New("input has no distance matrix")
+	}
+	matrix := *input.DistanceMatrix
+
+	indices := make(map[string]int, len(input.Stops)+2*len(input.Vehicles))
+	for idx, stop := range input.Stops {
+		indices[stop.ID] = idx
+	}
+	vehicles := make(map[string]int, len(input.Vehicles))
+	for idx, vehicle := range input.Vehicles {
+		vehicles[vehicle.ID] = idx
+	}
+
+	result := make([]route, 0, len(solution.Vehicles))
+	for _, vehicle := range solution.Vehicles {
+		v, ok := vehicles[vehicle.ID]
+		if !ok {
+			return nil, fmt.Errorf("vehicle %s is not part of the input", vehicle.ID)
+		}
+		start := len(input.Stops) + 2*v
+		end := start + 1
+
+		r := route{}
+		previous := start
+		for _, planned := range vehicle.Route {
+			index, ok := indices[planned.Stop.ID]
+			if !ok {
+				// The start and end stops of the vehicle.
+				continue
+			}
+			r.stops = append(r.stops, planned.Stop.ID)
+			r.distance += matrix[previous][index]
+			previous = index
+		}
+		if len(r.stops) == 0 {
+			continue
+		}
+		r.distance += matrix[previous][end]
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// WriteSolution writes a solution of an input read by [ReadSolomon] or
+// [ReadLiLim] in the notation used to report benchmark results: a line per
+// non-empty route listing the stops in the order they are visited, followed
+// by the number of vehicles and the total distance. Unplanned stops, if any,
+// are listed last.
+//
+//	Route 1 : 5 3 7 8 10 11 9 6 4 2 1
+//	Route 2 : 13 17 18 19 15 16 14 12
+//	Vehicles : 2
+//	Distance : 191.81
+func WriteSolution(
+	writer io.Writer,
+	input schema.Input,
+	solution schema.SolutionOutput,
+) error {
+	routes, err := routes(input, solution)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	total := 0.0
+	for idx, r := range routes {
+		fmt.Fprintf(&sb, "Route %d : %s\n", idx+1, strings.Join(r.stops, " "))
+		total += r.distance
+	}
+	fmt.Fprintf(&sb, "Vehicles : %d\n", len(routes))
+	fmt.Fprintf(&sb, "Distance : %s\n", strconv.FormatFloat(total, 'f', 2, 64))
+	if len(solution.Unplanned) > 0 {
+		unplanned := make([]string, len(solution.Unplanned))
+		for idx, stop := range solution.Unplanned {
+			unplanned[idx] = stop.ID
+		}
+		fmt.Fprintf(&sb, "Unplanned : %s\n", strings.Join(unplanned, " "))
+	}
+
+	_, err = io.WriteString(writer, sb.String())
+	return err
+}
@@ -59,8 +59,9 @@ func SolutionCheck(
 	}
 
 	nextCheck := &checkImpl{
-		solution:  solution,
-		verbosity: verbosity,
+		solution:    solution,
+		verbosity:   verbosity,
+		relaxations: options.Relaxations,
 		output: schema.Output{
 			DurationMaximum: options.Duration.Seconds(),
 			Verbosity:       verbosity.String(),
@@ -86,9 +87,10 @@ func SolutionCheck(
 }
 
 type checkImpl struct {
-	solution  nextroute.Solution
-	output    schema.Output
-	verbosity Verbosity
+	solution    nextroute.Solution
+	output      schema.Output
+	verbosity   Verbosity
+	relaxations bool
 }
 
 func (m *checkImpl) checkStartSolution() {
@@ -341,6 +343,12 @@ SolutionPlanUnitLoop:
 					}
 					constraints[name]++
 				}
+				if m.relaxations {
+					m.output.Relaxations = append(
+						m.output.Relaxations,
+						m.relax(ctx, solutionPlanUnit),
+					)
+				}
 			}
 		}
 		m.output.Summary.PlanUnitsChecked++
@@ -17,5 +17,20 @@ initial stops that are fixed, initial stops that are not fixed are not added
 to the solution. The check is executed on the unplanned plan units of the
 solution. If the check is invoked on a solution, it is executed on the
 unplanned plan units of the solution.
+
+If relaxations are enabled in the Options, the check suggests for each plan
+unit that cannot be added to the solution the minimal relaxation per
+constraint that would allow adding it, for example the additional capacity or
+the seconds by which to widen a time window. Constraints opt in by
+implementing nextroute.ConstraintRelaxer.
+
+StaticAnalysis finds the stops of a model that can never be planned without
+solving, for example stops with a quantity above every capacity or stops that
+cannot be reached in time by any vehicle. It can be used by the factory to fail
+creating such a model, see NewStaticAnalyzer.
+
+A plan, routes created by another planner, is checked with PlanCheck. It
+creates a solution from the routes without solving and reports every
+constraint each stop of the plan violates, and the objective of the solution.
 */
 package check
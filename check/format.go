@@ -6,8 +6,11 @@ import (
 	"context"
 
 	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/check/schema"
 	"github.com/nextmv-io/nextroute/factory"
+	nextrouteSchema "github.com/nextmv-io/nextroute/schema"
 	runSchema "github.com/nextmv-io/sdk/run/schema"
+	"github.com/nextmv-io/sdk/run/statistics"
 )
 
 // Format formats a solution in a basic format using factory.ToSolutionOutput
@@ -41,3 +44,51 @@ func Format(
 		solutions...,
 	), nil
 }
+
+// FormatPlan formats the solution created from a plan by [PlanCheck] in a
+// basic format using factory.ToSolutionOutput and adds the check of the plan
+// to the output of the solution.
+func FormatPlan(
+	ctx context.Context,
+	options any,
+	solution nextroute.Solution,
+	planOutput schema.PlanOutput,
+) runSchema.Output {
+	output := nextroute.Format(
+		ctx,
+		options,
+		nil,
+		func(solution nextroute.Solution) any {
+			solutionOutput := factory.ToSolutionOutput(solution)
+			solutionOutput.Check = &schema.Output{
+				Remark: "completed",
+				Plan:   &planOutput,
+			}
+			return solutionOutput
+		},
+		solution,
+	)
+	value := statistics.Float64(solution.Score())
+	output.Statistics.Result = &statistics.Result{
+		Value:  &value,
+		Custom: factory.DefaultCustomResultStatistics(solution),
+	}
+	return output
+}
+
+// FormatStatic adds the stops that can never be planned according to
+// [StaticAnalysis] to the check of each solution of the output.
+func FormatStatic(output runSchema.Output, stops []schema.StaticStop) runSchema.Output {
+	for idx, solution := range output.Solutions {
+		solutionOutput, ok := solution.(nextrouteSchema.SolutionOutput)
+		if !ok {
+			continue
+		}
+		if solutionOutput.Check == nil {
+			solutionOutput.Check = &schema.Output{Remark: "completed"}
+		}
+		solutionOutput.Check.Static = stops
+		output.Solutions[idx] = solutionOutput
+	}
+	return output
+}
@@ -6,6 +6,8 @@ import "time"
 
 // Options are the options for a check.
 type Options struct {
-	Duration  time.Duration `json:"duration" usage:"maximum duration of the check" default:"30s"`
-	Verbosity string        `json:"verbosity"  usage:"{off, low, medium, high} verbosity of the check" default:"off"`
+	Duration    time.Duration `json:"duration" usage:"maximum duration of the check" default:"30s"`
+	Verbosity   string        `json:"verbosity"  usage:"{off, low, medium, high} verbosity of the check" default:"off"`
+	Relaxations bool          `json:"relaxations" usage:"suggest the minimal relaxation per constraint for plan units that cannot be planned"`
+	Plan        string        `json:"plan" usage:"path to a plan to check instead of solving, a JSON file with the ordered stops of each vehicle"`
 }
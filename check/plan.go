@@ -0,0 +1,213 @@
+// © 2019-present nextmv.io inc
+
+package check
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/check/schema"
+	"github.com/nextmv-io/nextroute/factory"
+)
+
+// planConstraint is the name of the violations of an invalid plan.
+const planConstraint = "plan"
+
+// PlanCheck creates a solution from the routes of the plan without solving
+// and checks it. The routes are loaded with [factory.LoadRoutes], a stop
+// that violates a constraint is reported with every constraint it violates
+// and stays unplanned. Constraints implementing
+// [nextroute.ConstraintExplainer] explain why they are violated. Stops that
+// cannot be planned as in the plan for another reason are reported as
+// violations of the plan.
+//
+// The output reports the violations per stop and vehicle and the objective
+// of the solution, also per vehicle.
+func PlanCheck(
+	model nextroute.Model,
+	plan schema.Plan,
+) (nextroute.Solution, schema.PlanOutput, error) {
+	if model == nil {
+		return nil, schema.PlanOutput{}, fmt.Errorf("model is nil")
+	}
+
+	observer := newObserver()
+	model.AddSolutionObserver(observer)
+	defer model.RemoveSolutionObserver(observer)
+
+	routes := make([]factory.Route, len(plan.Vehicles))
+	for idx, planVehicle := range plan.Vehicles {
+		routes[idx] = factory.Route{
+			VehicleID: planVehicle.ID,
+			StopIDs:   planVehicle.Stops,
+		}
+	}
+	loaded, err := factory.LoadRoutes(
+		model,
+		routes,
+		func(move nextroute.SolutionMoveStops) (bool, []factory.RouteViolation, error) {
+			return execute(observer, move)
+		},
+	)
+	if err != nil {
+		return nil, schema.PlanOutput{}, err
+	}
+
+	return loaded.Solution, planOutput(plan, loaded), nil
+}
+
+// execute plans the move if no constraint is estimated to be violated.
+// Returns every constraint that is estimated to be violated, or the
+// constraint that is violated once planned.
+func execute(
+	observer Observer,
+	move nextroute.SolutionMoveStops,
+) (bool, []factory.RouteViolation, error) {
+	var violations []factory.RouteViolation
+	for _, constraint := range move.Solution().Model().Constraints() {
+		if violated, _ := constraint.EstimateIsViolated(move); violated {
+			violations = append(violations, violation(constraint, move))
+		}
+	}
+	if len(violations) > 0 {
+		return false, violations, nil
+	}
+
+	observer.Reset()
+	planned, err := move.Execute(context.Background())
+	if err != nil || planned {
+		return planned, nil, err
+	}
+	for _, constraint := range observer.OnPlanFailedConstraints() {
+		violations = append(violations, violation(constraint, move))
+	}
+	return false, violations, nil
+}
+
+// planOutput reports the violations of the loaded routes per stop and
+// vehicle of the plan, the planned stops, the feasibility and the objective.
+func planOutput(plan schema.Plan, loaded factory.LoadedRoutes) schema.PlanOutput {
+	output := schema.PlanOutput{
+		Feasible:  len(loaded.Violations) == 0,
+		Vehicles:  make([]schema.PlanVehicleCheck, len(plan.Vehicles)),
+		Objective: solutionObjective(loaded.Solution),
+		Unplanned: []string{},
+	}
+	for vehicleIdx, planVehicle := range plan.Vehicles {
+		output.Vehicles[vehicleIdx] = schema.PlanVehicleCheck{
+			ID:    planVehicle.ID,
+			Stops: make([]schema.PlanStopCheck, len(planVehicle.Stops)),
+		}
+		for stopIdx, id := range planVehicle.Stops {
+			output.Vehicles[vehicleIdx].Stops[stopIdx].ID = id
+		}
+	}
+	for _, routeViolation := range loaded.Violations {
+		v := schema.Violation{
+			Constraint: routeViolation.Constraint,
+			Reason:     routeViolation.Reason,
+		}
+		if v.Constraint == "" {
+			v.Constraint = planConstraint
+		}
+		vehicleCheck := &output.Vehicles[routeViolation.Route]
+		if routeViolation.Position < 0 {
+			vehicleCheck.Violations = append(vehicleCheck.Violations, v)
+			continue
+		}
+		stopCheck := &vehicleCheck.Stops[routeViolation.Position]
+		stopCheck.Violations = append(stopCheck.Violations, v)
+	}
+
+	vehicles := make(map[string]nextroute.SolutionVehicle, len(plan.Vehicles))
+	for _, vehicle := range loaded.Solution.Vehicles() {
+		vehicles[vehicle.ModelVehicle().ID()] = vehicle
+	}
+	for vehicleIdx := range output.Vehicles {
+		vehicleCheck := &output.Vehicles[vehicleIdx]
+		for stopIdx := range vehicleCheck.Stops {
+			stopCheck := &vehicleCheck.Stops[stopIdx]
+			modelStop := loaded.Stops[vehicleIdx][stopIdx]
+			stopCheck.Planned = modelStop != nil &&
+				len(stopCheck.Violations) == 0 &&
+				loaded.Solution.SolutionStop(modelStop).IsPlanned()
+		}
+		if len(vehicleCheck.Violations) > 0 {
+			continue
+		}
+		if vehicle, ok := vehicles[vehicleCheck.ID]; ok {
+			vehicleCheck.Objective = vehicleObjective(vehicle)
+		}
+	}
+
+	for _, solutionPlanUnit := range loaded.Solution.UnPlannedPlanUnits().SolutionPlanUnits() {
+		if solutionPlanUnit.IsPlanned() {
+			continue
+		}
+		output.Unplanned = append(output.Unplanned, toID(solutionPlanUnit.ModelPlanUnit())...)
+	}
+	sort.Strings(output.Unplanned)
+	return output
+}
+
+// solutionObjective returns the objective of the solution per term.
+func solutionObjective(solution nextroute.Solution) schema.Objective {
+	objective := schema.Objective{
+		Value: solution.Score(),
+		Terms: make([]schema.ObjectiveTerm, 0, len(solution.Model().Objective().Terms())),
+	}
+	for _, term := range solution.Model().Objective().Terms() {
+		value := solution.ObjectiveValue(term.Objective())
+		objective.Terms = append(objective.Terms, schema.ObjectiveTerm{
+			Name:   fmt.Sprintf("%v", term.Objective()),
+			Factor: term.Factor(),
+			Base:   value / term.Factor(),
+			Value:  value,
+		})
+	}
+	return objective
+}
+
+// vehicleObjective returns the contribution of the vehicle to the terms of
+// the objective as in the output of a solution, see
+// [factory.ToVehicleObjectiveOutput]. Returns nil if the vehicle is empty.
+func vehicleObjective(vehicle nextroute.SolutionVehicle) *schema.Objective {
+	if vehicle.IsEmpty() {
+		return nil
+	}
+	id := vehicle.ModelVehicle().ID()
+	objective := &schema.Objective{
+		Vehicle: &id,
+		Terms:   []schema.ObjectiveTerm{},
+	}
+	output := factory.ToVehicleObjectiveOutput(vehicle)
+	if output == nil {
+		return objective
+	}
+	objective.Value = output.Value
+	for _, term := range output.Objectives {
+		objective.Terms = append(objective.Terms, schema.ObjectiveTerm{
+			Name:   term.Name,
+			Factor: term.Factor,
+			Base:   term.Base,
+			Value:  term.Value,
+		})
+	}
+	return objective
+}
+
+// violation returns the violation of the constraint by the move.
+func violation(
+	constraint nextroute.ModelConstraint,
+	move nextroute.SolutionMoveStops,
+) factory.RouteViolation {
+	v := factory.RouteViolation{
+		Constraint: fmt.Sprintf("%v", constraint),
+	}
+	if explainer, ok := constraint.(nextroute.ConstraintExplainer); ok {
+		v.Reason = explainer.ExplainViolation(move)
+	}
+	return v
+}
@@ -0,0 +1,216 @@
+// © 2019-present nextmv.io inc
+
+package check_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nextmv-io/nextroute/check"
+	checkSchema "github.com/nextmv-io/nextroute/check/schema"
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+const planInput = `{
+	"defaults": {
+		"vehicles": {
+			"speed": 10,
+			"capacity": 2,
+			"start_location": {"lon": 7.60, "lat": 51.95},
+			"start_time": "2023-01-01T08:00:00Z"
+		}
+	},
+	"stops": [
+		{"id": "s1", "location": {"lon": 7.61, "lat": 51.96}, "quantity": -1},
+		{"id": "s2", "location": {"lon": 7.62, "lat": 51.97}, "quantity": -1},
+		{"id": "s3", "location": {"lon": 7.63, "lat": 51.98}, "quantity": -1},
+		{
+			"id": "s4",
+			"location": {"lon": 7.64, "lat": 51.99},
+			"start_time_window": ["2023-01-01T08:00:00Z", "2023-01-01T08:05:00Z"]
+		},
+		{"id": "p1", "location": {"lon": 7.65, "lat": 51.96}, "quantity": -1},
+		{"id": "d1", "location": {"lon": 7.66, "lat": 51.97}, "quantity": 1, "succeeds": "p1"}
+	],
+	"vehicles": [{"id": "v1"}, {"id": "v2"}]
+}`
+
+func planModelOptions() factory.Options {
+	options := factory.Options{}
+	options.Objectives.VehiclesDuration = 1
+	options.Objectives.UnplannedPenalty = 1
+	return options
+}
+
+func checkPlan(t *testing.T, plan checkSchema.Plan) checkSchema.PlanOutput {
+	var input schema.Input
+	if err := json.Unmarshal([]byte(planInput), &input); err != nil {
+		t.Fatal(err)
+	}
+	model, err := factory.NewModel(input, planModelOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, output, err := check.PlanCheck(model, plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solution.Score() != output.Objective.Value {
+		t.Errorf("objective %v, want score %v", output.Objective.Value, solution.Score())
+	}
+	return output
+}
+
+func TestPlanCheckFeasible(t *testing.T) {
+	output := checkPlan(t, checkSchema.Plan{
+		Vehicles: []checkSchema.PlanVehicle{
+			{ID: "v1", Stops: []string{"s1", "p1", "d1", "s2"}},
+			{ID: "v2", Stops: []string{"s3"}},
+		},
+	})
+	if !output.Feasible {
+		t.Fatalf("expected a feasible plan, got %+v", output)
+	}
+	for _, vehicle := range output.Vehicles {
+		for _, stop := range vehicle.Stops {
+			if !stop.Planned {
+				t.Errorf("expected stop %s to be planned", stop.ID)
+			}
+		}
+		if vehicle.Objective == nil || vehicle.Objective.Value <= 0 {
+			t.Errorf("expected an objective for vehicle %s", vehicle.ID)
+		}
+	}
+	if len(output.Unplanned) != 1 || output.Unplanned[0] != "s4" {
+		t.Errorf("expected s4 to be unplanned, got %v", output.Unplanned)
+	}
+}
+
+func TestPlanCheckViolations(t *testing.T) {
+	output := checkPlan(t, checkSchema.Plan{
+		Vehicles: []checkSchema.PlanVehicle{
+			{ID: "v1", Stops: []string{"s1", "s2", "s3", "x1", "s1"}},
+			{ID: "v2", Stops: []string{"d1", "p1", "s4"}},
+			{ID: "v3", Stops: []string{}},
+		},
+	})
+	if output.Feasible {
+		t.Fatal("expected an infeasible plan")
+	}
+
+	violations := map[string]checkSchema.PlanStopCheck{}
+	for _, vehicle := range output.Vehicles[:2] {
+		for _, stop := range vehicle.Stops {
+			if _, ok := violations[vehicle.ID+"/"+stop.ID]; !ok {
+				violations[vehicle.ID+"/"+stop.ID] = stop
+			}
+		}
+	}
+	tests := []struct {
+		stop       string
+		planned    bool
+		constraint string
+		reason     string
+	}{
+		{stop: "v1/s1", planned: true},
+		{stop: "v1/s2", planned: true},
+		{stop: "v1/s3", constraint: "capacity_default", reason: "above the maximum 2"},
+		{stop: "v1/x1", constraint: "plan", reason: "not part of the model"},
+		{stop: "v2/d1", constraint: "plan", reason: "Directed Acyclic Graph"},
+		{stop: "v2/p1", constraint: "plan", reason: "Directed Acyclic Graph"},
+		{stop: "v2/s4", constraint: "late_start_penalty", reason: "after the latest start"},
+	}
+	for _, test := range tests {
+		stop := violations[test.stop]
+		if stop.Planned != test.planned {
+			t.Errorf("stop %s: planned %v, want %v", test.stop, stop.Planned, test.planned)
+		}
+		if test.constraint == "" {
+			continue
+		}
+		if len(stop.Violations) == 0 {
+			t.Errorf("stop %s: expected a violation", test.stop)
+			continue
+		}
+		if stop.Violations[0].Constraint != test.constraint ||
+			!strings.Contains(stop.Violations[0].Reason, test.reason) {
+			t.Errorf(
+				"stop %s: violation %+v, want %s containing %q",
+				test.stop,
+				stop.Violations[0],
+				test.constraint,
+				test.reason,
+			)
+		}
+	}
+
+	duplicate := output.Vehicles[0].Stops[4]
+	if len(duplicate.Violations) != 1 ||
+		!strings.Contains(duplicate.Violations[0].Reason, "already part of the route") {
+		t.Errorf("expected a duplicate stop violation, got %+v", duplicate)
+	}
+	if len(output.Vehicles[2].Violations) != 1 {
+		t.Errorf("expected an unknown vehicle violation, got %+v", output.Vehicles[2])
+	}
+}
+
+func TestPlanCheckGroup(t *testing.T) {
+	var input schema.Input
+	err := json.Unmarshal([]byte(`{
+		"stops": [
+			{"id": "a", "location": {"lon": 7.60, "lat": 51.90}},
+			{"id": "b", "location": {"lon": 7.61, "lat": 51.90}},
+			{"id": "c", "location": {"lon": 7.62, "lat": 51.90}}
+		],
+		"stop_groups": [["a", "b"]],
+		"vehicles": [{"id": "v1", "speed": 10}, {"id": "v2", "speed": 10}]
+	}`), &input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := factory.NewModel(input, planModelOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		plan     checkSchema.Plan
+		feasible bool
+	}{
+		{
+			plan: checkSchema.Plan{Vehicles: []checkSchema.PlanVehicle{
+				{ID: "v1", Stops: []string{"a", "c", "b"}},
+			}},
+			feasible: true,
+		},
+		{
+			plan: checkSchema.Plan{Vehicles: []checkSchema.PlanVehicle{
+				{ID: "v1", Stops: []string{"a", "c"}},
+				{ID: "v2", Stops: []string{"b"}},
+			}},
+		},
+		{
+			plan: checkSchema.Plan{Vehicles: []checkSchema.PlanVehicle{
+				{ID: "v1", Stops: []string{"a"}},
+			}},
+		},
+	}
+	for idx, test := range tests {
+		solution, output, err := check.PlanCheck(model, test.plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.Feasible != test.feasible {
+			t.Errorf("plan %d: feasible %v, want %v", idx, output.Feasible, test.feasible)
+		}
+		planned := solution.PlannedPlanUnits().Size()
+		if test.feasible && planned != 2 {
+			t.Errorf("plan %d: expected the group and c to be planned, got %d", idx, planned)
+		}
+		if !test.feasible && output.Vehicles[0].Stops[0].Planned {
+			t.Errorf("plan %d: expected stop a of the group to be unplanned", idx)
+		}
+	}
+}
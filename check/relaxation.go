@@ -0,0 +1,190 @@
+// © 2019-present nextmv.io inc
+
+package check
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/check/schema"
+)
+
+// relax returns the minimal relaxation per constraint that allows the plan
+// unit to be planned. Every insertion of the plan unit on every vehicle is
+// evaluated. An insertion violating a single constraint that implements
+// [nextroute.ConstraintRelaxer] is a candidate, the candidate with the
+// smallest relaxation and then the smallest delta objective is reported per
+// constraint. Plan units planning all of their plan units are not relaxed.
+func (m *checkImpl) relax(
+	ctx context.Context,
+	solutionPlanUnit nextroute.SolutionPlanUnit,
+) schema.Relaxation {
+	relaxation := schema.Relaxation{
+		Stops:       toID(solutionPlanUnit.ModelPlanUnit()),
+		Constraints: []schema.ConstraintRelaxation{},
+	}
+
+	var planStopsUnits []nextroute.SolutionPlanStopsUnit
+	switch planUnit := solutionPlanUnit.(type) {
+	case nextroute.SolutionPlanStopsUnit:
+		planStopsUnits = append(planStopsUnits, planUnit)
+	case nextroute.SolutionPlanUnitsUnit:
+		if !planUnit.ModelPlanUnitsUnit().PlanOneOf() {
+			return relaxation
+		}
+		for _, child := range planUnit.SolutionPlanUnits() {
+			if planStopsUnit, ok := child.(nextroute.SolutionPlanStopsUnit); ok {
+				planStopsUnits = append(planStopsUnits, planStopsUnit)
+			}
+		}
+	}
+
+	best := make(map[string]*schema.ConstraintRelaxation)
+	for _, planStopsUnit := range planStopsUnits {
+		for _, sequence := range sequences(planStopsUnit) {
+			for _, vehicle := range m.solution.Vehicles() {
+				if ctx.Err() != nil {
+					return withConstraints(relaxation, best)
+				}
+				insertions(vehicle, sequence, func(stopPositions nextroute.StopPositions) {
+					candidate, ok := m.relaxMove(planStopsUnit, stopPositions)
+					if !ok {
+						return
+					}
+					current, ok := best[candidate.Constraint]
+					if !ok ||
+						candidate.Relaxation < current.Relaxation ||
+						candidate.Relaxation == current.Relaxation &&
+							candidate.DeltaObjective < current.DeltaObjective {
+						best[candidate.Constraint] = &candidate
+					}
+				})
+			}
+		}
+	}
+
+	return withConstraints(relaxation, best)
+}
+
+// relaxMove returns the relaxation of the move if it violates a single
+// constraint that can be relaxed.
+func (m *checkImpl) relaxMove(
+	planStopsUnit nextroute.SolutionPlanStopsUnit,
+	stopPositions nextroute.StopPositions,
+) (schema.ConstraintRelaxation, bool) {
+	move, err := nextroute.NewMoveStops(planStopsUnit, stopPositions)
+	if err != nil {
+		return schema.ConstraintRelaxation{}, false
+	}
+
+	var violated nextroute.ModelConstraint
+	for _, constraint := range m.solution.Model().Constraints() {
+		if isViolated, _ := constraint.EstimateIsViolated(move); isViolated {
+			if violated != nil {
+				return schema.ConstraintRelaxation{}, false
+			}
+			violated = constraint
+		}
+	}
+	relaxer, ok := violated.(nextroute.ConstraintRelaxer)
+	if !ok {
+		return schema.ConstraintRelaxation{}, false
+	}
+	amount, ok := relaxer.Relaxation(move)
+	if !ok {
+		return schema.ConstraintRelaxation{}, false
+	}
+
+	deltaObjective := 0.0
+	for _, term := range m.solution.Model().Objective().Terms() {
+		deltaObjective += term.Factor() * term.Objective().EstimateDeltaValue(move)
+	}
+
+	positions := make([]schema.Position, len(stopPositions))
+	for idx, stopPosition := range stopPositions {
+		positions[idx] = schema.Position{
+			Previous: stopPosition.Previous().ModelStop().ID(),
+			Stop:     stopPosition.Stop().ModelStop().ID(),
+			Next:     stopPosition.Next().ModelStop().ID(),
+		}
+	}
+
+	return schema.ConstraintRelaxation{
+		Constraint:     fmt.Sprintf("%v", violated),
+		Relaxation:     amount,
+		VehicleID:      move.Vehicle().ModelVehicle().ID(),
+		Positions:      positions,
+		DeltaObjective: deltaObjective,
+	}, true
+}
+
+// sequences returns the sequences in which the stops of the plan unit can be
+// planned.
+func sequences(planStopsUnit nextroute.SolutionPlanStopsUnit) []nextroute.SolutionStops {
+	quit := make(chan struct{})
+	defer close(quit)
+	var result []nextroute.SolutionStops
+	for sequence := range nextroute.SequenceGeneratorChannel(planStopsUnit, quit) {
+		result = append(result, sequence)
+	}
+	return result
+}
+
+// insertions calls yield with the stop positions of every insertion of the
+// sequence of stops in the route of the vehicle, keeping their order.
+func insertions(
+	vehicle nextroute.SolutionVehicle,
+	sequence nextroute.SolutionStops,
+	yield func(nextroute.StopPositions),
+) {
+	route := vehicle.SolutionStops()
+	// locations[i] is the index in the route of the stop the i-th stop of
+	// the sequence is inserted before.
+	locations := make([]int, len(sequence))
+	var insert func(idx, from int)
+	insert = func(idx, from int) {
+		if idx == len(sequence) {
+			stopPositions := make(nextroute.StopPositions, len(sequence))
+			for i, location := range locations {
+				previous, next := route[location-1], route[location]
+				if i > 0 && locations[i-1] == location {
+					previous = sequence[i-1]
+				}
+				if i < len(locations)-1 && locations[i+1] == location {
+					next = sequence[i+1]
+				}
+				stopPosition, err := nextroute.NewStopPosition(previous, sequence[i], next)
+				if err != nil {
+					return
+				}
+				stopPositions[i] = stopPosition
+			}
+			yield(stopPositions)
+			return
+		}
+		for location := from; location < len(route); location++ {
+			locations[idx] = location
+			insert(idx+1, location)
+		}
+	}
+	insert(0, 1)
+}
+
+// withConstraints returns the relaxation with the given relaxations per
+// constraint, sorted by the name of the constraint.
+func withConstraints(
+	relaxation schema.Relaxation,
+	constraints map[string]*schema.ConstraintRelaxation,
+) schema.Relaxation {
+	names := make([]string, 0, len(constraints))
+	for name := range constraints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		relaxation.Constraints = append(relaxation.Constraints, *constraints[name])
+	}
+	return relaxation
+}
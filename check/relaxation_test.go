@@ -0,0 +1,79 @@
+// © 2019-present nextmv.io inc
+
+package check_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute/check"
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+func TestRelaxations(t *testing.T) {
+	var input schema.Input
+	err := json.Unmarshal([]byte(`{
+		"stops": [
+			{"id": "big", "location": {"lon": 7.61, "lat": 51.96}, "quantity": -3},
+			{
+				"id": "late",
+				"location": {"lon": 7.64, "lat": 51.99},
+				"start_time_window": ["2023-01-01T08:00:00Z", "2023-01-01T08:01:00Z"]
+			},
+			{"id": "fine", "location": {"lon": 7.62, "lat": 51.97}, "quantity": -1}
+		],
+		"vehicles": [{
+			"id": "v1",
+			"speed": 10,
+			"capacity": 2,
+			"start_location": {"lon": 7.60, "lat": 51.95},
+			"start_time": "2023-01-01T08:00:00Z"
+		}]
+	}`), &input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	model, err := factory.NewModel(input, planModelOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := check.ModelCheck(model, check.Options{
+		Duration:    10 * time.Second,
+		Verbosity:   "medium",
+		Relaxations: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(output.Relaxations) != 2 {
+		t.Fatalf("expected 2 relaxations, got %+v", output.Relaxations)
+	}
+	for _, relaxation := range output.Relaxations {
+		if len(relaxation.Stops) != 1 || len(relaxation.Constraints) != 1 {
+			t.Fatalf("expected a single constraint for a single stop, got %+v", relaxation)
+		}
+		constraint := relaxation.Constraints[0]
+		if constraint.VehicleID != "v1" || len(constraint.Positions) != 1 {
+			t.Errorf("unexpected position %+v", constraint)
+		}
+		if constraint.DeltaObjective == 0 {
+			t.Errorf("expected a delta objective for %+v", constraint)
+		}
+		switch relaxation.Stops[0] {
+		case "big":
+			if constraint.Constraint != "capacity_default" || constraint.Relaxation != 1 {
+				t.Errorf("expected a capacity relaxation of 1, got %+v", constraint)
+			}
+		case "late":
+			if constraint.Constraint != "late_start_penalty" || constraint.Relaxation <= 0 {
+				t.Errorf("expected a time window relaxation, got %+v", constraint)
+			}
+		default:
+			t.Errorf("unexpected relaxation for stop %s", relaxation.Stops[0])
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// © 2019-present nextmv.io inc
+
+package schema
+
+// Plan is a plan to check, for example the routes created by another planner.
+type Plan struct {
+	// Vehicles are the routes of the plan.
+	Vehicles []PlanVehicle `json:"vehicles"`
+}
+
+// PlanVehicle is the route of a vehicle in a plan.
+type PlanVehicle struct {
+	// ID is the ID of the vehicle.
+	ID string `json:"id"`
+	// Stops are the IDs of the stops in the order they are visited, without
+	// the start and end of the vehicle.
+	Stops []string `json:"stops"`
+}
+
+// PlanOutput is the check of a plan.
+type PlanOutput struct {
+	// Feasible is true if all the stops of the plan are planned without
+	// violating a constraint.
+	Feasible bool `json:"feasible"`
+	// Objective is the objective of the solution created from the plan. The
+	// stops that cannot be planned are unplanned.
+	Objective Objective `json:"objective"`
+	// Vehicles is the check of the routes of the plan.
+	Vehicles []PlanVehicleCheck `json:"vehicles"`
+	// Unplanned are the IDs of the stops that are not planned, the stops that
+	// are not part of the plan and the stops that cannot be planned.
+	Unplanned []string `json:"unplanned"`
+}
+
+// PlanVehicleCheck is the check of the route of a vehicle in a plan.
+type PlanVehicleCheck struct {
+	// ID is the ID of the vehicle.
+	ID string `json:"id"`
+	// Stops is the check of the stops of the route in the order of the plan.
+	Stops []PlanStopCheck `json:"stops"`
+	// Violations are the violations of the route that cannot be attributed
+	// to a stop.
+	Violations []Violation `json:"violations,omitempty"`
+	// Objective is the contribution of the vehicle to the objective terms
+	// that can be attributed to a vehicle.
+	Objective *Objective `json:"objective,omitempty"`
+}
+
+// PlanStopCheck is the check of a stop in a plan.
+type PlanStopCheck struct {
+	// ID is the ID of the stop.
+	ID string `json:"id"`
+	// Planned is true if the stop is planned at its position in the route.
+	Planned bool `json:"planned"`
+	// Violations are the reasons the stop cannot be planned.
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+// Violation is a reason a stop or route of a plan cannot be planned.
+type Violation struct {
+	// Constraint is the name of the violated constraint. It is "plan" if the
+	// plan itself is invalid, for example if it refers to an unknown stop.
+	Constraint string `json:"constraint"`
+	// Reason explains the violation if the constraint can tell.
+	Reason string `json:"reason,omitempty"`
+}
@@ -24,6 +24,15 @@ type Output struct {
 	PlanUnits []PlanUnit `json:"plan_units"`
 	// Vehicles is the check of the vehicles.
 	Vehicles []Vehicle `json:"vehicles"`
+	// Relaxations are the minimal relaxations of the constraints that allow
+	// planning the plan units that have no move. Only calculated if
+	// relaxations are enabled.
+	Relaxations []Relaxation `json:"relaxations,omitempty"`
+	// Static are the stops that can never be planned according to the static
+	// analysis of the model. Only present if the analysis is reported.
+	Static []StaticStop `json:"static,omitempty"`
+	// Plan is the check of a plan, only present if a plan is checked.
+	Plan *PlanOutput `json:"plan,omitempty"`
 }
 
 // Solution is the solution the check has been executed on.
@@ -173,3 +182,32 @@ type Vehicle struct {
 	// vehicle. Only calculated if the depth is medium.
 	PlanUnitsHaveMoves *int `json:"plan_units_have_moves,omitempty"`
 }
+
+// Relaxation is the check of what to relax to plan a plan unit that has no
+// move.
+type Relaxation struct {
+	// Stops are the IDs of the stops of the plan unit.
+	Stops []string `json:"stops"`
+	// Constraints are the minimal relaxations of the constraints that allow
+	// the plan unit to be planned if only that constraint is relaxed. A
+	// constraint that cannot be relaxed on its own is not part of it.
+	Constraints []ConstraintRelaxation `json:"constraints"`
+}
+
+// ConstraintRelaxation is the minimal relaxation of a constraint that allows
+// a plan unit to be planned.
+type ConstraintRelaxation struct {
+	// Constraint is the name of the constraint.
+	Constraint string `json:"constraint"`
+	// Relaxation is by how much the limit of the constraint has to be relaxed,
+	// for example the capacity of the vehicle. It is in seconds for limits in
+	// time such as time windows and vehicle end times.
+	Relaxation float64 `json:"relaxation"`
+	// VehicleID is the ID of the vehicle the plan unit is planned on.
+	VehicleID string `json:"vehicle_id"`
+	// Positions define where the stops are inserted.
+	Positions []Position `json:"positions"`
+	// DeltaObjective is the estimate of the delta of the objective of
+	// planning the plan unit with the relaxed constraint.
+	DeltaObjective float64 `json:"delta_objective"`
+}
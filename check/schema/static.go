@@ -0,0 +1,23 @@
+// © 2019-present nextmv.io inc
+
+package schema
+
+// StaticStop is a stop that can never be planned according to the static
+// analysis of the model.
+type StaticStop struct {
+	// ID is the ID of the stop.
+	ID string `json:"id"`
+	// Violations are the reasons the stop cannot be planned on any vehicle.
+	Violations []StaticViolation `json:"violations"`
+}
+
+// StaticViolation is a reason a stop cannot be planned on some vehicles.
+type StaticViolation struct {
+	// Constraint is the name of the violated constraint.
+	Constraint string `json:"constraint"`
+	// Reason explains the violation for the first of the vehicles.
+	Reason string `json:"reason"`
+	// Vehicles are the IDs of the vehicles on which the stop violates the
+	// constraint.
+	Vehicles []string `json:"vehicles"`
+}
@@ -0,0 +1,255 @@
+// © 2019-present nextmv.io inc
+
+package check
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/check/schema"
+	"github.com/nextmv-io/nextroute/factory"
+)
+
+// StaticAnalysis returns the stops of the model that can never be planned,
+// without solving and without locking the model. Each stop is tested on each
+// vehicle on its own: its quantity must not exceed the capacity, it must be
+// compatible with the vehicle, it must be reachable in time from the start of
+// the vehicle and the vehicle must reach its end in time after the stop. A
+// stop succeeding another stop must be reachable in time from that stop. A
+// stop failing a test on every vehicle is returned with the reasons. The
+// tests are cheap and not exhaustive, a stop passing them may still be
+// impossible to plan. The plan unit of a returned stop cannot be planned.
+func StaticAnalysis(model nextroute.Model) ([]schema.StaticStop, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model is nil")
+	}
+
+	analysis := newStaticAnalysis(model)
+	stops := []schema.StaticStop{}
+	for _, planStopsUnit := range model.PlanStopsUnits() {
+		for _, stop := range planStopsUnit.Stops() {
+			if stop.IsFixed() {
+				continue
+			}
+			if violations := analysis.stop(stop); len(violations) > 0 {
+				stops = append(stops, schema.StaticStop{
+					ID:         stop.ID(),
+					Violations: violations,
+				})
+			}
+		}
+	}
+	return stops, nil
+}
+
+// NewStaticAnalyzer returns a [factory.StaticAnalyzer] using
+// [StaticAnalysis]. Set it as the static analyzer of the factory options to
+// fail creating a model with stops that can never be planned.
+func NewStaticAnalyzer() factory.StaticAnalyzer {
+	return staticAnalyzerImpl{}
+}
+
+type staticAnalyzerImpl struct{}
+
+func (staticAnalyzerImpl) Analyze(model nextroute.Model) (map[string][]string, error) {
+	stops, err := StaticAnalysis(model)
+	if err != nil {
+		return nil, err
+	}
+	reasons := make(map[string][]string, len(stops))
+	for _, stop := range stops {
+		for _, violation := range stop.Violations {
+			reasons[stop.ID] = append(
+				reasons[stop.ID],
+				fmt.Sprintf("%s: %s", violation.Constraint, violation.Reason),
+			)
+		}
+	}
+	return reasons, nil
+}
+
+// latest is implemented by the constructs limiting the latest arrival, start
+// or end at a stop.
+type latest interface {
+	nextroute.ModelConstraint
+	Latest() nextroute.StopTimeExpression
+	TemporalReference() nextroute.TemporalReference
+}
+
+type staticAnalysis struct {
+	model       nextroute.Model
+	maximums    []nextroute.Maximum
+	attributes  []nextroute.AttributesConstraint
+	latests     []latest
+	predecessor map[int]nextroute.ModelStops
+	successor   map[int]nextroute.ModelStops
+}
+
+func newStaticAnalysis(model nextroute.Model) *staticAnalysis {
+	analysis := &staticAnalysis{
+		model:       model,
+		predecessor: make(map[int]nextroute.ModelStops),
+		successor:   make(map[int]nextroute.ModelStops),
+	}
+	for _, constraint := range model.Constraints() {
+		switch c := constraint.(type) {
+		case nextroute.Maximum:
+			analysis.maximums = append(analysis.maximums, c)
+		case nextroute.AttributesConstraint:
+			analysis.attributes = append(analysis.attributes, c)
+		case latest:
+			analysis.latests = append(analysis.latests, c)
+		}
+	}
+	for _, planStopsUnit := range model.PlanStopsUnits() {
+		for _, arc := range planStopsUnit.DirectedAcyclicGraph().Arcs() {
+			origin, destination := arc.Origin(), arc.Destination()
+			analysis.successor[origin.Index()] = append(analysis.successor[origin.Index()], destination)
+			analysis.predecessor[destination.Index()] = append(analysis.predecessor[destination.Index()], origin)
+		}
+	}
+	return analysis
+}
+
+// stop returns the violations of the stop if it violates a constraint on
+// every vehicle, nil otherwise.
+func (s *staticAnalysis) stop(stop nextroute.ModelStop) []schema.StaticViolation {
+	violations := []schema.StaticViolation{}
+	for _, vehicle := range s.model.Vehicles() {
+		vehicleViolations := s.vehicle(stop, vehicle)
+		if len(vehicleViolations) == 0 {
+			return nil
+		}
+		for _, vehicleViolation := range vehicleViolations {
+			idx := slices.IndexFunc(violations, func(v schema.StaticViolation) bool {
+				return v.Constraint == vehicleViolation.Constraint
+			})
+			if idx < 0 {
+				violations = append(violations, schema.StaticViolation{
+					Constraint: vehicleViolation.Constraint,
+					Reason:     vehicleViolation.Reason,
+					Vehicles:   []string{},
+				})
+				idx = len(violations) - 1
+			}
+			if !slices.Contains(violations[idx].Vehicles, vehicle.ID()) {
+				violations[idx].Vehicles = append(violations[idx].Vehicles, vehicle.ID())
+			}
+		}
+	}
+	return violations
+}
+
+// vehicle returns the violations of the stop on the vehicle.
+func (s *staticAnalysis) vehicle(
+	stop nextroute.ModelStop,
+	vehicle nextroute.ModelVehicle,
+) []schema.Violation {
+	var violations []schema.Violation
+	vehicleType := vehicle.VehicleType()
+
+	for _, constraint := range s.attributes {
+		stopAttributes := constraint.StopAttributes(stop)
+		vehicleTypeAttributes := constraint.VehicleTypeAttributes(vehicleType)
+		if len(stopAttributes) == 0 ||
+			slices.ContainsFunc(stopAttributes, func(attribute string) bool {
+				return slices.Contains(vehicleTypeAttributes, attribute)
+			}) {
+			continue
+		}
+		violations = append(violations, schema.Violation{
+			Constraint: fmt.Sprintf("%v", constraint),
+			Reason: fmt.Sprintf(
+				"stop %s has attributes %v, vehicle %s has none of them %v",
+				stop.ID(),
+				stopAttributes,
+				vehicle.ID(),
+				vehicleTypeAttributes,
+			),
+		})
+	}
+
+	for _, constraint := range s.maximums {
+		quantity := constraint.Expression().Value(vehicleType, vehicle.First(), stop)
+		maximum := constraint.Maximum().Value(vehicleType, nil, nil)
+		if math.Abs(quantity) <= maximum {
+			continue
+		}
+		violations = append(violations, schema.Violation{
+			Constraint: fmt.Sprintf("%v", constraint),
+			Reason: fmt.Sprintf(
+				"quantity %v of stop %s is above the maximum %v of vehicle %s",
+				math.Abs(quantity),
+				stop.ID(),
+				maximum,
+				vehicle.ID(),
+			),
+		})
+	}
+
+	if len(s.latests) == 0 {
+		return violations
+	}
+
+	departure := s.model.TimeToValue(vehicle.Start())
+	_, arrival, start, end := vehicleType.TemporalValues(departure, vehicle.First(), stop)
+	violations = append(violations, s.late(stop, arrival, start, end, "")...)
+
+	_, lastArrival, lastStart, lastEnd := vehicleType.TemporalValues(end, stop, vehicle.Last())
+	violations = append(violations, s.late(vehicle.Last(), lastArrival, lastStart, lastEnd, stop.ID())...)
+
+	for _, predecessor := range s.predecessor[stop.Index()] {
+		_, _, _, predecessorEnd := vehicleType.TemporalValues(departure, vehicle.First(), predecessor)
+		_, stopArrival, stopStart, stopEnd := vehicleType.TemporalValues(predecessorEnd, predecessor, stop)
+		violations = append(violations, s.late(stop, stopArrival, stopStart, stopEnd, predecessor.ID())...)
+	}
+	for _, successor := range s.successor[stop.Index()] {
+		_, successorArrival, successorStart, successorEnd := vehicleType.TemporalValues(end, stop, successor)
+		violations = append(violations, s.late(successor, successorArrival, successorStart, successorEnd, stop.ID())...)
+	}
+
+	return violations
+}
+
+// late returns the violations of the latest constraints at the stop if the
+// stop is reached at the earliest at the given arrival, start and end, after
+// the stop with ID after if not empty.
+func (s *staticAnalysis) late(
+	stop nextroute.ModelStop,
+	arrival, start, end float64,
+	after string,
+) []schema.Violation {
+	var violations []schema.Violation
+	for _, constraint := range s.latests {
+		reference, name := start, "start"
+		switch constraint.TemporalReference() {
+		case nextroute.OnArrival:
+			reference, name = arrival, "arrival"
+		case nextroute.OnEnd:
+			reference, name = end, "end"
+		}
+		latest := constraint.Latest().Value(nil, nil, stop)
+		if reference <= latest {
+			continue
+		}
+		reason := fmt.Sprintf(
+			"%s at stop %s would be at the earliest %v, after the latest %s %v",
+			name,
+			stop.ID(),
+			s.model.ValueToTime(reference).Format(time.RFC3339),
+			name,
+			s.model.ValueToTime(latest).Format(time.RFC3339),
+		)
+		if after != "" {
+			reason = fmt.Sprintf("%s when visited after stop %s", reason, after)
+		}
+		violations = append(violations, schema.Violation{
+			Constraint: fmt.Sprintf("%v", constraint),
+			Reason:     reason,
+		})
+	}
+	return violations
+}
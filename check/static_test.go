@@ -0,0 +1,126 @@
+// © 2019-present nextmv.io inc
+
+package check_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/nextmv-io/nextroute/check"
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+const staticInput = `{
+	"defaults": {
+		"vehicles": {
+			"speed": 10,
+			"capacity": 2,
+			"start_location": {"lon": 7.60, "lat": 51.95},
+			"start_time": "2023-01-01T08:00:00Z",
+			"end_time": "2023-01-01T09:00:00Z"
+		}
+	},
+	"stops": [
+		{"id": "fine", "location": {"lon": 7.61, "lat": 51.96}, "quantity": -1},
+		{"id": "heavy", "location": {"lon": 7.61, "lat": 51.96}, "quantity": -3},
+		{"id": "frozen", "location": {"lon": 7.61, "lat": 51.96}, "compatibility_attributes": ["cold"]},
+		{
+			"id": "early",
+			"location": {"lon": 7.70, "lat": 52.05},
+			"start_time_window": ["2023-01-01T08:00:00Z", "2023-01-01T08:05:00Z"]
+		},
+		{"id": "far", "location": {"lon": 8.60, "lat": 52.95}},
+		{
+			"id": "pickup",
+			"location": {"lon": 7.70, "lat": 52.05},
+			"start_time_window": ["2023-01-01T08:00:00Z", "2023-01-01T08:59:00Z"]
+		},
+		{
+			"id": "delivery",
+			"location": {"lon": 7.60, "lat": 51.95},
+			"start_time_window": ["2023-01-01T08:00:00Z", "2023-01-01T08:05:00Z"],
+			"succeeds": "pickup"
+		}
+	],
+	"vehicles": [{"id": "v1"}, {"id": "v2", "compatibility_attributes": ["dry"]}]
+}`
+
+func staticModelOptions() factory.Options {
+	options := planModelOptions()
+	options.Validate.Static = "report"
+	options.StaticAnalyzer = check.NewStaticAnalyzer()
+	return options
+}
+
+func TestStaticAnalysis(t *testing.T) {
+	var input schema.Input
+	if err := json.Unmarshal([]byte(staticInput), &input); err != nil {
+		t.Fatal(err)
+	}
+	model, err := factory.NewModel(input, staticModelOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stops, err := check.StaticAnalysis(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.IsLocked() {
+		t.Error("expected the model not to be locked")
+	}
+
+	tests := map[string]struct {
+		constraint string
+		reason     string
+	}{
+		"heavy":    {constraint: "capacity_default", reason: "quantity 3 of stop heavy is above the maximum 2"},
+		"frozen":   {constraint: "attributes", reason: "has attributes [cold]"},
+		"early":    {constraint: "late_start_penalty", reason: "start at stop early would be at the earliest"},
+		"far":      {constraint: "late_end_penalty", reason: "after stop far"},
+		"pickup":   {constraint: "late_start_penalty", reason: "start at stop delivery"},
+		"delivery": {constraint: "late_start_penalty", reason: "when visited after stop pickup"},
+	}
+	if len(stops) != len(tests) {
+		t.Errorf("expected %d stops, got %+v", len(tests), stops)
+	}
+	for _, stop := range stops {
+		test, ok := tests[stop.ID]
+		if !ok {
+			t.Errorf("unexpected stop %s", stop.ID)
+			continue
+		}
+		if len(stop.Violations) != 1 ||
+			stop.Violations[0].Constraint != test.constraint ||
+			!strings.Contains(stop.Violations[0].Reason, test.reason) ||
+			len(stop.Violations[0].Vehicles) != 2 {
+			t.Errorf(
+				"stop %s: violations %+v, want %s containing %q on both vehicles",
+				stop.ID,
+				stop.Violations,
+				test.constraint,
+				test.reason,
+			)
+		}
+	}
+}
+
+func TestStaticAnalysisFail(t *testing.T) {
+	var input schema.Input
+	if err := json.Unmarshal([]byte(staticInput), &input); err != nil {
+		t.Fatal(err)
+	}
+	options := staticModelOptions()
+	options.Validate.Static = "fail"
+	_, err := factory.NewModel(input, options)
+	if err == nil || !strings.Contains(err.Error(), "6 stops can never be planned") {
+		t.Errorf("expected the static validation to fail, got %v", err)
+	}
+
+	options.StaticAnalyzer = nil
+	if _, err = factory.NewModel(input, options); err == nil {
+		t.Error("expected an error without a static analyzer")
+	}
+}
@@ -4,18 +4,37 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/nextmv-io/nextroute"
 	"github.com/nextmv-io/nextroute/check"
+	checkSchema "github.com/nextmv-io/nextroute/check/schema"
+	"github.com/nextmv-io/nextroute/common"
 	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/matrixprovider"
+	"github.com/nextmv-io/nextroute/report"
+	"github.com/nextmv-io/nextroute/roadnetwork"
 	"github.com/nextmv-io/nextroute/schema"
+	"github.com/nextmv-io/nextroute/server"
 	"github.com/nextmv-io/sdk/run"
+	"github.com/nextmv-io/sdk/run/decode"
+	"github.com/nextmv-io/sdk/run/encode"
 	runSchema "github.com/nextmv-io/sdk/run/schema"
+	"github.com/nextmv-io/sdk/run/validate"
 )
 
 func main() {
@@ -24,19 +43,296 @@ func main() {
 		fmt.Println(nextroute.Version())
 		return
 	}
+	// If the first argument is 'serve', serve the solver over HTTP.
+	if len(os.Args) >= 2 && os.Args[1] == "serve" {
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+		if err := serve(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 	// Continue with runner based execution.
-	runner := run.CLI(solver)
+	runner := run.CLI(
+		solver,
+		run.InputDecode[run.CLIRunnerConfig, schema.Input, options, runSchema.Output](
+			decodeInput,
+		),
+		run.InputValidate[run.CLIRunnerConfig, schema.Input, options, runSchema.Output](
+			validateInput,
+		),
+		run.IOProduce[run.CLIRunnerConfig, schema.Input, options, runSchema.Output](
+			ioProducer,
+		),
+		run.Encode[run.CLIRunnerConfig, schema.Input, options, runSchema.Output](
+			outputEncoder{},
+		),
+	)
 	err := runner.Run(context.Background())
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
+// serve serves the solver over HTTP, see package server. The options given on
+// the command line are the defaults of the solve requests, requests may
+// override the solve, model, solver and format options.
+func serve() error {
+	address := flag.String("serve.address", ":8080", "address the server listens on")
+	workers := flag.Int("serve.workers", 1, "number of inputs solved concurrently")
+	queue := flag.Int("serve.queue", 100, "number of jobs waiting for a worker")
+	_, defaults, err := run.FlagParser[options, run.CLIRunnerConfig]()
+	if err != nil {
+		return err
+	}
+
+	handler, err := server.NewServer(solver, defaults, server.Options{
+		Workers:   *workers,
+		Queue:     *queue,
+		Overrides: []string{"solve", "model", "solver", "format"},
+	})
+	if err != nil {
+		return err
+	}
+	defer handler.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	httpServer := &http.Server{
+		Addr:              *address,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("serving on %s", *address)
+		errs <- httpServer.ListenAndServe()
+	}()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		return httpServer.Shutdown(context.Background())
+	}
+}
+
 type options struct {
-	Model  factory.Options                `json:"model,omitempty"`
-	Solve  nextroute.ParallelSolveOptions `json:"solve,omitempty"`
-	Format nextroute.FormatOptions        `json:"format,omitempty"`
-	Check  check.Options                  `json:"check,omitempty"`
+	Input   inputOptions                   `json:"input,omitempty"`
+	Output  outputOptions                  `json:"output,omitempty"`
+	Model   factory.Options                `json:"model,omitempty"`
+	Solve   nextroute.ParallelSolveOptions `json:"solve,omitempty"`
+	Solver  nextroute.SolverFactoryOptions `json:"solver,omitempty"`
+	Format  nextroute.FormatOptions        `json:"format,omitempty"`
+	Stream  nextroute.StreamOptions        `json:"stream,omitempty"`
+	Check   check.Options                  `json:"check,omitempty"`
+	Network roadnetwork.Options            `json:"network,omitempty"`
+	Matrix  matrixprovider.Options         `json:"matrix,omitempty"`
+	Record  nextroute.RecordOptions        `json:"record,omitempty"`
+	Replay  nextroute.ReplayOptions        `json:"replay,omitempty"`
+}
+
+type inputOptions struct {
+	Format string `json:"format" usage:"{json, csv} format of the input, csv reads stops.csv and vehicles.csv from the directory given as input path" default:"json"`
+}
+
+type outputOptions struct {
+	Format string `json:"format" usage:"{json, geojson, csv, html} format of the output, html writes a report of the last solution, geojson writes the routes and stops of the last solution as a feature collection, csv writes a row per planned stop of the last solution and the unplanned stops to <output path>_unplanned.csv (after an empty line on stdout)" default:"json"`
+}
+
+// inputFormat returns the input format given on the command line. The input
+// is decoded before the options are passed to the solver, so the format is
+// looked up on the parsed flags.
+func inputFormat() string {
+	if f := flag.Lookup("input.format"); f != nil {
+		return f.Value.String()
+	}
+	return "json"
+}
+
+// ioProducer passes the input path as is for the csv format, the directory
+// is read by the decoder.
+func ioProducer(ctx context.Context, config run.CLIRunnerConfig) (run.IOData, error) {
+	if inputFormat() != "csv" {
+		return run.CliIOProducer(ctx, config)
+	}
+	if config.Runner.Input.Path == "" {
+		return nil, errors.New(
+			"csv input requires -runner.input.path to be a directory " +
+				"containing stops.csv and vehicles.csv",
+		)
+	}
+	var writer io.Writer = os.Stdout
+	if config.Runner.Output.Path != "" {
+		file, err := os.Create(config.Runner.Output.Path)
+		if err != nil {
+			return nil, err
+		}
+		writer = file
+	}
+	return csvIOData{directory: config.Runner.Input.Path, writer: writer}, nil
+}
+
+// csvIOData is the IOData of the csv format, the input is the directory.
+type csvIOData struct {
+	directory string
+	writer    io.Writer
+}
+
+func (d csvIOData) Input() any {
+	return d.directory
+}
+
+func (d csvIOData) Option() any {
+	return nil
+}
+
+func (d csvIOData) Writer() any {
+	return d.writer
+}
+
+func decodeInput(ctx context.Context, reader any) (schema.Input, error) {
+	switch inputFormat() {
+	case "json":
+		return run.GenericDecoder[schema.Input](decode.JSON())(ctx, reader)
+	case "csv":
+		directory, ok := reader.(string)
+		if !ok {
+			return schema.Input{}, errors.New("csv input requires a directory")
+		}
+		return schema.LoadCSV(directory)
+	}
+	return schema.Input{}, fmt.Errorf("unknown input format %s", inputFormat())
+}
+
+func validateInput(ctx context.Context, reader any) error {
+	if inputFormat() == "csv" {
+		return nil
+	}
+	return validate.JSON[schema.Input](nil)(ctx, reader)
+}
+
+// outputEncoder encodes the output in the format given by the options.
+type outputEncoder struct{}
+
+func (outputEncoder) Encode(
+	ctx context.Context,
+	outputs <-chan runSchema.Output,
+	writer any,
+	config any,
+	options options,
+) error {
+	var encoder run.Encoder[any, any] = run.GenericEncoder[any, any](encode.JSON())
+	var convert func(runSchema.Output) (any, error)
+	switch options.Output.Format {
+	case "", "json":
+		convert = func(output runSchema.Output) (any, error) {
+			return output, nil
+		}
+	case "geojson":
+		convert = func(output runSchema.Output) (any, error) {
+			solution, err := lastSolutionOutput(output)
+			if err != nil {
+				return nil, err
+			}
+			return schema.ToFeatureCollection(solution), nil
+		}
+	case "html":
+		encoder = run.GenericEncoder[any, any](htmlEncoder{})
+		convert = func(output runSchema.Output) (any, error) {
+			return lastSolutionOutput(output)
+		}
+	case "csv":
+		unplannedPath := ""
+		if pather, ok := config.(run.OutputPather); ok && pather.OutputPath() != "" {
+			path := pather.OutputPath()
+			extension := filepath.Ext(path)
+			unplannedPath = strings.TrimSuffix(path, extension) + "_unplanned" + extension
+		}
+		encoder = run.GenericEncoder[any, any](csvEncoder{unplannedPath: unplannedPath})
+		convert = func(output runSchema.Output) (any, error) {
+			if len(output.Solutions) == 0 {
+				return schema.SolutionOutput{}, nil
+			}
+			return output.Solutions[len(output.Solutions)-1], nil
+		}
+	default:
+		return fmt.Errorf("unknown output format %s", options.Output.Format)
+	}
+
+	converted := make(chan any)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(converted)
+		defer close(errs)
+		for output := range outputs {
+			value, err := convert(output)
+			if err != nil {
+				errs <- err
+				// Drain the outputs so the solver can finish.
+				for range outputs {
+				}
+				return
+			}
+			converted <- value
+		}
+	}()
+	if err := encoder.Encode(ctx, converted, writer, config, options); err != nil {
+		return err
+	}
+	return <-errs
+}
+
+// csvEncoder writes the planned stops of a solution as CSV. The unplanned
+// stops are written to a separate file if a path is given, otherwise they
+// follow the planned stops after an empty line.
+type csvEncoder struct {
+	unplannedPath string
+}
+
+func (e csvEncoder) Encode(writer io.Writer, solution any) error {
+	if e.unplannedPath == "" {
+		var unplanned bytes.Buffer
+		if err := factory.WriteCSV(writer, &unplanned, solution); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(writer, "\n"); err != nil {
+			return err
+		}
+		_, err := unplanned.WriteTo(writer)
+		return err
+	}
+
+	file, err := os.Create(e.unplannedPath)
+	if err != nil {
+		return err
+	}
+	if err := factory.WriteCSV(writer, file, solution); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// htmlEncoder writes a solution as an HTML report.
+type htmlEncoder struct{}
+
+func (htmlEncoder) Encode(writer io.Writer, solution any) error {
+	return report.WriteHTML(writer, solution.(schema.SolutionOutput))
+}
+
+// lastSolutionOutput returns the last solution of the output as a
+// [schema.SolutionOutput]. Returns an empty solution if there is none.
+func lastSolutionOutput(output runSchema.Output) (schema.SolutionOutput, error) {
+	if len(output.Solutions) == 0 {
+		return schema.SolutionOutput{}, nil
+	}
+	solution, ok := output.Solutions[len(output.Solutions)-1].(schema.SolutionOutput)
+	if !ok {
+		return schema.SolutionOutput{}, fmt.Errorf(
+			"cannot convert solution of type %T",
+			output.Solutions[len(output.Solutions)-1],
+		)
+	}
+	return solution, nil
 }
 
 func solver(
@@ -44,26 +340,54 @@ func solver(
 	input schema.Input,
 	options options,
 ) (runSchema.Output, error) {
+	provider, err := matrixProvider(options)
+	if err != nil {
+		return runSchema.Output{}, err
+	}
+	options.Model.MatrixProvider = provider
+	options.Model.StaticAnalyzer = check.NewStaticAnalyzer()
+
 	model, err := factory.NewModel(input, options.Model)
 	if err != nil {
 		return runSchema.Output{}, err
 	}
 
+	var static []checkSchema.StaticStop
+	if options.Model.Validate.Static == "report" {
+		if static, err = check.StaticAnalysis(model); err != nil {
+			return runSchema.Output{}, err
+		}
+	}
+
+	if options.Check.Plan != "" {
+		return checkPlan(ctx, model, options)
+	}
+
 	solver, err := nextroute.NewParallelSolver(model)
 	if err != nil {
 		return runSchema.Output{}, err
 	}
+	solver.SetSolverFactory(nextroute.NewSolverFactory(options.Solver))
+
+	recorder, err := solveRecorder(solver, options)
+	if err != nil {
+		return runSchema.Output{}, err
+	}
 
 	solutions, err := solver.Solve(ctx, options.Solve)
 	if err != nil {
 		return runSchema.Output{}, err
 	}
 
-	last, err := solutions.Last()
+	last, err := lastSolution(ctx, options, solutions)
 	if err != nil {
 		return runSchema.Output{}, err
 	}
 
+	if err := writeRecording(recorder, options); err != nil {
+		return runSchema.Output{}, err
+	}
+
 	output, err := check.Format(
 		ctx,
 		options,
@@ -75,6 +399,140 @@ func solver(
 		return runSchema.Output{}, err
 	}
 	output.Statistics.Result.Custom = factory.DefaultCustomResultStatistics(last)
+	if static != nil {
+		output = check.FormatStatic(output, static)
+	}
 
 	return output, nil
 }
+
+// checkPlan checks the plan given in the check options instead of solving,
+// see check.PlanCheck.
+func checkPlan(
+	ctx context.Context,
+	model nextroute.Model,
+	options options,
+) (runSchema.Output, error) {
+	data, err := os.ReadFile(options.Check.Plan)
+	if err != nil {
+		return runSchema.Output{}, err
+	}
+	var plan checkSchema.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return runSchema.Output{}, fmt.Errorf("decoding plan %s: %w", options.Check.Plan, err)
+	}
+	solution, planOutput, err := check.PlanCheck(model, plan)
+	if err != nil {
+		return runSchema.Output{}, err
+	}
+	return check.FormatPlan(ctx, options, solution, planOutput), nil
+}
+
+// lastSolution returns the last solution of the channel. If a stream path is
+// given, the improving solutions are written to it while solving.
+func lastSolution(
+	ctx context.Context,
+	options options,
+	solutions nextroute.SolutionChannel,
+) (nextroute.Solution, error) {
+	if options.Stream.Path == "" {
+		return solutions.Last()
+	}
+	var writer io.Writer = os.Stdout
+	if options.Stream.Path != "-" {
+		file, err := os.Create(options.Stream.Path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		writer = file
+	}
+	return solutions.Stream(
+		ctx,
+		writer,
+		options.Stream.Interval,
+		func(solution nextroute.Solution) any {
+			return factory.ToSolutionOutput(solution)
+		},
+	)
+}
+
+// solveRecorder attaches a replayer to the solver if a recording to replay is
+// given, otherwise a recorder if a path to record to is given. Returns nil if
+// neither is given.
+func solveRecorder(
+	solver nextroute.ParallelSolver,
+	options options,
+) (nextroute.SolveRecorder, error) {
+	if options.Replay.Path != "" {
+		data, err := os.ReadFile(options.Replay.Path)
+		if err != nil {
+			return nil, err
+		}
+		var recording nextroute.SolveRecording
+		if err := json.Unmarshal(data, &recording); err != nil {
+			return nil, fmt.Errorf("decoding recording %s: %w", options.Replay.Path, err)
+		}
+		return nextroute.NewSolveReplayer(solver, recording, options.Replay.Run)
+	}
+	if options.Record.Path != "" {
+		return nextroute.NewSolveRecorder(solver, options.Record.Seed)
+	}
+	return nil, nil
+}
+
+// writeRecording writes the recording of the solve to the record path. A
+// replay is not written.
+func writeRecording(recorder nextroute.SolveRecorder, options options) error {
+	if recorder == nil || options.Replay.Path != "" {
+		return nil
+	}
+	file, err := os.Create(options.Record.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return json.NewEncoder(file).Encode(recorder.Recording())
+}
+
+// matrixProvider returns the provider used to compute missing matrices: a
+// road network if a path is given, otherwise an OSRM server if a URL is given.
+// Returns nil if neither is given.
+func matrixProvider(options options) (factory.MatrixProvider, error) {
+	var provider factory.MatrixProvider
+	namespace := ""
+	switch {
+	case options.Network.Path != "":
+		graph, err := roadnetwork.Load(options.Network.Path)
+		if err != nil {
+			return nil, err
+		}
+		provider = roadnetwork.NewMatrixProvider(
+			graph,
+			common.NewSpeed(options.Network.AccessSpeed, common.MetersPerSecond),
+		)
+		namespace = fmt.Sprintf("network:%+v", options.Network)
+	case options.Matrix.OSRM.URL != "":
+		osrm, err := matrixprovider.NewOSRM(options.Matrix.OSRM)
+		if err != nil {
+			return nil, err
+		}
+		provider = osrm
+		namespace = fmt.Sprintf(
+			"osrm:%s:%s",
+			options.Matrix.OSRM.URL,
+			options.Matrix.OSRM.Profile,
+		)
+	default:
+		return nil, nil
+	}
+
+	if options.Matrix.Cache.Directory != "" {
+		provider = matrixprovider.NewDiskCache(
+			provider,
+			options.Matrix.Cache.Directory,
+			namespace,
+		)
+	}
+	return provider, nil
+}
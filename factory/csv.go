@@ -0,0 +1,204 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// plannedCSVColumns are the fixed columns of the planned stops table, the
+// flattened custom data of the stops follows them.
+var plannedCSVColumns = []string{
+	"vehicle_id",
+	"sequence",
+	"stop_id",
+	"lat",
+	"lon",
+	"arrival_time",
+	"start_time",
+	"end_time",
+	"duration",
+	"waiting_duration",
+	"travel_duration",
+	"travel_distance",
+	"cumulative_travel_duration",
+	"cumulative_travel_distance",
+	"early_arrival_duration",
+	"late_arrival_duration",
+}
+
+// unplannedCSVColumns are the fixed columns of the unplanned stops table.
+var unplannedCSVColumns = []string{
+	"stop_id",
+	"lat",
+	"lon",
+}
+
+// ToCSV converts a solution to two tables, each starting with a header: the
+// planned stops with a row per stop in the order of the vehicles and their
+// routes, and the unplanned stops. The custom data of the stops is flattened
+// to a column per key, nested keys are joined with a dot, for example
+// custom_data.address.city. The solution is a [schema.SolutionOutput] or any
+// value with the same JSON representation, such as the solutions of a custom
+// output created with [nextroute.Format].
+func ToCSV(solution any) (planned [][]string, unplanned [][]string, err error) {
+	output, err := toCSVSolutionOutput(solution)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	plannedCustomData := make([]map[string]string, 0)
+	for _, vehicle := range output.Vehicles {
+		for sequence, stop := range vehicle.Route {
+			row := []string{
+				vehicle.ID,
+				strconv.Itoa(sequence),
+				stop.Stop.ID,
+				formatCSVFloat(stop.Stop.Location.Lat),
+				formatCSVFloat(stop.Stop.Location.Lon),
+				formatCSVTime(stop.ArrivalTime),
+				formatCSVTime(stop.StartTime),
+				formatCSVTime(stop.EndTime),
+				strconv.Itoa(stop.Duration),
+				strconv.Itoa(stop.WaitingDuration),
+				strconv.Itoa(stop.TravelDuration),
+				strconv.Itoa(stop.TravelDistance),
+				strconv.Itoa(stop.CumulativeTravelDuration),
+				strconv.Itoa(stop.CumulativeTravelDistance),
+				strconv.Itoa(stop.EarlyArrivalDuration),
+				strconv.Itoa(stop.LateArrivalDuration),
+			}
+			planned = append(planned, row)
+			plannedCustomData = append(
+				plannedCustomData,
+				flattenCustomData(stop.Stop.CustomData),
+			)
+		}
+	}
+	planned = withCustomDataColumns(plannedCSVColumns, planned, plannedCustomData)
+
+	unplannedCustomData := make([]map[string]string, len(output.Unplanned))
+	for idx, stop := range output.Unplanned {
+		unplanned = append(unplanned, []string{
+			stop.ID,
+			formatCSVFloat(stop.Location.Lat),
+			formatCSVFloat(stop.Location.Lon),
+		})
+		unplannedCustomData[idx] = flattenCustomData(stop.CustomData)
+	}
+	unplanned = withCustomDataColumns(unplannedCSVColumns, unplanned, unplannedCustomData)
+
+	return planned, unplanned, nil
+}
+
+// WriteCSV writes the tables of [ToCSV] to the planned and unplanned writers.
+func WriteCSV(planned io.Writer, unplanned io.Writer, solution any) error {
+	plannedRows, unplannedRows, err := ToCSV(solution)
+	if err != nil {
+		return err
+	}
+	if err := csv.NewWriter(planned).WriteAll(plannedRows); err != nil {
+		return err
+	}
+	return csv.NewWriter(unplanned).WriteAll(unplannedRows)
+}
+
+// toCSVSolutionOutput converts a solution to a [schema.SolutionOutput] using
+// its JSON representation. This also turns custom data into maps, which are
+// flattened to columns.
+func toCSVSolutionOutput(solution any) (schema.SolutionOutput, error) {
+	data, err := json.Marshal(solution)
+	if err != nil {
+		return schema.SolutionOutput{}, err
+	}
+	var output schema.SolutionOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return schema.SolutionOutput{}, fmt.Errorf(
+			"solution of type %T is not a solution output: %w",
+			solution,
+			err,
+		)
+	}
+	return output, nil
+}
+
+// withCustomDataColumns prepends the header to the rows and appends a column
+// per custom data key, sorted by key.
+func withCustomDataColumns(
+	columns []string,
+	rows [][]string,
+	customData []map[string]string,
+) [][]string {
+	keys := make(map[string]struct{})
+	for _, data := range customData {
+		for key := range data {
+			keys[key] = struct{}{}
+		}
+	}
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+
+	header := append(append([]string{}, columns...), sortedKeys...)
+	result := make([][]string, 0, len(rows)+1)
+	result = append(result, header)
+	for idx, row := range rows {
+		for _, key := range sortedKeys {
+			row = append(row, customData[idx][key])
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+// flattenCustomData returns a value per leaf of the custom data keyed by its
+// path, starting with custom_data. Lists are kept as JSON.
+func flattenCustomData(customData any) map[string]string {
+	result := make(map[string]string)
+	var flatten func(prefix string, value any)
+	flatten = func(prefix string, value any) {
+		switch v := value.(type) {
+		case nil:
+		case map[string]any:
+			for key, child := range v {
+				flatten(prefix+"."+key, child)
+			}
+		case string:
+			result[prefix] = v
+		case float64:
+			result[prefix] = formatCSVFloat(v)
+		case bool:
+			result[prefix] = strconv.FormatBool(v)
+		default:
+			data, err := json.Marshal(v)
+			if err != nil {
+				result[prefix] = fmt.Sprintf("%v", v)
+				return
+			}
+			result[prefix] = string(data)
+		}
+	}
+	flatten("custom_data", customData)
+	return result
+}
+
+func formatCSVFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatCSVTime(value *time.Time) string {
+	if value == nil {
+		return ""
+	}
+	return value.Format(time.RFC3339)
+}
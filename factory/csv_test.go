@@ -0,0 +1,89 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+func TestToCSV(t *testing.T) {
+	arrival := time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	output := schema.SolutionOutput{
+		Vehicles: []schema.VehicleOutput{{
+			ID: "truck",
+			Route: []schema.PlannedStopOutput{{
+				Stop: schema.StopOutput{
+					ID:       "s1",
+					Location: schema.Location{Lon: 7.62, Lat: 51.96},
+					CustomData: map[string]any{
+						"address": map[string]any{"city": "Münster"},
+						"weight":  2.5,
+					},
+				},
+				ArrivalTime:              &arrival,
+				TravelDuration:           60,
+				CumulativeTravelDuration: 60,
+			}},
+		}},
+		Unplanned: []schema.StopOutput{
+			{ID: "s2", Location: schema.Location{Lon: 7.63, Lat: 51.97}, CustomData: "fragile"},
+		},
+	}
+
+	planned, unplanned, err := ToCSV(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) != 2 {
+		t.Fatalf("expected a header and 1 row, got %v", planned)
+	}
+	header := planned[0]
+	if header[len(header)-2] != "custom_data.address.city" ||
+		header[len(header)-1] != "custom_data.weight" {
+		t.Errorf("unexpected custom data columns %v", header)
+	}
+	row := planned[1]
+	if row[0] != "truck" || row[2] != "s1" || row[5] != "2023-01-01T09:00:00Z" {
+		t.Errorf("unexpected row %v", row)
+	}
+	if row[len(row)-2] != "Münster" || row[len(row)-1] != "2.5" {
+		t.Errorf("unexpected custom data values %v", row)
+	}
+	expected := [][]string{
+		{"stop_id", "lat", "lon", "custom_data"},
+		{"s2", "51.97", "7.63", "fragile"},
+	}
+	if !reflect.DeepEqual(unplanned, expected) {
+		t.Errorf("expected unplanned %v, got %v", expected, unplanned)
+	}
+
+	// A custom output with the same JSON representation.
+	type customOutput struct {
+		Vehicles []schema.VehicleOutput `json:"vehicles"`
+		Score    float64                `json:"score"`
+	}
+	var buffer, unplannedBuffer bytes.Buffer
+	err = WriteCSV(
+		&buffer,
+		&unplannedBuffer,
+		customOutput{Vehicles: output.Vehicles, Score: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(buffer.Bytes(), []byte("\n")) != 2 {
+		t.Errorf("expected 2 lines, got %s", buffer.String())
+	}
+	if unplannedBuffer.String() != "stop_id,lat,lon\n" {
+		t.Errorf("expected only the unplanned header, got %s", unplannedBuffer.String())
+	}
+
+	if _, _, err := ToCSV("not a solution"); err == nil {
+		t.Error("expected an error for a value that is not a solution")
+	}
+}
@@ -18,7 +18,11 @@ func NewModel(
 	modelOptions Options,
 ) (nextroute.Model, error) {
 	input = applyDefaults(input)
-	err := validate(input, modelOptions)
+	input, err := SetMatrices(input, modelOptions.MatrixProvider)
+	if err != nil {
+		return nil, err
+	}
+	err = validate(input, modelOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -34,6 +38,10 @@ func NewModel(
 		}
 	}
 
+	if err := analyzeStatic(model, modelOptions); err != nil {
+		return nil, err
+	}
+
 	return model, nil
 }
 
@@ -76,6 +76,13 @@ func ToSolutionOutput(solution nextroute.Solution) schema.SolutionOutput {
 	}
 }
 
+// ToGeoJSON converts a solution to a GeoJSON [schema.FeatureCollection] with
+// the routes and stops of [ToSolutionOutput], see
+// [schema.ToFeatureCollection].
+func ToGeoJSON(solution nextroute.Solution) schema.FeatureCollection {
+	return schema.ToFeatureCollection(ToSolutionOutput(solution))
+}
+
 func toStopOutput(modelStop nextroute.ModelStop) schema.StopOutput {
 	var customData any
 	if inputStop, ok := modelStop.Data().(schema.Stop); ok {
@@ -223,10 +230,44 @@ func toVehicleOutput(vehicle nextroute.SolutionVehicle) schema.VehicleOutput {
 
 	vehicleOutput.RouteWaitingDuration = vehicleOutput.RouteDuration -
 		vehicleOutput.RouteTravelDuration - vehicleOutput.RouteStopsDuration
+	vehicleOutput.Objective = ToVehicleObjectiveOutput(vehicle)
 
 	return vehicleOutput
 }
 
+// ToVehicleObjectiveOutput returns the contribution of the vehicle to each
+// objective term that implements [nextroute.ObjectiveVehicleValuer]. Returns
+// nil for an empty vehicle or if no term can be attributed to vehicles. It is
+// used for the output of a solution and for the check of a plan.
+func ToVehicleObjectiveOutput(vehicle nextroute.SolutionVehicle) *schema.ObjectiveOutput {
+	if vehicle.IsEmpty() {
+		return nil
+	}
+	objective := vehicle.ModelVehicle().Model().Objective()
+	output := schema.ObjectiveOutput{
+		Name:       fmt.Sprintf("%v", objective),
+		Objectives: []schema.ObjectiveOutput{},
+	}
+	for _, term := range objective.Terms() {
+		valuer, ok := term.Objective().(nextroute.ObjectiveVehicleValuer)
+		if !ok {
+			continue
+		}
+		base := valuer.VehicleValue(vehicle)
+		output.Objectives = append(output.Objectives, schema.ObjectiveOutput{
+			Name:   fmt.Sprintf("%v", term.Objective()),
+			Factor: term.Factor(),
+			Base:   base,
+			Value:  term.Factor() * base,
+		})
+		output.Value += term.Factor() * base
+	}
+	if len(output.Objectives) == 0 {
+		return nil
+	}
+	return &output
+}
+
 func toObjectiveOutput(solution nextroute.Solution) schema.ObjectiveOutput {
 	return schema.ObjectiveOutput{
 		Name: fmt.Sprintf("%v", solution.Model().Objective()),
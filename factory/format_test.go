@@ -0,0 +1,83 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"math"
+	"testing"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+func TestVehicleObjectiveOutput(t *testing.T) {
+	speed := 10.0
+	activation := 100
+	penalty := 1000
+	input := schema.Input{
+		Stops: []schema.Stop{
+			{ID: "s1", UnplannedPenalty: &penalty, Location: schema.Location{Lon: 7.62, Lat: 51.96}},
+			{ID: "s2", UnplannedPenalty: &penalty, Location: schema.Location{Lon: 7.63, Lat: 51.97}},
+			{ID: "s3", UnplannedPenalty: &penalty, Location: schema.Location{Lon: 7.64, Lat: 51.98}},
+		},
+		Vehicles: []schema.Vehicle{
+			{
+				ID:                "v1",
+				StartLocation:     &schema.Location{Lon: 7.60, Lat: 51.95},
+				Speed:             &speed,
+				ActivationPenalty: &activation,
+				InitialStops:      &[]schema.InitialStop{{ID: "s1"}, {ID: "s2"}},
+			},
+			{
+				ID:                "v2",
+				StartLocation:     &schema.Location{Lon: 7.60, Lat: 51.95},
+				Speed:             &speed,
+				ActivationPenalty: &activation,
+			},
+		},
+	}
+	options := Options{}
+	options.Objectives.VehicleActivationPenalty = 1
+	options.Objectives.VehiclesDuration = 1
+	options.Objectives.TravelDuration = 0.5
+	options.Objectives.UnplannedPenalty = 1
+	model, err := NewModel(input, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := ToSolutionOutput(solution)
+	if output.Vehicles[1].Objective != nil {
+		t.Errorf("expected no objective for an empty vehicle, got %v", output.Vehicles[1].Objective)
+	}
+	objective := output.Vehicles[0].Objective
+	if objective == nil {
+		t.Fatal("expected an objective for vehicle v1")
+	}
+
+	// All terms but the unplanned penalty are attributed to the vehicle.
+	unplanned := 0.0
+	for _, term := range output.Objective.Objectives {
+		if term.Name == "unplanned_penalty" {
+			unplanned = term.Value
+		}
+	}
+	if unplanned == 0 {
+		t.Fatal("expected an unplanned penalty for stop s3")
+	}
+	if len(objective.Objectives) != len(output.Objective.Objectives)-1 {
+		t.Errorf("expected all terms but unplanned_penalty, got %v", objective.Objectives)
+	}
+	if math.Abs(objective.Value+unplanned-output.Objective.Value) > 1e-6 {
+		t.Errorf(
+			"expected vehicle value %v plus unplanned %v to equal %v",
+			objective.Value,
+			unplanned,
+			output.Objective.Value,
+		)
+	}
+}
@@ -0,0 +1,158 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// The kinds of the constructs of the factory written by
+// [nextroute.WriteModel].
+const (
+	durationGroupsExpressionKind = "factory.duration_groups_expression"
+	vehicleTypeDataKind          = "factory.vehicle_type_data"
+	modelDataKind                = "factory.model_data"
+	stopDataKind                 = "factory.stop"
+	alternateStopDataKind        = "factory.alternate_stop"
+	vehicleDataKind              = "factory.vehicle"
+)
+
+func init() {
+	nextroute.RegisterModelUnmarshaler(durationGroupsExpressionKind, unmarshalDurationGroups)
+	nextroute.RegisterModelUnmarshaler(vehicleTypeDataKind, unmarshalVehicleTypeData)
+	// Apart from the stop indices used to format the output, the model data
+	// is only used while the model is built.
+	nextroute.RegisterModelData(
+		modelDataKind,
+		modelData{},
+		func(data any) ([]byte, error) {
+			return json.Marshal(data.(modelData).stopIDToIndex)
+		},
+		func(_ nextroute.ModelDecoder, data []byte) (any, error) {
+			model := modelData{groups: make([]group, 0)}
+			if err := json.Unmarshal(data, &model.stopIDToIndex); err != nil {
+				return nil, err
+			}
+			return model, nil
+		},
+	)
+	nextroute.RegisterModelData(stopDataKind, schema.Stop{}, json.Marshal, unmarshalJSON[schema.Stop])
+	nextroute.RegisterModelData(vehicleDataKind, schema.Vehicle{}, json.Marshal, unmarshalJSON[schema.Vehicle])
+	nextroute.RegisterModelData(
+		alternateStopDataKind,
+		alternateInputStop{},
+		func(data any) ([]byte, error) {
+			stop := data.(alternateInputStop)
+			return json.Marshal(alternateStopRecord{Index: stop.index, Stop: stop.stop})
+		},
+		func(_ nextroute.ModelDecoder, data []byte) (any, error) {
+			var record alternateStopRecord
+			if err := json.Unmarshal(data, &record); err != nil {
+				return nil, err
+			}
+			return alternateInputStop{index: record.Index, stop: record.Stop}, nil
+		},
+	)
+}
+
+// unmarshalJSON recreates input data written as JSON.
+func unmarshalJSON[T any](_ nextroute.ModelDecoder, data []byte) (any, error) {
+	var value T
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+type alternateStopRecord struct {
+	Stop  schema.AlternateStop `json:"stop"`
+	Index int                  `json:"index"`
+}
+
+type durationGroupsRecord struct {
+	GroupDuration []float64
+	ToGroupIndex  []int64
+	Durations     []float64
+	Stops         []int
+	GroupCount    int64
+}
+
+// MarshalModel implements nextroute.ModelMarshaler.
+func (d *durationGroupDurationImpl) MarshalModel(nextroute.ModelEncoder) (string, []byte, error) {
+	record := durationGroupsRecord{
+		GroupDuration: d.groupDuration,
+		ToGroupIndex:  d.toGroupIndex,
+		Durations:     d.durations,
+		Stops:         make([]int, len(d.stopIndexToStop)),
+		GroupCount:    d.groupCount,
+	}
+	for idx, stop := range d.stopIndexToStop {
+		record.Stops[idx] = -1
+		if stop != nil {
+			record.Stops[idx] = stop.Index()
+		}
+	}
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(record); err != nil {
+		return "", nil, err
+	}
+	return durationGroupsExpressionKind, buffer.Bytes(), nil
+}
+
+func unmarshalDurationGroups(decoder nextroute.ModelDecoder, data []byte) (any, error) {
+	var record durationGroupsRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+		return nil, err
+	}
+	expression := &durationGroupDurationImpl{
+		index:           nextroute.NewModelExpressionIndex(),
+		groupDuration:   record.GroupDuration,
+		toGroupIndex:    record.ToGroupIndex,
+		durations:       record.Durations,
+		stopIndexToStop: make([]nextroute.ModelStop, len(record.Stops)),
+		groupCount:      record.GroupCount,
+	}
+	for idx, stopIndex := range record.Stops {
+		if stopIndex < 0 {
+			continue
+		}
+		stop, err := decoder.Model().Stop(stopIndex)
+		if err != nil {
+			return nil, err
+		}
+		expression.stopIndexToStop[idx] = stop
+	}
+	return expression, nil
+}
+
+// MarshalModel implements nextroute.ModelMarshaler.
+func (d vehicleTypeData) MarshalModel(encoder nextroute.ModelEncoder) (string, []byte, error) {
+	reference, err := encoder.Expression(d.DistanceExpression)
+	if err != nil {
+		return "", nil, err
+	}
+	return vehicleTypeDataKind, []byte(strconv.Itoa(reference)), nil
+}
+
+func unmarshalVehicleTypeData(decoder nextroute.ModelDecoder, data []byte) (any, error) {
+	reference, err := strconv.Atoi(string(data))
+	if err != nil {
+		return nil, err
+	}
+	expression, err := decoder.Expression(reference)
+	if err != nil {
+		return nil, err
+	}
+	distanceExpression, ok := expression.(nextroute.DistanceExpression)
+	if !ok && expression != nil {
+		return nil, fmt.Errorf("expression %s is not a distance expression", expression.Name())
+	}
+	return vehicleTypeData{DistanceExpression: distanceExpression}, nil
+}
@@ -0,0 +1,120 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/schema"
+	"github.com/nextmv-io/sdk/run"
+	runSchema "github.com/nextmv-io/sdk/run/schema"
+)
+
+// TestWriteReadModel writes and reads the models of the golden inputs and
+// expects both models to be solved to the same solutions.
+func TestWriteReadModel(t *testing.T) {
+	forEachGoldenModel(t, func(t *testing.T, model nextroute.Model) {
+		var buffer bytes.Buffer
+		if err := nextroute.WriteModel(&buffer, model); err != nil {
+			t.Fatal(err)
+		}
+		read, err := nextroute.ReadModel(&buffer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want, wantScore := solveModel(t, model)
+		got, gotScore := solveModel(t, read)
+		if wantScore != gotScore {
+			t.Errorf("score %v, want %v", gotScore, wantScore)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("solutions differ after reading the model:\n%s\nwant\n%s", got, want)
+		}
+	})
+}
+
+// forEachGoldenModel runs f as a subtest for the model of each valid golden
+// input, with every objective enabled.
+func forEachGoldenModel(t *testing.T, f func(t *testing.T, model nextroute.Model)) {
+	t.Helper()
+	files, err := filepath.Glob("../tests/golden/testdata/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no golden inputs found")
+	}
+
+	options := Options{}
+	options.Objectives.MinStops = 1
+	options.Objectives.EarlyArrivalPenalty = 1
+	options.Objectives.LateArrivalPenalty = 1
+	options.Objectives.VehicleActivationPenalty = 1
+	options.Objectives.VehiclesDuration = 1
+	options.Objectives.UnplannedPenalty = 1
+
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var input schema.Input
+			if err := json.Unmarshal(data, &input); err != nil {
+				t.Fatal(err)
+			}
+			model, err := NewModel(input, options)
+			if err != nil {
+				// Some golden inputs test invalid models.
+				t.Skip(err)
+			}
+			f(t, model)
+		})
+	}
+}
+
+func solveModel(t *testing.T, model nextroute.Model) (string, float64) {
+	t.Helper()
+	last, output := solve(t, model)
+	data, err := json.Marshal(output.Solutions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data), last.Score()
+}
+
+// solve solves the model deterministically in a few iterations and returns
+// the last solution and its formatted output.
+func solve(t *testing.T, model nextroute.Model) (nextroute.Solution, runSchema.Output) {
+	t.Helper()
+	solver, err := nextroute.NewParallelSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := nextroute.ParallelSolveOptions{
+		Iterations:           20,
+		Duration:             10 * time.Second,
+		ParallelRuns:         1,
+		StartSolutions:       1,
+		RunDeterministically: true,
+	}
+	ctx := context.WithValue(context.Background(), run.Start, time.Now())
+	solutions, err := solver.Solve(ctx, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := solutions.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return last, Format(ctx, options, solver, last)
+}
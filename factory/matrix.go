@@ -0,0 +1,151 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// MatrixProvider provides the duration and distance matrices between
+// locations, for example by calling a routing engine.
+type MatrixProvider interface {
+	// Matrices returns the duration matrix in seconds and the distance matrix
+	// in meters between the given locations. The matrices are indexed in the
+	// order of the locations. Invalid locations result in a row and column of
+	// zeros.
+	Matrices(locations common.Locations) (
+		duration [][]float64,
+		distance [][]float64,
+		err error,
+	)
+}
+
+// SetMatrices uses the provider to compute the duration and distance matrices
+// of the input and sets them on the input. Matrices already present on the
+// input are not replaced. If the provider is nil, the input is returned
+// unchanged. The locations are indexed as described by [MatrixLocations].
+func SetMatrices(
+	input schema.Input,
+	provider MatrixProvider,
+) (schema.Input, error) {
+	if provider == nil ||
+		(input.DurationMatrix != nil && input.DistanceMatrix != nil) {
+		return input, nil
+	}
+
+	locations, err := MatrixLocations(input)
+	if err != nil {
+		return input, err
+	}
+
+	duration, distance, err := provider.Matrices(locations)
+	if err != nil {
+		return input, fmt.Errorf("computing matrices: %w", err)
+	}
+	for _, m := range []struct {
+		name   string
+		matrix [][]float64
+	}{{"duration", duration}, {"distance", distance}} {
+		name, matrix := m.name, m.matrix
+		if len(matrix) != len(locations) {
+			return input, fmt.Errorf(
+				"matrix provider returned a %s matrix with %v rows, expected %v",
+				name,
+				len(matrix),
+				len(locations),
+			)
+		}
+		for i, row := range matrix {
+			if len(row) != len(locations) {
+				return input, fmt.Errorf(
+					"matrix provider returned a %s matrix with %v columns in row %v, expected %v",
+					name,
+					len(row),
+					i,
+					len(locations),
+				)
+			}
+		}
+	}
+
+	if input.DurationMatrix == nil {
+		input.DurationMatrix = &duration
+	}
+	if input.DistanceMatrix == nil {
+		input.DistanceMatrix = &distance
+	}
+
+	return input, nil
+}
+
+// MatrixLocations returns the locations of the input in the order used to
+// index the duration and distance matrices: first the stops, then the
+// alternate stops and finally the start and end location of each vehicle.
+// Defaults are applied before the locations are collected. A vehicle without
+// a start or end location results in an invalid location.
+func MatrixLocations(input schema.Input) (common.Locations, error) {
+	input.Vehicles = slices.Clone(input.Vehicles)
+	input.Stops = slices.Clone(input.Stops)
+	input = applyDefaults(input)
+
+	// The vehicle stops are indexed after the alternate stops, as the measure
+	// indices of the vehicle stops are set by the factory.
+	alternates := 0
+	if input.AlternateStops != nil {
+		alternates = len(*input.AlternateStops)
+	}
+	size := len(input.Stops) + alternates + 2*len(input.Vehicles)
+
+	locations := make(common.Locations, size)
+	for idx := range locations {
+		locations[idx] = common.NewInvalidLocation()
+	}
+
+	index := 0
+	for _, stop := range input.Stops {
+		location, err := toLocation(&stop.Location)
+		if err != nil {
+			return nil, err
+		}
+		locations[index] = location
+		index++
+	}
+
+	if input.AlternateStops != nil {
+		for idx, alternate := range *input.AlternateStops {
+			location, err := toLocation(&alternate.Location)
+			if err != nil {
+				return nil, err
+			}
+			locations[index+idx] = location
+		}
+	}
+	index += alternates
+
+	for _, vehicle := range input.Vehicles {
+		start, err := toLocation(vehicle.StartLocation)
+		if err != nil {
+			return nil, err
+		}
+		end, err := toLocation(vehicle.EndLocation)
+		if err != nil {
+			return nil, err
+		}
+		locations[index] = start
+		locations[index+1] = end
+		index += 2
+	}
+
+	return locations, nil
+}
+
+func toLocation(location *schema.Location) (common.Location, error) {
+	if location == nil {
+		return common.NewInvalidLocation(), nil
+	}
+	return common.NewLocation(location.Lon, location.Lat)
+}
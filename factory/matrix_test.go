@@ -0,0 +1,63 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// TestMatrixLocations expects the locations of an input with alternate stops
+// at the measure indices the factory assigns to the stops of the model.
+func TestMatrixLocations(t *testing.T) {
+	data, err := os.ReadFile("../tests/golden/testdata/alternates.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var input schema.Input
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatal(err)
+	}
+
+	locations, err := MatrixLocations(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := len(input.Stops) + len(*input.AlternateStops) + 2*len(input.Vehicles)
+	if len(locations) != want {
+		t.Fatalf("expected %v locations, got %v", want, len(locations))
+	}
+	for idx, vehicle := range input.Vehicles {
+		start := locations[len(input.Stops)+len(*input.AlternateStops)+2*idx]
+		if !start.IsValid() ||
+			start.Longitude() != vehicle.StartLocation.Lon ||
+			start.Latitude() != vehicle.StartLocation.Lat {
+			t.Errorf("vehicle %v: expected start location %v, got %v", vehicle.ID, *vehicle.StartLocation, start)
+		}
+	}
+
+	model, err := NewModel(input, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stop := range model.Stops() {
+		if stop.MeasureIndex() >= len(locations) {
+			t.Errorf("stop %v: measure index %v out of range", stop.ID(), stop.MeasureIndex())
+			continue
+		}
+		location := locations[stop.MeasureIndex()]
+		if location.IsValid() != stop.Location().IsValid() ||
+			location.IsValid() && location != stop.Location() {
+			t.Errorf(
+				"stop %v: expected location %v at measure index %v, got %v",
+				stop.ID(),
+				stop.Location(),
+				stop.MeasureIndex(),
+				location,
+			)
+		}
+	}
+}
@@ -55,5 +55,13 @@ type Options struct {
 			Matrix                   bool `json:"matrix" usage:"enable matrix validation" default:"false"`
 			MatrixAsymmetryTolerance int  `json:"matrix_asymmetry_tolerance" usage:"percentage of acceptable matrix asymmetry, requires matrix validation enabled" default:"20"`
 		} `json:"enable"`
+		Static string `json:"static" usage:"{off, report, fail} analyze which stops can never be planned before solving, fail if there are any or report them in the check" default:"off"`
 	} `json:"validate"`
+	// MatrixProvider is used to compute the duration and distance matrices
+	// when they are not present on the input. If nil, missing matrices are
+	// not computed.
+	MatrixProvider MatrixProvider `json:"-"`
+	// StaticAnalyzer finds the stops that can never be planned if the static
+	// validation fails, see check.NewStaticAnalyzer.
+	StaticAnalyzer StaticAnalyzer `json:"-"`
 }
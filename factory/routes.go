@@ -0,0 +1,629 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// Route is the route of a vehicle given by the IDs of the vehicle and of its
+// stops, see [LoadRoutes]. The IDs of the stops may start with the start and
+// end with the end of the vehicle.
+type Route struct {
+	// VehicleID is the ID of the vehicle.
+	VehicleID string
+	// StopIDs are the IDs of the stops in the order of the route.
+	StopIDs []string
+}
+
+// RouteViolation is a stop or vehicle of a route that cannot be planned as in
+// the route, see [LoadRoutes].
+type RouteViolation struct {
+	// Route is the index of the route.
+	Route int
+	// Position is the index of the stop in the IDs of the stops of the route,
+	// -1 if the violation is about the vehicle.
+	Position int
+	// Constraint is the name of the violated constraint, empty if the route
+	// does not match the model.
+	Constraint string
+	// Reason explains the violation.
+	Reason string
+}
+
+// MoveExecutor executes a move planning the stops of a plan unit at their
+// positions in a route, see [LoadRoutes]. It returns false and the
+// violations if the move is not planned. The route and position of the
+// violations are set by LoadRoutes.
+type MoveExecutor func(move nextroute.SolutionMoveStops) (bool, []RouteViolation, error)
+
+// LoadedRoutes are the routes loaded by [LoadRoutes].
+type LoadedRoutes struct {
+	// Solution is the solution with the stops of the routes planned.
+	Solution nextroute.Solution
+	// Stops are the stops of the model of the routes by route and position,
+	// nil for a stop that does not match the model.
+	Stops [][]nextroute.ModelStop
+	// Violations are the stops and vehicles of the routes that cannot be
+	// planned as in the routes.
+	Violations []RouteViolation
+}
+
+// LoadRoutes creates a solution of the model and plans the stops of the
+// routes in the order of the routes. Alternate stops are planned as the
+// alternate of the vehicle of the route. Fixed initial stops of the model
+// stay planned on their vehicle, all other stops of the model are unplanned.
+// The moves planning the stops are executed by the executor, if it is nil
+// the moves are executed as is.
+//
+// The plan units of the routes are planned at once with the other plan units
+// of a plan units unit that plans all of its plan units, in the order of
+// their last stop in the routes. A plan unit can fail because of the plan
+// units planned before it, for example if a constraint is violated by the
+// stops in between the stops of the plan unit but not by the complete route.
+// Therefore, the plan units that fail are planned first in a second attempt,
+// the attempt planning more plan units is kept.
+//
+// Stops and vehicles that cannot be planned as in the routes are not dropped
+// silently but returned as violations and stay unplanned: stops and vehicles
+// that are not part of the model, stops that are part of more than one
+// route, plan units of which only some stops are part of the routes or that
+// span more than one vehicle, more than one of the plan units of a plan units
+// unit that plans one of its plan units and stops that violate a constraint.
+func LoadRoutes(
+	model nextroute.Model,
+	routes []Route,
+	execute MoveExecutor,
+) (LoadedRoutes, error) {
+	if model == nil {
+		return LoadedRoutes{}, fmt.Errorf("model is nil")
+	}
+	if execute == nil {
+		execute = func(move nextroute.SolutionMoveStops) (bool, []RouteViolation, error) {
+			planned, err := move.Execute(context.Background())
+			return planned, nil, err
+		}
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		return LoadedRoutes{}, err
+	}
+	for _, solutionPlanUnit := range solution.PlannedPlanUnits().SolutionPlanUnits() {
+		if solutionPlanUnit.IsFixed() {
+			continue
+		}
+		if _, err := solutionPlanUnit.UnPlan(); err != nil {
+			return LoadedRoutes{}, err
+		}
+	}
+
+	loader := &routeLoader{
+		solution: solution,
+		execute:  execute,
+		loaded: LoadedRoutes{
+			Solution:   solution,
+			Violations: make([]RouteViolation, 0),
+		},
+	}
+	if err := loader.resolve(routes); err != nil {
+		return LoadedRoutes{}, err
+	}
+	loader.group()
+	if err := loader.plan(); err != nil {
+		return LoadedRoutes{}, err
+	}
+
+	return loader.loaded, nil
+}
+
+// routeStop is a stop of a route that refers to a stop of the model.
+type routeStop struct {
+	modelStop nextroute.ModelStop
+	// vehicle is the index of the route, position the index of the stop in
+	// the resolved stops of the route and index the index of the stop in the
+	// IDs of the stops of the route.
+	vehicle  int
+	position int
+	index    int
+}
+
+// routeUnit is a plan stops unit of the model with its stops in the routes.
+type routeUnit struct {
+	modelPlanStopsUnit nextroute.ModelPlanStopsUnit
+	stops              []routeStop
+}
+
+// routeGroup is a set of plan units that is planned at once. It is either a
+// plan units unit that plans all of its plan units or a single plan unit.
+type routeGroup struct {
+	modelPlanUnitsUnit nextroute.ModelPlanUnitsUnit
+	units              []*routeUnit
+	// violations are the violations of the last attempt to plan the group.
+	violations []RouteViolation
+	// last is the order of the last stop of the group in the routes.
+	last     int
+	rejected bool
+}
+
+type routeLoader struct {
+	solution nextroute.Solution
+	execute  MoveExecutor
+	loaded   LoadedRoutes
+	// vehicles are the vehicles of the routes by index, routes of vehicles
+	// that are not part of the model have no vehicle.
+	vehicles map[int]nextroute.SolutionVehicle
+	routes   [][]routeStop
+	groups   []*routeGroup
+}
+
+// resolve resolves the routes to the vehicles and stops of the model.
+func (l *routeLoader) resolve(routes []Route) error {
+	model := l.solution.Model()
+	data, err := getModelData(model)
+	if err != nil {
+		return err
+	}
+
+	modelVehicles := make(map[string]nextroute.ModelVehicle, len(model.Vehicles()))
+	for _, modelVehicle := range model.Vehicles() {
+		modelVehicles[modelVehicle.ID()] = modelVehicle
+	}
+	modelStops := make(map[string]nextroute.ModelStop, len(model.Stops()))
+	for _, modelStop := range model.Stops() {
+		if _, alternate := modelStop.Data().(alternateInputStop); alternate || modelStop.IsFirstOrLast() {
+			continue
+		}
+		if _, ok := modelStops[modelStop.ID()]; !ok {
+			modelStops[modelStop.ID()] = modelStop
+		}
+	}
+
+	l.vehicles = make(map[int]nextroute.SolutionVehicle, len(routes))
+	l.routes = make([][]routeStop, len(routes))
+	l.loaded.Stops = make([][]nextroute.ModelStop, len(routes))
+	routeOfVehicle := make(map[string]bool, len(routes))
+	routeOfStop := make(map[int]string)
+	for vehicleIdx, route := range routes {
+		l.loaded.Stops[vehicleIdx] = make([]nextroute.ModelStop, len(route.StopIDs))
+		modelVehicle, ok := modelVehicles[route.VehicleID]
+		switch {
+		case !ok:
+			l.violation(vehicleIdx, -1, "vehicle %s is not part of the model", route.VehicleID)
+		case routeOfVehicle[route.VehicleID]:
+			l.violation(vehicleIdx, -1, "vehicle %s has more than one route", route.VehicleID)
+			ok = false
+		}
+		routeOfVehicle[route.VehicleID] = true
+
+		for index, id := range route.StopIDs {
+			if ok && isRouteEnd(modelVehicle, id, index, len(route.StopIDs)) {
+				if index == 0 {
+					l.loaded.Stops[vehicleIdx][index] = modelVehicle.First()
+				} else {
+					l.loaded.Stops[vehicleIdx][index] = modelVehicle.Last()
+				}
+				continue
+			}
+
+			modelStop, found := modelStops[id]
+			if inputVehicle, isInput := vehicleData(modelVehicle); ok && isInput {
+				if stopIndex, alternate := data.stopIDToIndex[alternateStopID(id, inputVehicle)]; alternate {
+					if modelStop, err = model.Stop(stopIndex); err != nil {
+						return err
+					}
+					found = true
+				}
+			}
+			if !found {
+				l.violation(vehicleIdx, index, "stop %s is not part of the model", id)
+				continue
+			}
+			if vehicle, planned := routeOfStop[modelStop.Index()]; planned {
+				l.violation(
+					vehicleIdx,
+					index,
+					"stop %s is already part of the route of vehicle %s",
+					id,
+					vehicle,
+				)
+				continue
+			}
+			routeOfStop[modelStop.Index()] = route.VehicleID
+			l.loaded.Stops[vehicleIdx][index] = modelStop
+			if !ok {
+				l.violation(vehicleIdx, index, "stop %s cannot be planned without vehicle %s", id, route.VehicleID)
+				continue
+			}
+
+			solutionStop := l.solution.SolutionStop(modelStop)
+			if solutionStop.IsPlanned() {
+				vehicle := solutionStop.Vehicle().ModelVehicle()
+				if vehicle.Index() != modelVehicle.Index() {
+					l.violation(vehicleIdx, index, "stop %s is fixed on vehicle %s", id, vehicle.ID())
+					continue
+				}
+			}
+			l.routes[vehicleIdx] = append(l.routes[vehicleIdx], routeStop{
+				modelStop: modelStop,
+				vehicle:   vehicleIdx,
+				position:  len(l.routes[vehicleIdx]),
+				index:     index,
+			})
+		}
+		if ok {
+			l.vehicles[vehicleIdx] = l.solution.SolutionVehicle(modelVehicle)
+		}
+	}
+
+	return nil
+}
+
+// group collects the unplanned stops of the routes in plan units and the
+// plan units in groups that are planned at once, in the order of their last
+// stop in the routes. Groups that cannot be planned as in the routes are
+// rejected.
+func (l *routeLoader) group() {
+	units := make(map[int]*routeUnit)
+	groups := make(map[int]*routeGroup)
+	oneOf := make(map[int][]*routeGroup)
+	var parents []nextroute.ModelPlanUnitsUnit
+	order := 0
+	for _, route := range l.routes {
+		for _, stop := range route {
+			order++
+			if l.solution.SolutionStop(stop.modelStop).IsPlanned() {
+				continue
+			}
+			modelPlanStopsUnit := stop.modelStop.PlanStopsUnit()
+			unit, ok := units[modelPlanStopsUnit.Index()]
+			if !ok {
+				unit = &routeUnit{modelPlanStopsUnit: modelPlanStopsUnit}
+				units[modelPlanStopsUnit.Index()] = unit
+			}
+			unit.stops = append(unit.stops, stop)
+
+			var modelPlanUnitsUnit nextroute.ModelPlanUnitsUnit
+			key := modelPlanStopsUnit.Index()
+			parent, hasParent := modelPlanStopsUnit.PlanUnitsUnit()
+			if hasParent && parent.PlanAll() {
+				modelPlanUnitsUnit = parent
+				key = parent.Index()
+			}
+			group, ok := groups[key]
+			if !ok {
+				group = &routeGroup{modelPlanUnitsUnit: modelPlanUnitsUnit}
+				groups[key] = group
+				l.groups = append(l.groups, group)
+				if hasParent && !parent.PlanAll() {
+					if _, ok := oneOf[parent.Index()]; !ok {
+						parents = append(parents, parent)
+					}
+					oneOf[parent.Index()] = append(oneOf[parent.Index()], group)
+				}
+			}
+			if len(unit.stops) == 1 {
+				group.units = append(group.units, unit)
+			}
+			group.last = order
+		}
+	}
+	sort.SliceStable(l.groups, func(i, j int) bool {
+		return l.groups[i].last < l.groups[j].last
+	})
+
+	for _, group := range l.groups {
+		for _, unit := range group.units {
+			switch {
+			case len(vehiclesOf(unit)) > 1:
+				l.reject(group, "stops %v of the same plan unit must be on the same vehicle", toIDs(unit.modelPlanStopsUnit))
+			case len(unit.stops) != len(unit.modelPlanStopsUnit.Stops()):
+				l.reject(group, "stops %v of the same plan unit must all be part of the routes", toIDs(unit.modelPlanStopsUnit))
+			}
+		}
+		if group.rejected || group.modelPlanUnitsUnit == nil {
+			continue
+		}
+		switch {
+		case len(group.units) != len(group.modelPlanUnitsUnit.PlanUnits()):
+			l.reject(group, "stops %v of the same group must all be part of the routes", toIDs(group.modelPlanUnitsUnit))
+		case group.modelPlanUnitsUnit.SameVehicle() && len(vehiclesOf(group.units...)) > 1:
+			l.reject(group, "stops %v of the same group must be on the same vehicle", toIDs(group.modelPlanUnitsUnit))
+		}
+	}
+
+	// Only one of the plan units of a plan units unit that plans one of its
+	// plan units, such as the alternate stops, can be planned.
+	for _, parent := range parents {
+		groups := oneOf[parent.Index()]
+		if len(groups) == 1 && !l.solution.SolutionPlanUnit(parent).IsPlanned() {
+			continue
+		}
+		for _, group := range groups {
+			l.reject(group, "only one of the stops %v can be planned", toIDs(parent))
+		}
+	}
+}
+
+// plan plans the groups that are not rejected in the order of the routes.
+// The groups that fail are planned first in a second attempt, the second
+// attempt is kept if it plans more groups. The groups that cannot be planned
+// are rejected with the violations of their last attempt and stay unplanned.
+func (l *routeLoader) plan() error {
+	groups := make([]*routeGroup, 0, len(l.groups))
+	for _, group := range l.groups {
+		if !group.rejected {
+			groups = append(groups, group)
+		}
+	}
+
+	failed, err := l.attempt(groups)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		reordered := append(slices.Clone(failed), slices.DeleteFunc(
+			slices.Clone(groups),
+			func(group *routeGroup) bool {
+				return slices.Contains(failed, group)
+			},
+		)...)
+		retried, err := l.attempt(reordered)
+		if err != nil {
+			return err
+		}
+		if len(retried) >= len(failed) {
+			if failed, err = l.attempt(groups); err != nil {
+				return err
+			}
+		} else {
+			failed = retried
+		}
+	}
+
+	for _, group := range failed {
+		if len(group.violations) == 0 {
+			l.reject(group, "stops %v cannot be planned as in the routes", toIDs(group.modelPlanUnit()))
+			continue
+		}
+		group.rejected = true
+		for _, unit := range group.units {
+			for _, stop := range unit.stops {
+				for _, violation := range group.violations {
+					violation.Route = stop.vehicle
+					violation.Position = stop.index
+					l.loaded.Violations = append(l.loaded.Violations, violation)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// attempt unplans the groups planned by a previous attempt and plans the
+// groups in order. Groups that cannot be planned are planned again after the
+// other groups, until no more groups can be planned. Returns the groups that
+// cannot be planned.
+func (l *routeLoader) attempt(groups []*routeGroup) ([]*routeGroup, error) {
+	for _, group := range groups {
+		solutionPlanUnit := l.solution.SolutionPlanUnit(group.modelPlanUnit())
+		if !solutionPlanUnit.IsPlanned() {
+			continue
+		}
+		if _, err := solutionPlanUnit.UnPlan(); err != nil {
+			return nil, err
+		}
+	}
+
+	pending := slices.Clone(groups)
+	for progress := true; progress; {
+		progress = false
+		remaining := pending[:0]
+		for _, group := range pending {
+			planned, err := l.executeGroup(group)
+			if err != nil {
+				return nil, err
+			}
+			if !planned {
+				remaining = append(remaining, group)
+				continue
+			}
+			progress = true
+		}
+		pending = remaining
+	}
+	return pending, nil
+}
+
+// executeGroup plans the plan units of the group at their positions in the
+// routes. Returns false, sets the violations of the group and leaves the
+// group unplanned if a plan unit cannot be planned.
+func (l *routeLoader) executeGroup(group *routeGroup) (bool, error) {
+	group.violations = nil
+	moves := make(nextroute.SolutionMoves, 0, len(group.units))
+	for _, unit := range group.units {
+		move, err := l.move(unit)
+		if err != nil {
+			group.violations = []RouteViolation{{Reason: err.Error()}}
+			return false, revertMoves(moves)
+		}
+		planned, violations, err := l.execute(move)
+		if err != nil {
+			return false, err
+		}
+		if !planned {
+			group.violations = violations
+			return false, revertMoves(moves)
+		}
+		moves = append(moves, move)
+	}
+	if group.modelPlanUnitsUnit == nil {
+		return true, nil
+	}
+
+	// The plan units of a group are planned at once, the moves of the plan
+	// units are planned again as a single move.
+	if err := revertMoves(moves); err != nil {
+		return false, err
+	}
+	move, err := nextroute.NewMoveUnits(
+		l.solution.SolutionPlanUnit(group.modelPlanUnitsUnit).(nextroute.SolutionPlanUnitsUnit),
+		moves,
+	)
+	if err != nil {
+		return false, err
+	}
+	planned, err := move.Execute(context.Background())
+	if err != nil {
+		return false, err
+	}
+	if !planned {
+		return false, fmt.Errorf(
+			"group %v cannot be planned after planning its plan units",
+			toIDs(group.modelPlanUnitsUnit),
+		)
+	}
+	return true, nil
+}
+
+// move returns the move planning the stops of the plan unit at their
+// positions in the route. Each stop is planned after the closest stop before
+// it in the route that is planned or part of the plan unit.
+func (l *routeLoader) move(unit *routeUnit) (nextroute.SolutionMoveStops, error) {
+	route := l.routes[unit.stops[0].vehicle]
+	vehicle := l.vehicles[unit.stops[0].vehicle]
+	positioned := func(stop routeStop) bool {
+		return stop.modelStop.PlanStopsUnit().Index() == unit.modelPlanStopsUnit.Index() ||
+			l.solution.SolutionStop(stop.modelStop).IsPlanned()
+	}
+
+	stopPositions := make(nextroute.StopPositions, len(unit.stops))
+	for idx, stop := range unit.stops {
+		previous := vehicle.First()
+		for p := stop.position - 1; p >= 0; p-- {
+			if positioned(route[p]) {
+				previous = l.solution.SolutionStop(route[p].modelStop)
+				break
+			}
+		}
+		next := vehicle.Last()
+		for n := stop.position + 1; n < len(route); n++ {
+			if positioned(route[n]) {
+				next = l.solution.SolutionStop(route[n].modelStop)
+				break
+			}
+		}
+		stopPosition, err := nextroute.NewStopPosition(
+			previous,
+			l.solution.SolutionStop(stop.modelStop),
+			next,
+		)
+		if err != nil {
+			return nil, err
+		}
+		stopPositions[idx] = stopPosition
+	}
+
+	return nextroute.NewMoveStops(
+		l.solution.SolutionPlanStopsUnit(unit.modelPlanStopsUnit),
+		stopPositions,
+	)
+}
+
+// reject marks the group as rejected and adds a violation for every stop of
+// the group that is part of the routes.
+func (l *routeLoader) reject(group *routeGroup, format string, a ...any) {
+	group.rejected = true
+	for _, unit := range group.units {
+		for _, stop := range unit.stops {
+			l.violation(stop.vehicle, stop.index, format, a...)
+		}
+	}
+}
+
+func (l *routeLoader) violation(route, position int, format string, a ...any) {
+	l.loaded.Violations = append(l.loaded.Violations, RouteViolation{
+		Route:    route,
+		Position: position,
+		Reason:   fmt.Sprintf(format, a...),
+	})
+}
+
+// modelPlanUnit returns the plan unit of the model that is planned by the
+// group.
+func (g *routeGroup) modelPlanUnit() nextroute.ModelPlanUnit {
+	if g.modelPlanUnitsUnit != nil {
+		return g.modelPlanUnitsUnit
+	}
+	return g.units[0].modelPlanStopsUnit
+}
+
+// vehiclesOf returns the indices of the routes of the stops of the units.
+func vehiclesOf(units ...*routeUnit) map[int]bool {
+	vehicles := make(map[int]bool)
+	for _, unit := range units {
+		for _, stop := range unit.stops {
+			vehicles[stop.vehicle] = true
+		}
+	}
+	return vehicles
+}
+
+// isRouteEnd returns true if the stop is the first stop of the route and the
+// start of the vehicle or the last stop of the route and the end of the
+// vehicle.
+func isRouteEnd(vehicle nextroute.ModelVehicle, id string, position, length int) bool {
+	return (position == 0 && id == vehicle.First().ID()) ||
+		(position == length-1 && id == vehicle.Last().ID())
+}
+
+// vehicleData returns the input vehicle of the vehicle, if any.
+func vehicleData(vehicle nextroute.ModelVehicle) (schema.Vehicle, bool) {
+	if vehicle == nil {
+		return schema.Vehicle{}, false
+	}
+	inputVehicle, ok := vehicle.Data().(schema.Vehicle)
+	return inputVehicle, ok
+}
+
+// revertMoves unplans the plan units of the executed moves in reverse order.
+func revertMoves(moves nextroute.SolutionMoves) error {
+	for idx := len(moves) - 1; idx >= 0; idx-- {
+		unplanned, err := moves[idx].PlanUnit().UnPlan()
+		if err != nil {
+			return err
+		}
+		if !unplanned {
+			return fmt.Errorf(
+				"plan unit %v cannot be unplanned",
+				toIDs(moves[idx].PlanUnit().ModelPlanUnit()),
+			)
+		}
+	}
+	return nil
+}
+
+// toIDs returns the IDs of the stops of the plan unit.
+func toIDs(modelPlanUnit nextroute.ModelPlanUnit) []string {
+	switch unit := modelPlanUnit.(type) {
+	case nextroute.ModelPlanStopsUnit:
+		ids := make([]string, len(unit.Stops()))
+		for idx, stop := range unit.Stops() {
+			ids[idx] = stop.ID()
+		}
+		return ids
+	case nextroute.ModelPlanUnitsUnit:
+		var ids []string
+		for _, planUnit := range unit.PlanUnits() {
+			ids = append(ids, toIDs(planUnit)...)
+		}
+		return ids
+	}
+	return []string{}
+}
@@ -0,0 +1,120 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"fmt"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// SolutionMismatch is a stop or vehicle of a [schema.SolutionOutput] that does
+// not match the model it is loaded for, see [SolutionFromOutput].
+type SolutionMismatch struct {
+	// VehicleID is the ID of the vehicle of the mismatch. It is empty if the
+	// mismatch is about a stop that is not part of a route.
+	VehicleID string `json:"vehicle_id,omitempty"`
+	// StopID is the ID of the stop of the mismatch. It is empty if the
+	// mismatch is about a vehicle.
+	StopID string `json:"stop_id,omitempty"`
+	// Reason explains the mismatch.
+	Reason string `json:"reason"`
+}
+
+// SolutionFromOutput creates a solution of the model from an output created
+// by [ToSolutionOutput], for example to check it, evaluate its objective or
+// to continue solving from it. The routes of the output are loaded with
+// [LoadRoutes].
+//
+// Stops and vehicles of the output that do not match the model are not
+// dropped silently but returned as mismatches: the violations of the routes
+// and stops and vehicles of the model that are not part of the output. The
+// stops of the mismatches stay unplanned.
+func SolutionFromOutput(
+	model nextroute.Model,
+	output schema.SolutionOutput,
+) (nextroute.Solution, []SolutionMismatch, error) {
+	routes := make([]Route, len(output.Vehicles))
+	for idx, vehicleOutput := range output.Vehicles {
+		routes[idx] = Route{
+			VehicleID: vehicleOutput.ID,
+			StopIDs:   make([]string, len(vehicleOutput.Route)),
+		}
+		for position, plannedStop := range vehicleOutput.Route {
+			routes[idx].StopIDs[position] = plannedStop.Stop.ID
+		}
+	}
+
+	loaded, err := LoadRoutes(model, routes, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mismatches := make([]SolutionMismatch, 0, len(loaded.Violations))
+	for _, violation := range loaded.Violations {
+		mismatch := SolutionMismatch{
+			VehicleID: routes[violation.Route].VehicleID,
+			Reason:    violation.Reason,
+		}
+		if violation.Position >= 0 {
+			mismatch.StopID = routes[violation.Route].StopIDs[violation.Position]
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	return loaded.Solution, append(mismatches, unmatched(model, output)...), nil
+}
+
+// unmatched returns the mismatches of the unplanned stops of the output and
+// of the stops and vehicles of the model that are not part of the output.
+func unmatched(
+	model nextroute.Model,
+	output schema.SolutionOutput,
+) []SolutionMismatch {
+	mismatches := make([]SolutionMismatch, 0)
+	mentioned := make(map[string]bool)
+	for _, vehicleOutput := range output.Vehicles {
+		for _, plannedStop := range vehicleOutput.Route {
+			mentioned[plannedStop.Stop.ID] = true
+		}
+	}
+
+	modelStops := make(map[string]bool, len(model.Stops()))
+	for _, modelStop := range model.Stops() {
+		modelStops[modelStop.ID()] = true
+	}
+	for _, stopOutput := range output.Unplanned {
+		mentioned[stopOutput.ID] = true
+		if !modelStops[stopOutput.ID] {
+			mismatches = append(mismatches, SolutionMismatch{
+				StopID: stopOutput.ID,
+				Reason: fmt.Sprintf("stop %s is not part of the model", stopOutput.ID),
+			})
+		}
+	}
+
+	routeOfVehicle := make(map[string]bool, len(output.Vehicles))
+	for _, vehicleOutput := range output.Vehicles {
+		routeOfVehicle[vehicleOutput.ID] = true
+	}
+	for _, modelVehicle := range model.Vehicles() {
+		if !routeOfVehicle[modelVehicle.ID()] {
+			mismatches = append(mismatches, SolutionMismatch{
+				VehicleID: modelVehicle.ID(),
+				Reason:    fmt.Sprintf("vehicle %s is not part of the output", modelVehicle.ID()),
+			})
+		}
+	}
+	for _, modelStop := range model.Stops() {
+		if modelStop.IsFirstOrLast() || mentioned[modelStop.ID()] {
+			continue
+		}
+		mentioned[modelStop.ID()] = true
+		mismatches = append(mismatches, SolutionMismatch{
+			StopID: modelStop.ID(),
+			Reason: fmt.Sprintf("stop %s is not part of the output", modelStop.ID()),
+		})
+	}
+	return mismatches
+}
@@ -0,0 +1,120 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// TestSolutionFromOutput creates the solutions of the golden inputs from
+// their output and expects the same solutions without mismatches.
+func TestSolutionFromOutput(t *testing.T) {
+	forEachGoldenModel(t, func(t *testing.T, model nextroute.Model) {
+		solution, _ := solve(t, model)
+		output := ToSolutionOutput(solution)
+
+		loaded, mismatches, err := SolutionFromOutput(model, output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mismatches) > 0 {
+			t.Fatalf("expected no mismatches, got %v", mismatches)
+		}
+		if loaded.Score() != solution.Score() {
+			t.Errorf("score %v, want %v", loaded.Score(), solution.Score())
+		}
+		got, err := json.Marshal(ToSolutionOutput(loaded))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := json.Marshal(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("output differs after loading the solution:\n%s\nwant\n%s", got, want)
+		}
+	})
+}
+
+func TestSolutionFromOutputMismatches(t *testing.T) {
+	speed := 10.0
+	input := schema.Input{
+		Stops: []schema.Stop{
+			{ID: "pickup", Location: schema.Location{Lon: 7.62, Lat: 51.96}},
+			{ID: "delivery", Location: schema.Location{Lon: 7.63, Lat: 51.97}, Precedes: "pickup"},
+			{ID: "s1", Location: schema.Location{Lon: 7.64, Lat: 51.98}},
+			{ID: "s2", Location: schema.Location{Lon: 7.65, Lat: 51.99}},
+			{ID: "s3", Location: schema.Location{Lon: 7.66, Lat: 52.00}},
+		},
+		AlternateStops: &[]schema.AlternateStop{
+			{ID: "a1", Location: schema.Location{Lon: 7.61, Lat: 51.95}},
+			{ID: "a2", Location: schema.Location{Lon: 7.62, Lat: 51.95}},
+		},
+		Vehicles: []schema.Vehicle{
+			{ID: "v1", Speed: &speed, AlternateStops: &[]string{"a1", "a2"}},
+			{ID: "v2", Speed: &speed, AlternateStops: &[]string{"a1"}},
+			{ID: "v3", Speed: &speed},
+		},
+	}
+	model, err := NewModel(input, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	route := func(ids ...string) []schema.PlannedStopOutput {
+		stops := make([]schema.PlannedStopOutput, len(ids))
+		for idx, id := range ids {
+			stops[idx].Stop.ID = id
+		}
+		return stops
+	}
+	output := schema.SolutionOutput{
+		Vehicles: []schema.VehicleOutput{
+			{ID: "v1", Route: route("s1", "pickup", "a1", "a2", "unknown")},
+			{ID: "v2", Route: route("delivery", "a1")},
+			{ID: "v4", Route: route("s2")},
+		},
+		Unplanned: []schema.StopOutput{{ID: "removed"}},
+	}
+
+	solution, mismatches, err := SolutionFromOutput(model, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []SolutionMismatch{
+		{VehicleID: "v1", StopID: "unknown", Reason: "stop unknown is not part of the model"},
+		{VehicleID: "v4", Reason: "vehicle v4 is not part of the model"},
+		{VehicleID: "v4", StopID: "s2", Reason: "stop s2 cannot be planned without vehicle v4"},
+		{VehicleID: "v1", StopID: "pickup", Reason: "stops [delivery pickup] of the same plan unit must be on the same vehicle"},
+		{VehicleID: "v2", StopID: "delivery", Reason: "stops [delivery pickup] of the same plan unit must be on the same vehicle"},
+		{VehicleID: "v1", StopID: "a1", Reason: "only one of the stops [a1 a2] can be planned"},
+		{VehicleID: "v1", StopID: "a2", Reason: "only one of the stops [a1 a2] can be planned"},
+		{StopID: "removed", Reason: "stop removed is not part of the model"},
+		{VehicleID: "v3", Reason: "vehicle v3 is not part of the output"},
+		{StopID: "s3", Reason: "stop s3 is not part of the output"},
+	}
+	if !reflect.DeepEqual(mismatches, want) {
+		t.Errorf("mismatches\n%v\nwant\n%v", mismatches, want)
+	}
+
+	planned := map[string][]string{}
+	for _, vehicle := range solution.Vehicles() {
+		for _, stop := range vehicle.SolutionStops() {
+			if stop.IsFirst() || stop.IsLast() {
+				continue
+			}
+			planned[vehicle.ModelVehicle().ID()] = append(planned[vehicle.ModelVehicle().ID()], stop.ModelStop().ID())
+		}
+	}
+	wantPlanned := map[string][]string{"v1": {"s1"}, "v2": {"a1"}}
+	if !reflect.DeepEqual(planned, wantPlanned) {
+		t.Errorf("planned stops %v, want %v", planned, wantPlanned)
+	}
+}
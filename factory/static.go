@@ -0,0 +1,90 @@
+// © 2019-present nextmv.io inc
+
+package factory
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/nextmv-io/nextroute"
+	nmerror "github.com/nextmv-io/nextroute/common/errors"
+)
+
+// StaticAnalyzer finds the stops of a model that can never be planned,
+// without solving. It is used by [NewModel] if Options.Validate.Static is
+// "fail".
+type StaticAnalyzer interface {
+	// Analyze returns the reasons why a stop can never be planned by the ID
+	// of the stop. Stops that may be planned are not part of it.
+	Analyze(model nextroute.Model) (map[string][]string, error)
+}
+
+// The static analysis modes of Options.Validate.Static.
+const (
+	staticOff    = "off"
+	staticReport = "report"
+	staticFail   = "fail"
+)
+
+// maximumStaticStops is the maximum number of stops named in the error if the
+// static analysis fails.
+const maximumStaticStops = 10
+
+func validateStatic(modelOptions Options) error {
+	switch modelOptions.Validate.Static {
+	case "", staticOff, staticReport:
+		return nil
+	case staticFail:
+		if modelOptions.StaticAnalyzer == nil {
+			return nmerror.NewInputDataError(fmt.Errorf(
+				"static validation %s requires a static analyzer",
+				staticFail,
+			))
+		}
+		return nil
+	}
+	return nmerror.NewInputDataError(fmt.Errorf(
+		"static validation must be one of %s, %s or %s, not %s",
+		staticOff,
+		staticReport,
+		staticFail,
+		modelOptions.Validate.Static,
+	))
+}
+
+// analyzeStatic fails if the static analyzer finds stops that can never be
+// planned and the static validation fails fast. Reporting is left to the
+// caller, for example by adding the analysis to the check output.
+func analyzeStatic(model nextroute.Model, modelOptions Options) error {
+	if modelOptions.Validate.Static != staticFail {
+		return nil
+	}
+
+	reasons, err := modelOptions.StaticAnalyzer.Analyze(model)
+	if err != nil {
+		return err
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(reasons))
+	for id := range reasons {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	stops := make([]string, 0, maximumStaticStops)
+	for _, id := range ids[:min(len(ids), maximumStaticStops)] {
+		stops = append(stops, fmt.Sprintf("`%s` (%s)", id, strings.Join(reasons[id], "; ")))
+	}
+	return nmerror.NewInputDataError(fmt.Errorf(
+		"%d stops can never be planned, first %d are %s, if intended use"+
+			" validate option to report instead (`options.Model.Validate.Static = %s`)",
+		len(ids),
+		len(stops),
+		strings.Join(stops, ", "),
+		staticReport,
+	))
+}
@@ -233,6 +233,10 @@ func validateMatrix(
 }
 
 func validateConstraints(input schema.Input, modelOptions Options) error {
+	if err := validateStatic(modelOptions); err != nil {
+		return err
+	}
+
 	if !modelOptions.Validate.Disable.StartTime {
 		hasStartTimeWindow := common.Has(
 			input.Stops,
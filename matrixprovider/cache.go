@@ -0,0 +1,125 @@
+// © 2019-present nextmv.io inc
+
+package matrixprovider
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/nextroute/factory"
+)
+
+// CacheOptions configure the disk cache of the matrices.
+type CacheOptions struct {
+	// Directory is the directory the matrices are stored in. If empty, the
+	// matrices are not cached.
+	Directory string `json:"directory" usage:"directory to cache computed matrices in"`
+}
+
+// NewDiskCache creates a [factory.MatrixProvider] that stores the matrices
+// computed by the provider in the directory. The matrices are stored in a
+// file named after a hash of the namespace and the locations, the same
+// locations in the same order are only computed once. The namespace
+// distinguishes providers sharing a directory, for example by including the
+// URL and profile of a server. The directory is created if it does not
+// exist.
+func NewDiskCache(
+	provider factory.MatrixProvider,
+	directory string,
+	namespace string,
+) factory.MatrixProvider {
+	return &diskCacheImpl{
+		provider:  provider,
+		directory: directory,
+		namespace: namespace,
+	}
+}
+
+type diskCacheImpl struct {
+	provider  factory.MatrixProvider
+	directory string
+	namespace string
+}
+
+type cachedMatrices struct {
+	Duration [][]float64 `json:"duration"`
+	Distance [][]float64 `json:"distance"`
+}
+
+// key returns the content address of the locations.
+func (c *diskCacheImpl) key(locations common.Locations) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.namespace))
+	buffer := make([]byte, 8)
+	for _, location := range locations {
+		for _, value := range []float64{location.Longitude(), location.Latitude()} {
+			binary.LittleEndian.PutUint64(buffer, math.Float64bits(value))
+			hash.Write(buffer)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *diskCacheImpl) Matrices(
+	locations common.Locations,
+) ([][]float64, [][]float64, error) {
+	path := filepath.Join(c.directory, c.key(locations)+".json")
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var cached cachedMatrices
+		if err := json.Unmarshal(data, &cached); err != nil {
+			return nil, nil, fmt.Errorf("reading cached matrices %s: %w", path, err)
+		}
+		return cached.Duration, cached.Distance, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, err
+	}
+
+	duration, distance, err := c.provider.Matrices(locations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = json.Marshal(cachedMatrices{
+		Duration: duration,
+		Distance: distance,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(c.directory, 0o755); err != nil {
+		return nil, nil, err
+	}
+	// Write to a temporary file first so a concurrent reader never sees a
+	// partially written file.
+	file, err := os.CreateTemp(c.directory, "matrices-*.tmp")
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, nil, err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return nil, nil, err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return nil, nil, err
+	}
+
+	return duration, distance, nil
+}
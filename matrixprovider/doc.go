@@ -0,0 +1,28 @@
+// © 2019-present nextmv.io inc
+
+/*
+Package matrixprovider holds implementations of [factory.MatrixProvider] that
+compute the duration and distance matrices of an input when they are not
+given.
+
+A provider created with [NewOSRM] requests the matrices from a server
+implementing the OSRM `/table` API. Large sets of locations are split into
+blocks, each request covers the sources of one block and the destinations of
+another block. A provider can be wrapped with [NewDiskCache] to store the
+matrices on disk, keyed by the set of locations, so they are not requested
+again:
+
+	provider, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL: "http://localhost:5000",
+	})
+	if err != nil {
+		return err
+	}
+	options.MatrixProvider = matrixprovider.NewDiskCache(
+		provider,
+		"matrices",
+		"osrm",
+	)
+	model, err := factory.NewModel(input, options)
+*/
+package matrixprovider
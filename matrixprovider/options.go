@@ -0,0 +1,9 @@
+// © 2019-present nextmv.io inc
+
+package matrixprovider
+
+// Options configure the matrix providers.
+type Options struct {
+	OSRM  OSRMOptions  `json:"osrm"`
+	Cache CacheOptions `json:"cache"`
+}
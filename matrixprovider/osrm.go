@@ -0,0 +1,244 @@
+// © 2019-present nextmv.io inc
+
+package matrixprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/nextroute/factory"
+)
+
+// OSRMOptions configure a provider using the OSRM `/table` API.
+type OSRMOptions struct {
+	// URL is the base URL of the server, for example
+	// `http://localhost:5000`. If empty, no OSRM provider is used.
+	URL string `json:"url" usage:"base URL of an OSRM compatible server used to compute missing matrices"`
+	// Profile is the routing profile used in the request path.
+	Profile string `json:"profile" usage:"routing profile of the OSRM server" default:"driving"`
+	// ChunkSize is the maximum number of sources and destinations together,
+	// the coordinates, in a single request. It matches the maximum table size
+	// of the server, at least 2.
+	ChunkSize int `json:"chunk_size" usage:"maximum number of sources and destinations in a single OSRM request" default:"100"`
+	// Timeout is the maximum duration of a single request.
+	Timeout time.Duration `json:"timeout" usage:"maximum duration of a single OSRM request" default:"60s"`
+}
+
+// NewOSRM creates a [factory.MatrixProvider] that requests the matrices from
+// a server implementing the OSRM `/table` API. Zero values of the options,
+// except for the URL, are replaced by their defaults. Returns an error if
+// the URL is empty or invalid.
+func NewOSRM(options OSRMOptions) (factory.MatrixProvider, error) {
+	if options.URL == "" {
+		return nil, fmt.Errorf("OSRM url is empty")
+	}
+	base, err := url.Parse(strings.TrimRight(options.URL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OSRM url %s: %w", options.URL, err)
+	}
+	if options.Profile == "" {
+		options.Profile = "driving"
+	}
+	if options.ChunkSize <= 0 {
+		options.ChunkSize = 100
+	}
+	if options.ChunkSize < 2 {
+		return nil, fmt.Errorf(
+			"OSRM chunk size %v must be at least 2",
+			options.ChunkSize,
+		)
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = time.Minute
+	}
+	return &osrmImpl{
+		base:    base,
+		options: options,
+		client:  &http.Client{Timeout: options.Timeout},
+	}, nil
+}
+
+type osrmImpl struct {
+	base    *url.URL
+	client  *http.Client
+	options OSRMOptions
+}
+
+// osrmTable is the response of the OSRM `/table` API. Unreachable pairs are
+// null.
+type osrmTable struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Durations [][]*float64 `json:"durations"`
+	Distances [][]*float64 `json:"distances"`
+}
+
+func (o *osrmImpl) Matrices(
+	locations common.Locations,
+) ([][]float64, [][]float64, error) {
+	duration := make([][]float64, len(locations))
+	distance := make([][]float64, len(locations))
+	for idx := range locations {
+		duration[idx] = make([]float64, len(locations))
+		distance[idx] = make([]float64, len(locations))
+	}
+
+	valid := make([]int, 0, len(locations))
+	for idx, location := range locations {
+		if location.IsValid() {
+			valid = append(valid, idx)
+		}
+	}
+
+	// A request between two different blocks sends the locations of both, a
+	// block holds at most half of the chunk size unless all locations fit in
+	// a single request.
+	blockSize := o.options.ChunkSize
+	if len(valid) > blockSize {
+		blockSize /= 2
+	}
+	var blocks [][]int
+	for start := 0; start < len(valid); start += blockSize {
+		blocks = append(blocks, valid[start:min(start+blockSize, len(valid))])
+	}
+
+	for _, sources := range blocks {
+		for _, destinations := range blocks {
+			if err := o.table(
+				locations,
+				sources,
+				destinations,
+				duration,
+				distance,
+			); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	return duration, distance, nil
+}
+
+// table requests the block of the matrices from the sources to the
+// destinations and fills it in. Sources and destinations are indices of the
+// locations.
+func (o *osrmImpl) table(
+	locations common.Locations,
+	sources []int,
+	destinations []int,
+	duration [][]float64,
+	distance [][]float64,
+) error {
+	// Sources and destinations may be the same block, a location is only
+	// sent once.
+	coordinates := make([]string, 0, len(sources)+len(destinations))
+	positions := make(map[int]int, len(sources)+len(destinations))
+	position := func(location int) string {
+		p, ok := positions[location]
+		if !ok {
+			p = len(coordinates)
+			positions[location] = p
+			coordinates = append(coordinates, fmt.Sprintf(
+				"%s,%s",
+				strconv.FormatFloat(locations[location].Longitude(), 'f', -1, 64),
+				strconv.FormatFloat(locations[location].Latitude(), 'f', -1, 64),
+			))
+		}
+		return strconv.Itoa(p)
+	}
+	sourcePositions := make([]string, len(sources))
+	for idx, source := range sources {
+		sourcePositions[idx] = position(source)
+	}
+	destinationPositions := make([]string, len(destinations))
+	for idx, destination := range destinations {
+		destinationPositions[idx] = position(destination)
+	}
+
+	query := url.Values{}
+	query.Set("sources", strings.Join(sourcePositions, ";"))
+	query.Set("destinations", strings.Join(destinationPositions, ";"))
+	query.Set("annotations", "duration,distance")
+	endpoint := fmt.Sprintf(
+		"%s/table/v1/%s/%s?%s",
+		o.base.String(),
+		url.PathEscape(o.options.Profile),
+		strings.Join(coordinates, ";"),
+		query.Encode(),
+	)
+
+	request, err := http.NewRequestWithContext(
+		context.Background(),
+		http.MethodGet,
+		endpoint,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+	response, err := o.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("requesting OSRM table: %w", err)
+	}
+	defer response.Body.Close()
+
+	var table osrmTable
+	if err := json.NewDecoder(response.Body).Decode(&table); err != nil {
+		return fmt.Errorf(
+			"decoding OSRM table response with status %s: %w",
+			response.Status,
+			err,
+		)
+	}
+	if response.StatusCode != http.StatusOK || table.Code != "Ok" {
+		return fmt.Errorf(
+			"OSRM table request failed with status %s, code %s: %s",
+			response.Status,
+			table.Code,
+			table.Message,
+		)
+	}
+	if len(table.Durations) != len(sources) || len(table.Distances) != len(sources) {
+		return fmt.Errorf(
+			"OSRM table response has %v duration and %v distance rows, expected %v",
+			len(table.Durations),
+			len(table.Distances),
+			len(sources),
+		)
+	}
+
+	for i, source := range sources {
+		if len(table.Durations[i]) != len(destinations) ||
+			len(table.Distances[i]) != len(destinations) {
+			return fmt.Errorf(
+				"OSRM table response row %v has %v durations and %v distances, expected %v",
+				i,
+				len(table.Durations[i]),
+				len(table.Distances[i]),
+				len(destinations),
+			)
+		}
+		for j, destination := range destinations {
+			if table.Durations[i][j] == nil || table.Distances[i][j] == nil {
+				return fmt.Errorf(
+					"location %v at index %v can not be reached from location %v at index %v",
+					locations[destination],
+					destination,
+					locations[source],
+					source,
+				)
+			}
+			duration[source][destination] = *table.Durations[i][j]
+			distance[source][destination] = *table.Distances[i][j]
+		}
+	}
+
+	return nil
+}
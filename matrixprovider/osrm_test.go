@@ -0,0 +1,285 @@
+// © 2019-present nextmv.io inc
+
+package matrixprovider_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/matrixprovider"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// newServer returns a stand-in for an OSRM server which accepts requests of
+// at most maxTableSize coordinates. The distance is the haversine distance,
+// the duration is the distance travelled at 10 meters per second.
+func newServer(t *testing.T, requests *atomic.Int64, maxTableSize int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			const prefix = "/table/v1/driving/"
+			if !strings.HasPrefix(r.URL.Path, prefix) {
+				http.Error(w, `{"code":"InvalidUrl"}`, http.StatusBadRequest)
+				return
+			}
+			var locations common.Locations
+			for _, coordinate := range strings.Split(strings.TrimPrefix(r.URL.Path, prefix), ";") {
+				parts := strings.Split(coordinate, ",")
+				lon, _ := strconv.ParseFloat(parts[0], 64)
+				lat, _ := strconv.ParseFloat(parts[1], 64)
+				location, err := common.NewLocation(lon, lat)
+				if err != nil {
+					t.Error(err)
+				}
+				locations = append(locations, location)
+			}
+			if len(locations) > maxTableSize {
+				t.Errorf("expected at most %v coordinates, got %v", maxTableSize, len(locations))
+				http.Error(w, `{"code":"TooBig"}`, http.StatusBadRequest)
+				return
+			}
+			indices := func(name string) []int {
+				var result []int
+				for _, value := range strings.Split(r.URL.Query().Get(name), ";") {
+					index, err := strconv.Atoi(value)
+					if err != nil {
+						t.Error(err)
+					}
+					result = append(result, index)
+				}
+				return result
+			}
+			table := struct {
+				Code      string      `json:"code"`
+				Durations [][]float64 `json:"durations"`
+				Distances [][]float64 `json:"distances"`
+			}{Code: "Ok"}
+			for _, source := range indices("sources") {
+				var durations, distances []float64
+				for _, destination := range indices("destinations") {
+					distance, _ := common.Haversine(locations[source], locations[destination])
+					distances = append(distances, distance.Value(common.Meters))
+					durations = append(durations, distance.Value(common.Meters)/10)
+				}
+				table.Durations = append(table.Durations, durations)
+				table.Distances = append(table.Distances, distances)
+			}
+			if err := json.NewEncoder(w).Encode(table); err != nil {
+				t.Error(err)
+			}
+		},
+	))
+}
+
+func locations(t *testing.T) common.Locations {
+	t.Helper()
+	locations := common.Locations{common.NewInvalidLocation()}
+	for idx := 0; idx < 5; idx++ {
+		location, err := common.NewLocation(float64(idx)*0.01, 52+float64(idx)*0.01)
+		if err != nil {
+			t.Fatal(err)
+		}
+		locations = append(locations, location)
+	}
+	return locations
+}
+
+func TestOSRMChunks(t *testing.T) {
+	var requests atomic.Int64
+	server := newServer(t, &requests, 100)
+	defer server.Close()
+
+	whole, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunked, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL:       server.URL,
+		ChunkSize: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locations := locations(t)
+	expectedDuration, expectedDistance, err := whole.Matrices(locations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %v", requests.Load())
+	}
+
+	duration, distance, err := chunked.Matrices(locations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 5 valid locations do not fit in a request of 2 coordinates, in blocks
+	// of 1 they result in 25 requests.
+	if requests.Load() != 26 {
+		t.Errorf("expected 25 chunked requests, got %v", requests.Load()-1)
+	}
+
+	for i := range locations {
+		for j := range locations {
+			if duration[i][j] != expectedDuration[i][j] ||
+				distance[i][j] != expectedDistance[i][j] {
+				t.Errorf("chunked matrices differ at %v, %v", i, j)
+			}
+			if (i == 0 || j == 0) && duration[i][j] != 0 {
+				t.Errorf("expected zero duration for invalid location at %v, %v", i, j)
+			}
+		}
+	}
+	if expectedDuration[1][2] <= 0 {
+		t.Error("expected a positive duration between different locations")
+	}
+}
+
+func TestOSRMTableSize(t *testing.T) {
+	var requests atomic.Int64
+	server := newServer(t, &requests, 4)
+	defer server.Close()
+
+	if _, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL:       server.URL,
+		ChunkSize: 1,
+	}); err == nil {
+		t.Error("expected an error for a chunk size of 1")
+	}
+	provider, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL:       server.URL,
+		ChunkSize: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 4 valid locations fit in a single request.
+	if _, _, err := provider.Matrices(locations(t)[:5]); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %v", requests.Load())
+	}
+
+	// 5 valid locations in blocks of 2 result in 3 blocks, 9 requests.
+	if _, _, err := provider.Matrices(locations(t)); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 10 {
+		t.Errorf("expected 9 requests, got %v", requests.Load()-1)
+	}
+}
+
+func TestOSRMError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":"InvalidQuery","message":"bad"}`))
+		},
+	))
+	defer server.Close()
+
+	provider, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = provider.Matrices(locations(t))
+	if err == nil || !strings.Contains(err.Error(), "InvalidQuery") {
+		t.Errorf("expected an InvalidQuery error, got %v", err)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	var requests atomic.Int64
+	server := newServer(t, &requests, 100)
+	defer server.Close()
+
+	osrm, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	directory := t.TempDir()
+	provider := matrixprovider.NewDiskCache(osrm, directory, "test")
+
+	locations := locations(t)
+	duration, _, err := provider.Matrices(locations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, _, err := matrixprovider.NewDiskCache(osrm, directory, "test").
+		Matrices(locations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %v", requests.Load())
+	}
+	if cached[1][2] != duration[1][2] {
+		t.Errorf("expected cached duration %v, got %v", duration[1][2], cached[1][2])
+	}
+
+	if _, _, err := provider.Matrices(locations[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 2 {
+		t.Errorf("expected a request for other locations, got %v", requests.Load())
+	}
+}
+
+func TestNewModelMatrixProvider(t *testing.T) {
+	var requests atomic.Int64
+	server := newServer(t, &requests, 100)
+	defer server.Close()
+
+	provider, err := matrixprovider.NewOSRM(matrixprovider.OSRMOptions{
+		URL: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := schema.Input{
+		Stops: []schema.Stop{
+			{ID: "s1", Location: schema.Location{Lon: 0.01, Lat: 52.01}},
+			{ID: "s2", Location: schema.Location{Lon: 0.02, Lat: 52.02}},
+		},
+		Vehicles: []schema.Vehicle{{
+			ID:            "v1",
+			StartLocation: &schema.Location{Lon: 0, Lat: 52},
+		}},
+	}
+
+	// Without a speed the model requires a duration matrix.
+	if _, err := factory.NewModel(input, factory.Options{}); err == nil {
+		t.Fatal("expected an error without duration matrix")
+	}
+
+	model, err := factory.NewModel(input, factory.Options{
+		MatrixProvider: provider,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected 1 request, got %v", requests.Load())
+	}
+	if model.NumberOfStops() != 4 {
+		t.Errorf("expected 4 stops, got %v", model.NumberOfStops())
+	}
+}
@@ -286,10 +286,14 @@ func (l *clusterImpl) getSolutionStops(vehicle SolutionVehicle) []SolutionStop {
 func (l *clusterImpl) Value(solutionStop Solution) float64 {
 	sum := 0.0
 	for _, vehicle := range solutionStop.(*solutionImpl).vehiclesMutable() {
-		if vehicle.IsEmpty() {
-			continue
-		}
-		sum += vehicle.Last().ObjectiveData(l).(*centroidData).compactness
+		sum += l.VehicleValue(vehicle)
 	}
 	return sum
 }
+
+func (l *clusterImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	if vehicle.IsEmpty() {
+		return 0
+	}
+	return vehicle.Last().ObjectiveData(l).(*centroidData).compactness
+}
@@ -17,6 +17,27 @@ type ConstraintReporter interface {
 	ReportConstraint(SolutionStop) map[string]any
 }
 
+// ConstraintExplainer is the interface that can be used by a constraint if it
+// wants to explain why a move violates it. The explanation is used when
+// checking a plan, see package check.
+type ConstraintExplainer interface {
+	// ExplainViolation returns why the move violates the constraint. It is
+	// called for moves that are estimated to violate the constraint. Returns
+	// an empty string if the constraint cannot tell.
+	ExplainViolation(move SolutionMoveStops) string
+}
+
+// ConstraintRelaxer is the interface that can be used by a constraint if it
+// can tell by how much its limit has to be relaxed to allow a move. The
+// relaxation is used to suggest what to change to plan a plan unit, see
+// package check.
+type ConstraintRelaxer interface {
+	// Relaxation returns by how much the limit of the constraint has to be
+	// relaxed for the move not to violate it, in seconds for limits in time.
+	// Returns false if relaxing the limit does not allow the move.
+	Relaxation(move SolutionMoveStops) (float64, bool)
+}
+
 // Locker is an interface for locking a constraint. This interface is called
 // when the model is locked. The constraint can use this to initialize data
 // structures that are used to check the constraint.
@@ -171,6 +171,28 @@ func (l *attributesConstraintImpl) EstimateIsViolated(
 	return true, constSkipVehiclePositionsHint
 }
 
+func (l *attributesConstraintImpl) ExplainViolation(move SolutionMoveStops) string {
+	vehicleType := move.Vehicle().ModelVehicle().VehicleType()
+	vehicleTypeAttributes := l.VehicleTypeAttributes(vehicleType)
+	for _, stop := range move.PlanStopsUnit().ModelPlanStopsUnit().Stops() {
+		stopAttributes := l.StopAttributes(stop)
+		if len(stopAttributes) == 0 ||
+			slices.ContainsFunc(stopAttributes, func(attribute string) bool {
+				return slices.Contains(vehicleTypeAttributes, attribute)
+			}) {
+			continue
+		}
+		return fmt.Sprintf(
+			"stop %s has attributes %v, vehicle %s has none of them %v",
+			stop.ID(),
+			stopAttributes,
+			move.Vehicle().ModelVehicle().ID(),
+			vehicleTypeAttributes,
+		)
+	}
+	return ""
+}
+
 func (l *attributesConstraintImpl) mapTwoIndices(i, j int) int {
 	return i*l.vehicleTypes + j
 }
@@ -2,6 +2,8 @@
 
 package nextroute
 
+import "fmt"
+
 // MaximumStopsConstraint is a constraint that limits the maximum number of
 // stops a vehicle type can have. The maximum number of stops is defined by
 // the maximum stops expression. The first stop of a vehicle is not counted
@@ -71,6 +73,16 @@ func (l *maximumStopsConstraintImpl) EstimateIsViolated(
 	return false, constNoPositionsHint
 }
 
+func (l *maximumStopsConstraintImpl) ExplainViolation(move SolutionMoveStops) string {
+	vehicle := move.Vehicle()
+	return fmt.Sprintf(
+		"vehicle %s would have %d stops, above the maximum of %v",
+		vehicle.ModelVehicle().ID(),
+		vehicle.NumberOfStops()+move.StopPositionsLength(),
+		l.maximumStopsByVehicleType[vehicle.ModelVehicle().VehicleType().Index()],
+	)
+}
+
 func (l *maximumStopsConstraintImpl) EstimationCost() Cost {
 	return Constant
 }
@@ -3,6 +3,7 @@
 package nextroute
 
 import (
+	"fmt"
 	"math"
 	"time"
 )
@@ -142,6 +143,10 @@ func (l *latestImpl) Factor(stop ModelStop) float64 {
 	return l.latenessFactor.Value(nil, nil, stop)
 }
 
+func (l *latestImpl) TemporalReference() TemporalReference {
+	return l.temporalReference
+}
+
 func (l *latestImpl) ReportConstraint(stop SolutionStop) map[string]any {
 	var t time.Time
 	switch l.temporalReference {
@@ -190,24 +195,30 @@ func (l *latestImpl) Value(s Solution) float64 {
 	solution := s.(*solutionImpl)
 	value := 0.0
 	for _, vehicle := range solution.vehicles {
-		solutionStop := vehicle.First().Next()
-		lastSolutionStop := vehicle.Last()
-		for {
-			latenessFactor := l.latenessFactor.Value(
-				nil,
-				nil,
-				solutionStop.ModelStop(),
-			)
-			value += l.Lateness(solutionStop) * latenessFactor
+		value += l.VehicleValue(vehicle)
+	}
 
-			if solutionStop == lastSolutionStop {
-				break
-			}
+	return value
+}
 
-			solutionStop = solutionStop.Next()
+func (l *latestImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	value := 0.0
+	solutionStop := vehicle.First().Next()
+	lastSolutionStop := vehicle.Last()
+	for {
+		latenessFactor := l.latenessFactor.Value(
+			nil,
+			nil,
+			solutionStop.ModelStop(),
+		)
+		value += l.Lateness(solutionStop) * latenessFactor
+
+		if solutionStop == lastSolutionStop {
+			break
 		}
-	}
 
+		solutionStop = solutionStop.Next()
+	}
 	return value
 }
 
@@ -221,6 +232,87 @@ func (l *latestImpl) EstimateIsViolated(
 	return score != 0.0, hint.(*stopPositionHintImpl)
 }
 
+func (l *latestImpl) ExplainViolation(move SolutionMoveStops) string {
+	moveImpl := move.(*solutionMoveStopsImpl)
+	vehicle := moveImpl.vehicle()
+	vehicleType := vehicle.ModelVehicle().VehicleType()
+	model := vehicle.ModelVehicle().Model()
+
+	generator := newSolutionStopGenerator(*moveImpl, false, true)
+	defer generator.release()
+
+	previousStop, _ := generator.next()
+	previousModelStop := previousStop.ModelStop()
+	end := previousStop.EndValue()
+	for solutionStop, ok := generator.next(); ok; solutionStop, ok = generator.next() {
+		modelStop := solutionStop.ModelStop()
+		_, arrival, start, stopEnd := vehicleType.TemporalValues(
+			end,
+			previousModelStop,
+			modelStop,
+		)
+		end = stopEnd
+		previousModelStop = modelStop
+
+		reference, name := start, "start"
+		switch l.temporalReference {
+		case OnArrival:
+			reference, name = arrival, "arrival"
+		case OnEnd:
+			reference, name = stopEnd, "end"
+		}
+
+		latest := l.latest.Value(nil, nil, modelStop)
+		if reference > latest {
+			return fmt.Sprintf(
+				"%s at stop %s would be %v, after the latest %s %v",
+				name,
+				modelStop.ID(),
+				model.ValueToTime(reference).Format(time.RFC3339),
+				name,
+				model.ValueToTime(latest).Format(time.RFC3339),
+			)
+		}
+	}
+	return ""
+}
+
+func (l *latestImpl) Relaxation(move SolutionMoveStops) (float64, bool) {
+	moveImpl := move.(*solutionMoveStopsImpl)
+	vehicle := moveImpl.vehicle()
+	vehicleType := vehicle.ModelVehicle().VehicleType()
+
+	generator := newSolutionStopGenerator(*moveImpl, false, true)
+	defer generator.release()
+
+	// Moving the latest time of a stop does not change the arrival, start
+	// or end at any stop, the relaxation is the largest lateness.
+	relaxation := 0.0
+	previousStop, _ := generator.next()
+	previousModelStop := previousStop.ModelStop()
+	end := previousStop.EndValue()
+	for solutionStop, ok := generator.next(); ok; solutionStop, ok = generator.next() {
+		modelStop := solutionStop.ModelStop()
+		_, arrival, start, stopEnd := vehicleType.TemporalValues(
+			end,
+			previousModelStop,
+			modelStop,
+		)
+		end = stopEnd
+		previousModelStop = modelStop
+
+		reference := start
+		switch l.temporalReference {
+		case OnArrival:
+			reference = arrival
+		case OnEnd:
+			reference = stopEnd
+		}
+		relaxation = math.Max(relaxation, reference-l.latest.Value(nil, nil, modelStop))
+	}
+	return relaxation * vehicle.ModelVehicle().Model().DurationUnit().Seconds(), true
+}
+
 func (l *latestImpl) EstimateDeltaValue(
 	move SolutionMoveStops,
 ) float64 {
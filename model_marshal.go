@@ -0,0 +1,695 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/nextmv-io/nextroute/common"
+)
+
+// ModelMarshaler is implemented by the constructs of a model that can be
+// written with [WriteModel]: expressions, constraints, objectives and the
+// data of the model, its stops, vehicle types and vehicles. The built-in
+// expressions, constraints and objectives implement it. A custom construct
+// opts in by implementing it and registering a [ModelUnmarshalFunc] for its
+// kind with [RegisterModelUnmarshaler].
+type ModelMarshaler interface {
+	// MarshalModel returns the kind of the construct and its data. The kind
+	// selects the function recreating the construct when the model is read.
+	// Expressions used by the construct are encoded as references using the
+	// encoder. Stops, vehicle types and vehicles are encoded by their index.
+	MarshalModel(encoder ModelEncoder) (kind string, data []byte, err error)
+}
+
+// ModelEncoder encodes references to the expressions of a model while the
+// model is written.
+type ModelEncoder interface {
+	// Model returns the model being written.
+	Model() Model
+	// Expression returns the reference of the expression. The expression is
+	// written once, regardless of how often it is referenced. It must be a
+	// built-in expression or implement [ModelMarshaler].
+	Expression(expression ModelExpression) (int, error)
+}
+
+// ModelDecoder decodes references to the expressions of a model while the
+// model is read.
+type ModelDecoder interface {
+	// Model returns the model being read. Its stops, vehicle types, vehicles
+	// and plan units exist when constructs are recreated, they can be looked
+	// up by index.
+	Model() Model
+	// Expression returns the expression of the reference returned by
+	// [ModelEncoder.Expression] when the model was written.
+	Expression(reference int) (ModelExpression, error)
+}
+
+// ModelUnmarshalFunc recreates a construct from the data returned by
+// [ModelMarshaler.MarshalModel] or by the marshal function of
+// [RegisterModelData].
+type ModelUnmarshalFunc func(decoder ModelDecoder, data []byte) (any, error)
+
+// modelFormatVersion is the version of the format written by WriteModel.
+const modelFormatVersion = 1
+
+var (
+	modelUnmarshalersMutex sync.RWMutex
+	modelUnmarshalers      = map[string]ModelUnmarshalFunc{}
+	modelDataMarshalers    = map[reflect.Type]modelDataMarshaler{}
+)
+
+type modelDataMarshaler struct {
+	marshal func(data any) ([]byte, error)
+	kind    string
+}
+
+// RegisterModelUnmarshaler registers the function recreating constructs of
+// the given kind when a model is read. Register custom constructs before
+// calling [ReadModel], for example in an init function of the package
+// defining them. Registering a kind twice replaces the function.
+func RegisterModelUnmarshaler(kind string, unmarshal ModelUnmarshalFunc) {
+	modelUnmarshalersMutex.Lock()
+	defer modelUnmarshalersMutex.Unlock()
+	modelUnmarshalers[kind] = unmarshal
+}
+
+// RegisterModelData registers data of the type of the given value, set on a
+// model, stop, vehicle type or vehicle, that does not implement
+// [ModelMarshaler]. The data is written using marshal and recreated using
+// unmarshal under the given kind. Use it for types that cannot implement
+// [ModelMarshaler], for example types of another package.
+func RegisterModelData(
+	kind string,
+	value any,
+	marshal func(data any) ([]byte, error),
+	unmarshal ModelUnmarshalFunc,
+) {
+	modelUnmarshalersMutex.Lock()
+	defer modelUnmarshalersMutex.Unlock()
+	modelDataMarshalers[reflect.TypeOf(value)] = modelDataMarshaler{
+		kind:    kind,
+		marshal: marshal,
+	}
+	modelUnmarshalers[kind] = unmarshal
+}
+
+// WriteModel writes the model in a binary format to the writer. The model
+// can be recreated with [ReadModel], also in another process, to solve it
+// again without building it. Stops, vehicle types, vehicles, plan units,
+// constraints, objectives, expressions and the data of the model are
+// written. All expressions, constraints, objectives and data must be
+// built-in or opt in by implementing [ModelMarshaler] or by being registered
+// with [RegisterModelData], an error is returned otherwise. Expressions
+// computing values with a function, such as binary expressions, and
+// measures by point cannot be written.
+func WriteModel(writer io.Writer, model Model) error {
+	if model == nil {
+		return fmt.Errorf("model is nil")
+	}
+	m, ok := model.(*modelImpl)
+	if !ok {
+		return fmt.Errorf("model of type %T can not be written", model)
+	}
+
+	encoder := &modelEncoderImpl{
+		model:      m,
+		references: make(map[any]int),
+	}
+	record, err := encoder.write(m)
+	if err != nil {
+		return err
+	}
+	return gob.NewEncoder(writer).Encode(record)
+}
+
+// ReadModel reads a model written by [WriteModel] from the reader. The
+// model is recreated unlocked, it is locked once a solution is created.
+// Custom constructs must have been registered with
+// [RegisterModelUnmarshaler] or [RegisterModelData].
+func ReadModel(reader io.Reader) (Model, error) {
+	var record modelRecord
+	if err := gob.NewDecoder(reader).Decode(&record); err != nil {
+		return nil, fmt.Errorf("reading model: %w", err)
+	}
+	if record.Version != modelFormatVersion {
+		return nil, fmt.Errorf(
+			"model format version %d is not supported, expected %d",
+			record.Version,
+			modelFormatVersion,
+		)
+	}
+
+	model, err := NewModel()
+	if err != nil {
+		return nil, err
+	}
+	decoder := &modelDecoderImpl{
+		model:      model.(*modelImpl),
+		record:     &record,
+		constructs: make([]any, len(record.Constructs)),
+		decoding:   make([]bool, len(record.Constructs)),
+	}
+	if err := decoder.read(); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+type modelRecord struct {
+	Data               *constructRecord
+	TimeFormat         string
+	Stops              []stopRecord
+	VehicleTypes       []vehicleTypeRecord
+	Vehicles           []vehicleRecord
+	PlanUnits          []planUnitRecord
+	Constructs         []constructRecord
+	Constraints        []int
+	Terms              []termRecord
+	Version            int
+	SequenceSampleSize int
+}
+
+type constructRecord struct {
+	Kind string
+	Data []byte
+}
+
+type stopRecord struct {
+	Data          *constructRecord
+	ID            string
+	Windows       [][2]float64
+	Longitude     float64
+	Latitude      float64
+	EarliestStart float64
+	MeasureIndex  int
+	ValidLocation bool
+}
+
+type vehicleTypeRecord struct {
+	Data           *constructRecord
+	ID             string
+	TravelDuration int
+	Duration       int
+}
+
+type vehicleRecord struct {
+	Start       time.Time
+	Data        *constructRecord
+	ID          string
+	Stops       []int
+	Fixed       []bool
+	VehicleType int
+	First       int
+	Last        int
+}
+
+type planUnitRecord struct {
+	Stops       []int
+	Arcs        []arcRecord
+	PlanUnits   []int
+	OneOf       bool
+	SameVehicle bool
+}
+
+type arcRecord struct {
+	Origin      int
+	Destination int
+	Direct      bool
+}
+
+type termRecord struct {
+	Factor    float64
+	Objective int
+}
+
+type modelEncoderImpl struct {
+	model      *modelImpl
+	references map[any]int
+	constructs []constructRecord
+}
+
+func (e *modelEncoderImpl) Model() Model {
+	return e.model
+}
+
+func (e *modelEncoderImpl) Expression(expression ModelExpression) (int, error) {
+	if expression == nil {
+		return -1, nil
+	}
+	return e.reference(expression)
+}
+
+// reference returns the reference of the construct, writing it on first use.
+// Constructs of comparable types are written once.
+func (e *modelEncoderImpl) reference(construct any) (int, error) {
+	comparable := reflect.TypeOf(construct).Comparable()
+	if comparable {
+		if reference, ok := e.references[construct]; ok {
+			return reference, nil
+		}
+	}
+	marshaler, ok := construct.(ModelMarshaler)
+	if !ok {
+		return -1, fmt.Errorf(
+			"%T can not be written, it does not implement ModelMarshaler",
+			construct,
+		)
+	}
+	reference := len(e.constructs)
+	e.constructs = append(e.constructs, constructRecord{})
+	if comparable {
+		e.references[construct] = reference
+	}
+	kind, data, err := marshaler.MarshalModel(e)
+	if err != nil {
+		return -1, err
+	}
+	e.constructs[reference] = constructRecord{Kind: kind, Data: data}
+	return reference, nil
+}
+
+func (e *modelEncoderImpl) data(data any) (*constructRecord, error) {
+	if data == nil {
+		return nil, nil
+	}
+	if marshaler, ok := data.(ModelMarshaler); ok {
+		kind, bytes, err := marshaler.MarshalModel(e)
+		if err != nil {
+			return nil, err
+		}
+		return &constructRecord{Kind: kind, Data: bytes}, nil
+	}
+	modelUnmarshalersMutex.RLock()
+	marshaler, ok := modelDataMarshalers[reflect.TypeOf(data)]
+	modelUnmarshalersMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(
+			"data of type %T can not be written, implement ModelMarshaler"+
+				" or register the type with RegisterModelData",
+			data,
+		)
+	}
+	bytes, err := marshaler.marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return &constructRecord{Kind: marshaler.kind, Data: bytes}, nil
+}
+
+func (e *modelEncoderImpl) write(m *modelImpl) (modelRecord, error) {
+	record := modelRecord{
+		Version:            modelFormatVersion,
+		TimeFormat:         m.timeFormat,
+		SequenceSampleSize: m.sequenceSampleSize,
+		Stops:              make([]stopRecord, len(m.stops)),
+		VehicleTypes:       make([]vehicleTypeRecord, len(m.vehicleTypes)),
+		Vehicles:           make([]vehicleRecord, len(m.vehicles)),
+		PlanUnits:          make([]planUnitRecord, len(m.planUnits)),
+		Constraints:        make([]int, len(m.constraints)),
+		Terms:              make([]termRecord, len(m.objective.Terms())),
+	}
+
+	var err error
+	if record.Data, err = e.data(m.Data()); err != nil {
+		return record, fmt.Errorf("writing model data: %w", err)
+	}
+
+	for idx, modelStop := range m.stops {
+		stop := modelStop.(*stopImpl)
+		record.Stops[idx] = stopRecord{
+			ID:            stop.id,
+			Longitude:     stop.location.Longitude(),
+			Latitude:      stop.location.Latitude(),
+			ValidLocation: stop.location.IsValid(),
+			MeasureIndex:  stop.measureIndex,
+			Windows:       stop.windows,
+			EarliestStart: stop.earliestStartTime,
+		}
+		if record.Stops[idx].Data, err = e.data(stop.Data()); err != nil {
+			return record, fmt.Errorf("writing data of stop %s: %w", stop.ID(), err)
+		}
+	}
+
+	for idx, vehicleType := range m.vehicleTypes {
+		vehicleTypeRecord := vehicleTypeRecord{ID: vehicleType.ID()}
+		if vehicleTypeRecord.TravelDuration, err = e.Expression(vehicleType.TravelDurationExpression()); err != nil {
+			return record, fmt.Errorf("writing vehicle type %s: %w", vehicleType.ID(), err)
+		}
+		if vehicleTypeRecord.Duration, err = e.Expression(vehicleType.DurationExpression()); err != nil {
+			return record, fmt.Errorf("writing vehicle type %s: %w", vehicleType.ID(), err)
+		}
+		if vehicleTypeRecord.Data, err = e.data(vehicleType.Data()); err != nil {
+			return record, fmt.Errorf("writing data of vehicle type %s: %w", vehicleType.ID(), err)
+		}
+		record.VehicleTypes[idx] = vehicleTypeRecord
+	}
+
+	for idx, vehicle := range m.vehicles {
+		vehicleRecord := vehicleRecord{
+			ID:          vehicle.ID(),
+			VehicleType: vehicle.VehicleType().Index(),
+			Start:       vehicle.Start(),
+			First:       vehicle.First().Index(),
+			Last:        vehicle.Last().Index(),
+		}
+		for _, stop := range vehicle.Stops() {
+			vehicleRecord.Stops = append(vehicleRecord.Stops, stop.Index())
+			vehicleRecord.Fixed = append(vehicleRecord.Fixed, stop.IsFixed())
+		}
+		if vehicleRecord.Data, err = e.data(vehicle.Data()); err != nil {
+			return record, fmt.Errorf("writing data of vehicle %s: %w", vehicle.ID(), err)
+		}
+		record.Vehicles[idx] = vehicleRecord
+	}
+
+	for idx, planUnit := range m.planUnits {
+		switch unit := planUnit.(type) {
+		case ModelPlanStopsUnit:
+			planUnitRecord := planUnitRecord{
+				Stops: common.Map(unit.Stops(), func(stop ModelStop) int {
+					return stop.Index()
+				}),
+			}
+			for _, arc := range unit.DirectedAcyclicGraph().Arcs() {
+				planUnitRecord.Arcs = append(planUnitRecord.Arcs, arcRecord{
+					Origin:      arc.Origin().Index(),
+					Destination: arc.Destination().Index(),
+					Direct:      arc.IsDirect(),
+				})
+			}
+			record.PlanUnits[idx] = planUnitRecord
+		case ModelPlanUnitsUnit:
+			record.PlanUnits[idx] = planUnitRecord{
+				PlanUnits: common.Map(unit.PlanUnits(), func(planUnit ModelPlanUnit) int {
+					return planUnit.Index()
+				}),
+				OneOf:       unit.PlanOneOf(),
+				SameVehicle: unit.SameVehicle(),
+			}
+		default:
+			return record, fmt.Errorf("plan unit of type %T can not be written", planUnit)
+		}
+	}
+
+	for idx, constraint := range m.constraints {
+		if record.Constraints[idx], err = e.reference(constraint); err != nil {
+			return record, fmt.Errorf("writing constraint %v: %w", constraint, err)
+		}
+	}
+
+	for idx, term := range m.objective.Terms() {
+		record.Terms[idx].Factor = term.Factor()
+		if record.Terms[idx].Objective, err = e.reference(term.Objective()); err != nil {
+			return record, fmt.Errorf("writing objective %v: %w", term.Objective(), err)
+		}
+	}
+
+	record.Constructs = e.constructs
+	return record, nil
+}
+
+type modelDecoderImpl struct {
+	model      *modelImpl
+	record     *modelRecord
+	constructs []any
+	decoding   []bool
+}
+
+func (d *modelDecoderImpl) Model() Model {
+	return d.model
+}
+
+func (d *modelDecoderImpl) Expression(reference int) (ModelExpression, error) {
+	if reference < 0 {
+		return nil, nil
+	}
+	construct, err := d.construct(reference)
+	if err != nil {
+		return nil, err
+	}
+	expression, ok := construct.(ModelExpression)
+	if !ok {
+		return nil, fmt.Errorf("construct %d of type %T is not an expression", reference, construct)
+	}
+	return expression, nil
+}
+
+// construct returns the construct of the reference, recreating it on first
+// use.
+func (d *modelDecoderImpl) construct(reference int) (any, error) {
+	if reference < 0 || reference >= len(d.constructs) {
+		return nil, fmt.Errorf("construct reference %d is out of range", reference)
+	}
+	if construct := d.constructs[reference]; construct != nil {
+		return construct, nil
+	}
+	if d.decoding[reference] {
+		return nil, fmt.Errorf("construct %d refers to itself", reference)
+	}
+	d.decoding[reference] = true
+	construct, err := d.unmarshal(d.record.Constructs[reference])
+	if err != nil {
+		return nil, err
+	}
+	d.constructs[reference] = construct
+	return construct, nil
+}
+
+func (d *modelDecoderImpl) unmarshal(record constructRecord) (any, error) {
+	modelUnmarshalersMutex.RLock()
+	unmarshal, ok := modelUnmarshalers[record.Kind]
+	if !ok {
+		unmarshal, ok = expressionUnmarshalers[record.Kind]
+	}
+	if !ok {
+		unmarshal, ok = constructUnmarshalers[record.Kind]
+	}
+	modelUnmarshalersMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf(
+			"kind %s is unknown, register it with RegisterModelUnmarshaler",
+			record.Kind,
+		)
+	}
+	construct, err := unmarshal(d, record.Data)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", record.Kind, err)
+	}
+	return construct, nil
+}
+
+func (d *modelDecoderImpl) data(data ModelData, record *constructRecord) error {
+	if record == nil {
+		return nil
+	}
+	value, err := d.unmarshal(*record)
+	if err != nil {
+		return err
+	}
+	data.SetData(value)
+	return nil
+}
+
+// durationExpression returns the expression of the reference as a duration
+// expression.
+func (d *modelDecoderImpl) durationExpression(reference int) (DurationExpression, error) {
+	expression, err := d.Expression(reference)
+	if err != nil {
+		return nil, err
+	}
+	durationExpression, ok := expression.(DurationExpression)
+	if !ok {
+		return nil, fmt.Errorf("expression %v is not a duration expression", expression)
+	}
+	return durationExpression, nil
+}
+
+func (d *modelDecoderImpl) read() error {
+	m, record := d.model, d.record
+	m.timeFormat = record.TimeFormat
+	m.sequenceSampleSize = record.SequenceSampleSize
+
+	for _, stopRecord := range record.Stops {
+		location := common.NewInvalidLocation()
+		if stopRecord.ValidLocation {
+			var err error
+			location, err = common.NewLocation(stopRecord.Longitude, stopRecord.Latitude)
+			if err != nil {
+				return err
+			}
+		}
+		modelStop, err := m.NewStop(location)
+		if err != nil {
+			return err
+		}
+		stop := modelStop.(*stopImpl)
+		stop.SetID(stopRecord.ID)
+		stop.measureIndex = stopRecord.MeasureIndex
+		stop.earliestStartTime = stopRecord.EarliestStart
+		if len(stopRecord.Windows) > 0 {
+			stop.windows = stopRecord.Windows
+			if stop.windowChecker, err = common.NewIntervalCheckerSliceLookup(stop.windows); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, vehicleTypeRecord := range record.VehicleTypes {
+		travelDuration, err := d.Expression(vehicleTypeRecord.TravelDuration)
+		if err != nil {
+			return err
+		}
+		timeDependent, ok := travelDuration.(TimeDependentDurationExpression)
+		if !ok {
+			return fmt.Errorf(
+				"travel duration %v of vehicle type %s is not time dependent",
+				travelDuration,
+				vehicleTypeRecord.ID,
+			)
+		}
+		duration, err := d.durationExpression(vehicleTypeRecord.Duration)
+		if err != nil {
+			return err
+		}
+		vehicleType, err := m.NewVehicleType(timeDependent, duration)
+		if err != nil {
+			return err
+		}
+		vehicleType.SetID(vehicleTypeRecord.ID)
+	}
+
+	for _, vehicleRecord := range record.Vehicles {
+		vehicle, err := m.NewVehicle(
+			m.vehicleTypes[vehicleRecord.VehicleType],
+			vehicleRecord.Start,
+			m.stops[vehicleRecord.First],
+			m.stops[vehicleRecord.Last],
+		)
+		if err != nil {
+			return err
+		}
+		vehicle.SetID(vehicleRecord.ID)
+	}
+
+	for _, planUnitRecord := range record.PlanUnits {
+		if err := d.planUnit(planUnitRecord); err != nil {
+			return err
+		}
+	}
+
+	for idx, vehicleRecord := range record.Vehicles {
+		for i, stop := range vehicleRecord.Stops {
+			if err := m.vehicles[idx].AddStop(m.stops[stop], vehicleRecord.Fixed[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, reference := range record.Constraints {
+		construct, err := d.construct(reference)
+		if err != nil {
+			return err
+		}
+		constraint, ok := construct.(ModelConstraint)
+		if !ok {
+			return fmt.Errorf("construct %d of type %T is not a constraint", reference, construct)
+		}
+		if err := m.AddConstraint(constraint); err != nil {
+			return err
+		}
+	}
+
+	for _, term := range record.Terms {
+		construct, err := d.construct(term.Objective)
+		if err != nil {
+			return err
+		}
+		objective, ok := construct.(ModelObjective)
+		if !ok {
+			return fmt.Errorf("construct %d of type %T is not an objective", term.Objective, construct)
+		}
+		if _, err := m.objective.NewTerm(term.Factor, objective); err != nil {
+			return err
+		}
+	}
+
+	for idx, stopRecord := range record.Stops {
+		if err := d.data(m.stops[idx], stopRecord.Data); err != nil {
+			return fmt.Errorf("reading data of stop %s: %w", stopRecord.ID, err)
+		}
+	}
+	for idx, vehicleTypeRecord := range record.VehicleTypes {
+		if err := d.data(m.vehicleTypes[idx], vehicleTypeRecord.Data); err != nil {
+			return fmt.Errorf("reading data of vehicle type %s: %w", vehicleTypeRecord.ID, err)
+		}
+	}
+	for idx, vehicleRecord := range record.Vehicles {
+		if err := d.data(m.vehicles[idx], vehicleRecord.Data); err != nil {
+			return fmt.Errorf("reading data of vehicle %s: %w", vehicleRecord.ID, err)
+		}
+	}
+	if err := d.data(m, record.Data); err != nil {
+		return fmt.Errorf("reading model data: %w", err)
+	}
+
+	return nil
+}
+
+func (d *modelDecoderImpl) planUnit(record planUnitRecord) error {
+	m := d.model
+	if len(record.PlanUnits) > 0 {
+		planUnits := common.Map(record.PlanUnits, func(idx int) ModelPlanUnit {
+			return m.planUnits[idx]
+		})
+		var err error
+		if record.OneOf {
+			_, err = m.NewPlanOneOfPlanUnits(planUnits...)
+		} else {
+			_, err = m.NewPlanAllPlanUnits(record.SameVehicle, planUnits...)
+		}
+		return err
+	}
+
+	stops := common.Map(record.Stops, func(idx int) ModelStop {
+		return m.stops[idx]
+	})
+	if len(stops) == 1 && len(record.Arcs) == 0 {
+		_, err := m.NewPlanSingleStop(stops[0])
+		return err
+	}
+	dag := NewDirectedAcyclicGraph()
+	for _, arc := range record.Arcs {
+		var err error
+		if arc.Direct {
+			err = dag.AddDirectArc(m.stops[arc.Origin], m.stops[arc.Destination])
+		} else {
+			err = dag.AddArc(m.stops[arc.Origin], m.stops[arc.Destination])
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := m.NewPlanMultipleStops(stops, dag)
+	return err
+}
+
+// marshalRecord encodes the record of a built-in construct.
+func marshalRecord(kind string, record any) (string, []byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(record); err != nil {
+		return "", nil, fmt.Errorf("writing %s: %w", kind, err)
+	}
+	return kind, buffer.Bytes(), nil
+}
+
+// unmarshalRecord decodes the record of a built-in construct.
+func unmarshalRecord(data []byte, record any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(record)
+}
@@ -0,0 +1,453 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"fmt"
+)
+
+// The kinds of the built-in constraints and objectives written by
+// WriteModel.
+const (
+	maximumKind                      = "nextroute.maximum"
+	latestKind                       = "nextroute.latest"
+	attributesConstraintKind         = "nextroute.attributes_constraint"
+	maximumStopsConstraintKind       = "nextroute.maximum_stops_constraint"
+	maximumDurationConstraintKind    = "nextroute.maximum_duration_constraint"
+	maximumTravelDurationKind        = "nextroute.maximum_travel_duration_constraint"
+	maximumWaitStopConstraintKind    = "nextroute.maximum_wait_stop_constraint"
+	maximumWaitVehicleConstraintKind = "nextroute.maximum_wait_vehicle_constraint"
+	noMixConstraintKind              = "nextroute.no_mix_constraint"
+	clusterKind                      = "nextroute.cluster"
+	successorConstraintKind          = "nextroute.successor_constraint"
+	earlinessObjectiveKind           = "nextroute.earliness_objective"
+	expressionObjectiveKind          = "nextroute.expression_objective"
+	minStopsObjectiveKind            = "nextroute.min_stops_objective"
+	travelDurationObjectiveKind      = "nextroute.travel_duration_objective"
+	unplannedObjectiveKind           = "nextroute.unplanned_objective"
+	vehiclesObjectiveKind            = "nextroute.vehicles_objective"
+	vehiclesDurationObjectiveKind    = "nextroute.vehicles_duration_objective"
+)
+
+// constructRecordFields is the record of the built-in constraints and
+// objectives, each uses the fields it needs.
+type constructRecordFields struct {
+	StopAttributes        map[int][]string
+	VehicleTypeAttributes map[int][]string
+	Insert                map[int]MixItem
+	Remove                map[int]MixItem
+	Successors            map[int][]int
+	Name                  string
+	Expressions           []int
+	Value                 float64
+	TemporalReference     int
+	IncludeFirst          bool
+	IncludeLast           bool
+}
+
+// marshalConstruct writes the record of a built-in constraint or objective
+// with the references of its expressions.
+func marshalConstruct(
+	encoder ModelEncoder,
+	kind string,
+	record constructRecordFields,
+	expressions ...ModelExpression,
+) (string, []byte, error) {
+	record.Expressions = make([]int, len(expressions))
+	for idx, expression := range expressions {
+		var err error
+		if record.Expressions[idx], err = encoder.Expression(expression); err != nil {
+			return "", nil, err
+		}
+	}
+	return marshalRecord(kind, record)
+}
+
+func (l *maximumImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		maximumKind,
+		constructRecordFields{Name: l.name, Value: l.penaltyOffset},
+		l.expressions[0],
+		l.maximum,
+	)
+}
+
+func (l *latestImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		latestKind,
+		constructRecordFields{
+			Name:              l.name,
+			TemporalReference: int(l.temporalReference),
+		},
+		l.latest,
+		l.latenessFactor,
+	)
+}
+
+func (l *attributesConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		attributesConstraintKind,
+		constructRecordFields{
+			Name:                  l.name,
+			StopAttributes:        l.stopAttributes,
+			VehicleTypeAttributes: l.vehicleTypeAttributes,
+		},
+	)
+}
+
+func (l *maximumStopsConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		maximumStopsConstraintKind,
+		constructRecordFields{Name: l.name},
+		l.maximumStops,
+	)
+}
+
+func (l *maximumDurationConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		maximumDurationConstraintKind,
+		constructRecordFields{Name: l.name},
+		l.maximum,
+	)
+}
+
+func (l *maximumTravelDurationConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		maximumTravelDurationKind,
+		constructRecordFields{Name: l.name},
+		l.maximum,
+	)
+}
+
+func (l *maximumWaitStopConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		maximumWaitStopConstraintKind,
+		constructRecordFields{Name: l.name},
+		l.maxima,
+	)
+}
+
+func (l *maximumWaitVehicleConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		maximumWaitVehicleConstraintKind,
+		constructRecordFields{Name: l.name},
+		l.maxima,
+	)
+}
+
+func (l *noMixConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	record := constructRecordFields{
+		Name:   l.name,
+		Insert: make(map[int]MixItem, len(l.insert)),
+		Remove: make(map[int]MixItem, len(l.remove)),
+	}
+	for stop, item := range l.insert {
+		record.Insert[stop.Index()] = item
+	}
+	for stop, item := range l.remove {
+		record.Remove[stop.Index()] = item
+	}
+	return marshalConstruct(encoder, noMixConstraintKind, record)
+}
+
+func (l *clusterImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		clusterKind,
+		constructRecordFields{
+			Name:         l.name,
+			IncludeFirst: l.includeFirst,
+			IncludeLast:  l.includeLast,
+		},
+	)
+}
+
+func (l *successorConstraintImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	record := constructRecordFields{
+		Name:       l.name,
+		Successors: make(map[int][]int, len(l.disallowedSuccessors)),
+	}
+	for stop, successors := range l.disallowedSuccessors {
+		for _, successor := range successors {
+			record.Successors[stop.Index()] = append(record.Successors[stop.Index()], successor.Index())
+		}
+	}
+	return marshalConstruct(encoder, successorConstraintKind, record)
+}
+
+func (l *earlinessObjectiveImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		earlinessObjectiveKind,
+		constructRecordFields{TemporalReference: int(l.temporalReference)},
+		l.targetTime,
+		l.earlinessFactor,
+	)
+}
+
+func (e *expressionObjectiveImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		expressionObjectiveKind,
+		constructRecordFields{},
+		e.expression,
+	)
+}
+
+func (t *minStopsObjectiveImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		minStopsObjectiveKind,
+		constructRecordFields{},
+		t.minStops,
+		t.minStopsPenalty,
+	)
+}
+
+func (t *travelDurationObjectiveImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(encoder, travelDurationObjectiveKind, constructRecordFields{})
+}
+
+func (t *unplannedObjectiveImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		unplannedObjectiveKind,
+		constructRecordFields{},
+		t.expression,
+	)
+}
+
+func (t *vehiclesObjectiveImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(
+		encoder,
+		vehiclesObjectiveKind,
+		constructRecordFields{},
+		t.expression,
+	)
+}
+
+func (t *vehiclesDurationObjectiveImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	return marshalConstruct(encoder, vehiclesDurationObjectiveKind, constructRecordFields{})
+}
+
+// constructUnmarshaler returns a ModelUnmarshalFunc recreating a built-in
+// constraint or objective from its record and its expressions.
+func constructUnmarshaler(
+	create func(decoder ModelDecoder, record constructRecordFields, expressions ModelExpressions) (any, error),
+) ModelUnmarshalFunc {
+	return unmarshaler(func(decoder ModelDecoder, record constructRecordFields) (any, error) {
+		expressions := make(ModelExpressions, len(record.Expressions))
+		for idx, reference := range record.Expressions {
+			var err error
+			if expressions[idx], err = decoder.Expression(reference); err != nil {
+				return nil, err
+			}
+		}
+		return create(decoder, record, expressions)
+	})
+}
+
+// as returns the expression as T.
+func as[T any](expression ModelExpression) (T, error) {
+	result, ok := expression.(T)
+	if !ok {
+		return result, fmt.Errorf("expression %v of type %T is not a %T", expression, expression, (*T)(nil))
+	}
+	return result, nil
+}
+
+var constructUnmarshalers = map[string]ModelUnmarshalFunc{
+	maximumKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		maximum, err := as[VehicleTypeExpression](e[1])
+		if err != nil {
+			return nil, err
+		}
+		return &maximumImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{e[0]}),
+			maximum:             maximum,
+			penaltyOffset:       r.Value,
+		}, nil
+	}),
+	latestKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		latest, err := as[StopTimeExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		latenessFactor, err := as[StopExpression](e[1])
+		if err != nil {
+			return nil, err
+		}
+		return &latestImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			latest:              latest,
+			latenessFactor:      latenessFactor,
+			temporalReference:   TemporalReference(r.TemporalReference),
+		}, nil
+	}),
+	attributesConstraintKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, _ ModelExpressions) (any, error) {
+		constraint := &attributesConstraintImpl{
+			modelConstraintImpl:   newModelConstraintImpl(r.Name, ModelExpressions{}),
+			stopAttributes:        r.StopAttributes,
+			vehicleTypeAttributes: r.VehicleTypeAttributes,
+		}
+		if constraint.stopAttributes == nil {
+			constraint.stopAttributes = make(map[int][]string)
+		}
+		if constraint.vehicleTypeAttributes == nil {
+			constraint.vehicleTypeAttributes = make(map[int][]string)
+		}
+		return constraint, nil
+	}),
+	maximumStopsConstraintKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		maximumStops, err := as[VehicleTypeExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		return &maximumStopsConstraintImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			maximumStops:        maximumStops,
+		}, nil
+	}),
+	maximumDurationConstraintKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		maximum, err := as[VehicleTypeDurationExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		return &maximumDurationConstraintImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			maximum:             maximum,
+		}, nil
+	}),
+	maximumTravelDurationKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		maximum, err := as[VehicleTypeDurationExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		return &maximumTravelDurationConstraintImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			maximum:             maximum,
+		}, nil
+	}),
+	maximumWaitStopConstraintKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		maxima, err := as[StopDurationExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		return &maximumWaitStopConstraintImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			maxima:              maxima,
+		}, nil
+	}),
+	maximumWaitVehicleConstraintKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		maxima, err := as[VehicleTypeDurationExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		return &maximumWaitVehicleConstraintImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			maxima:              maxima,
+		}, nil
+	}),
+	noMixConstraintKind: constructUnmarshaler(func(d ModelDecoder, r constructRecordFields, _ ModelExpressions) (any, error) {
+		constraint := &noMixConstraintImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			insert:              make(map[ModelStop]MixItem, len(r.Insert)),
+			remove:              make(map[ModelStop]MixItem, len(r.Remove)),
+		}
+		for idx, item := range r.Insert {
+			stop, err := d.Model().Stop(idx)
+			if err != nil {
+				return nil, err
+			}
+			constraint.insert[stop] = item
+		}
+		for idx, item := range r.Remove {
+			stop, err := d.Model().Stop(idx)
+			if err != nil {
+				return nil, err
+			}
+			constraint.remove[stop] = item
+		}
+		return constraint, nil
+	}),
+	clusterKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, _ ModelExpressions) (any, error) {
+		return &clusterImpl{
+			modelConstraintImpl: newModelConstraintImpl(r.Name, ModelExpressions{}),
+			includeFirst:        r.IncludeFirst,
+			includeLast:         r.IncludeLast,
+		}, nil
+	}),
+	successorConstraintKind: constructUnmarshaler(func(d ModelDecoder, r constructRecordFields, _ ModelExpressions) (any, error) {
+		constraint := &successorConstraintImpl{
+			modelConstraintImpl:  newModelConstraintImpl(r.Name, ModelExpressions{}),
+			disallowedSuccessors: make(map[ModelStop]ModelStops, len(r.Successors)),
+		}
+		for idx, successors := range r.Successors {
+			stop, err := d.Model().Stop(idx)
+			if err != nil {
+				return nil, err
+			}
+			for _, successorIdx := range successors {
+				successor, err := d.Model().Stop(successorIdx)
+				if err != nil {
+					return nil, err
+				}
+				constraint.disallowedSuccessors[stop] = append(constraint.disallowedSuccessors[stop], successor)
+			}
+		}
+		return constraint, nil
+	}),
+	earlinessObjectiveKind: constructUnmarshaler(func(_ ModelDecoder, r constructRecordFields, e ModelExpressions) (any, error) {
+		targetTime, err := as[StopTimeExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		earlinessFactor, err := as[StopExpression](e[1])
+		if err != nil {
+			return nil, err
+		}
+		return NewEarlinessObjective(targetTime, earlinessFactor, TemporalReference(r.TemporalReference))
+	}),
+	expressionObjectiveKind: constructUnmarshaler(func(_ ModelDecoder, _ constructRecordFields, e ModelExpressions) (any, error) {
+		return NewExpressionObjective(e[0]), nil
+	}),
+	minStopsObjectiveKind: constructUnmarshaler(func(_ ModelDecoder, _ constructRecordFields, e ModelExpressions) (any, error) {
+		minStops, err := as[VehicleTypeExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		minStopsPenalty, err := as[VehicleTypeExpression](e[1])
+		if err != nil {
+			return nil, err
+		}
+		return NewMinStopsObjective(minStops, minStopsPenalty), nil
+	}),
+	travelDurationObjectiveKind: constructUnmarshaler(func(ModelDecoder, constructRecordFields, ModelExpressions) (any, error) {
+		return NewTravelDurationObjective(), nil
+	}),
+	unplannedObjectiveKind: constructUnmarshaler(func(_ ModelDecoder, _ constructRecordFields, e ModelExpressions) (any, error) {
+		expression, err := as[StopExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		return NewUnPlannedObjective(expression), nil
+	}),
+	vehiclesObjectiveKind: constructUnmarshaler(func(_ ModelDecoder, _ constructRecordFields, e ModelExpressions) (any, error) {
+		expression, err := as[VehicleTypeExpression](e[0])
+		if err != nil {
+			return nil, err
+		}
+		return NewVehiclesObjective(expression), nil
+	}),
+	vehiclesDurationObjectiveKind: constructUnmarshaler(func(ModelDecoder, constructRecordFields, ModelExpressions) (any, error) {
+		return NewVehiclesDurationObjective(), nil
+	}),
+}
@@ -0,0 +1,677 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/sdk/measure"
+)
+
+// The kinds of the built-in expressions written by WriteModel.
+const (
+	constantExpressionKind                = "nextroute.constant_expression"
+	fromExpressionKind                    = "nextroute.from_expression"
+	toExpressionKind                      = "nextroute.to_expression"
+	vehicleTypeExpressionKind             = "nextroute.vehicle_type_expression"
+	vehicleTypeDistanceExpressionKind     = "nextroute.vehicle_type_distance_expression"
+	fromToExpressionKind                  = "nextroute.from_to_expression"
+	vehicleTypeFromToExpressionKind       = "nextroute.vehicle_type_from_to_expression"
+	distanceExpressionKind                = "nextroute.distance_expression"
+	scaledDurationExpressionKind          = "nextroute.scaled_duration_expression"
+	stopDurationExpressionKind            = "nextroute.stop_duration_expression"
+	vehicleTypeDurationExpressionKind     = "nextroute.vehicle_type_duration_expression"
+	constantDurationExpressionKind        = "nextroute.constant_duration_expression"
+	travelDurationExpressionKind          = "nextroute.travel_duration_expression"
+	timeExpressionKind                    = "nextroute.time_expression"
+	stopTimeExpressionKind                = "nextroute.stop_time_expression"
+	timeDependentDurationExpressionKind   = "nextroute.time_dependent_duration_expression"
+	timeIndependentDurationExpressionKind = "nextroute.time_independent_duration_expression"
+	composedExpressionKind                = "nextroute.composed_per_vehicle_type_expression"
+	sumExpressionKind                     = "nextroute.sum_expression"
+	termExpressionKind                    = "nextroute.term_expression"
+	measureByIndexExpressionKind          = "nextroute.measure_by_index_expression"
+	haversineExpressionKind               = "nextroute.haversine_expression"
+)
+
+// metersPerHour is the unit speeds are written in, it is the unit speeds are
+// stored in.
+var metersPerHour = common.NewSpeedUnit(common.Meters, time.Hour)
+
+// valuesRecord is the record of expressions defined by a default value and a
+// value per stop or vehicle type.
+type valuesRecord struct {
+	Name              string
+	Values            []float64
+	DefaultValue      float64
+	HasPositiveValues bool
+	HasNegativeValues bool
+}
+
+// valueMapRecord is the record of expressions defined by a default value and
+// a value per stop or vehicle type index.
+type valueMapRecord struct {
+	Values            map[int]float64
+	Name              string
+	DefaultValue      float64
+	HasPositiveValues bool
+	HasNegativeValues bool
+}
+
+// fromToRecord is the record of expressions defined by a default value and a
+// value per pair of stops, optionally per vehicle type.
+type fromToRecord struct {
+	Values            map[int]map[int]float64
+	VehicleTypeValues map[int]map[int]map[int]float64
+	Name              string
+	DefaultValue      float64
+	HasPositiveValues bool
+	HasNegativeValues bool
+}
+
+// wrappedRecord is the record of expressions defined by other expressions.
+type wrappedRecord struct {
+	Epoch       time.Time
+	Name        string
+	Expressions []int
+	Expression  int
+	Value       float64
+	Unit        int
+	Flag        bool
+}
+
+type stopTimeRecord struct {
+	DefaultTime  time.Time
+	Name         string
+	Values       []float64
+	HasValue     []bool
+	DefaultValue float64
+}
+
+type timeDependentRecord struct {
+	Name                        string
+	Elements                    []timeDependentElementRecord
+	DefaultExpression           int
+	SatisfiesTriangleInequality bool
+}
+
+type timeDependentElementRecord struct {
+	Start      float64
+	End        float64
+	Expression int
+}
+
+type measureByIndexRecord struct {
+	Name  string
+	Costs []float64
+	Size  int
+}
+
+func (c *constantExpression) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(constantExpressionKind, wrappedRecord{Name: c.name, Value: c.value})
+}
+
+func (s *fromExpression) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(fromExpressionKind, valuesRecord{
+		Name:              s.name,
+		Values:            s.values,
+		DefaultValue:      s.defaultValue,
+		HasPositiveValues: s.hasPositiveValues,
+		HasNegativeValues: s.hasNegativeValues,
+	})
+}
+
+func (s *toExpression) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(toExpressionKind, valuesRecord{
+		Name:              s.name,
+		Values:            s.values,
+		DefaultValue:      s.defaultValue,
+		HasPositiveValues: s.hasPositiveValues,
+		HasNegativeValues: s.hasNegativeValues,
+	})
+}
+
+func (v *vehicleTypeExpressionImpl) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(vehicleTypeExpressionKind, valuesRecord{
+		Name:              v.name,
+		Values:            v.values,
+		DefaultValue:      v.defaultValue,
+		HasPositiveValues: v.hasPositiveValues,
+		HasNegativeValues: v.hasNegativeValues,
+	})
+}
+
+func (v *vehicleTypeDistanceExpressionImpl) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(vehicleTypeDistanceExpressionKind, valuesRecord{
+		Name: v.name,
+		Values: common.Map(v.values, func(distance common.Distance) float64 {
+			return distance.Value(common.Meters)
+		}),
+		DefaultValue:      v.defaultValue.Value(common.Meters),
+		HasPositiveValues: v.hasPositiveValues,
+		HasNegativeValues: v.hasNegativeValues,
+	})
+}
+
+func (m *fromToExpression) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(fromToExpressionKind, fromToRecord{
+		Name:              m.name,
+		Values:            m.values,
+		DefaultValue:      m.defaultValue,
+		HasPositiveValues: m.hasPositiveValues,
+		HasNegativeValues: m.hasNegativeValues,
+	})
+}
+
+func (m *vehicleTypeFromToExpression) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(vehicleTypeFromToExpressionKind, fromToRecord{
+		Name:              m.name,
+		VehicleTypeValues: m.values,
+		DefaultValue:      m.defaultValue,
+		HasPositiveValues: m.hasPositiveValues,
+		HasNegativeValues: m.hasNegativeValues,
+	})
+}
+
+func (d *distanceExpression) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	expression, err := encoder.Expression(d.modelExpression)
+	if err != nil {
+		return "", nil, err
+	}
+	return marshalRecord(distanceExpressionKind, wrappedRecord{
+		Name:       d.name,
+		Expression: expression,
+		Unit:       int(d.unit),
+	})
+}
+
+func (s *scaledDurationExpressionImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	expression, err := encoder.Expression(s.expression)
+	if err != nil {
+		return "", nil, err
+	}
+	return marshalRecord(scaledDurationExpressionKind, wrappedRecord{
+		Name:       s.name,
+		Expression: expression,
+		Value:      s.multiplier,
+	})
+}
+
+func (s *stopDurationExpressionImpl) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(stopDurationExpressionKind, valueMapRecord{
+		Name:              s.name,
+		Values:            s.values,
+		DefaultValue:      s.defaultValue,
+		HasPositiveValues: s.hasPositiveValues,
+		HasNegativeValues: s.hasNegativeValues,
+	})
+}
+
+func (v *vehicleTypeDurationExpressionImpl) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(vehicleTypeDurationExpressionKind, valueMapRecord{
+		Name:              v.name,
+		Values:            v.values,
+		DefaultValue:      v.defaultValue,
+		HasPositiveValues: v.hasPositiveValues,
+		HasNegativeValues: v.hasNegativeValues,
+	})
+}
+
+func (c *constantDurationExpressionImpl) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(constantDurationExpressionKind, wrappedRecord{
+		Name:  c.name,
+		Value: float64(c.duration),
+	})
+}
+
+func (d *travelDurationExpression) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	expression, err := encoder.Expression(d.distanceExpression)
+	if err != nil {
+		return "", nil, err
+	}
+	return marshalRecord(travelDurationExpressionKind, wrappedRecord{
+		Name:       d.name,
+		Expression: expression,
+		Value:      d.speed.Value(metersPerHour),
+	})
+}
+
+func (t *timeExpressionImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	expression, err := encoder.Expression(t.expression)
+	if err != nil {
+		return "", nil, err
+	}
+	return marshalRecord(timeExpressionKind, wrappedRecord{
+		Name:       t.name,
+		Expression: expression,
+		Epoch:      t.epoch,
+	})
+}
+
+func (s *stopTimeExpressionImpl) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(stopTimeExpressionKind, stopTimeRecord{
+		Name:         s.name,
+		DefaultTime:  s.defaultTime,
+		Values:       s.values,
+		HasValue:     s.hasValue,
+		DefaultValue: s.defaultValue,
+	})
+}
+
+func (t *timeDependentDurationExpressionImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	defaultExpression, err := encoder.Expression(t.defaultExpression)
+	if err != nil {
+		return "", nil, err
+	}
+	record := timeDependentRecord{
+		Name:                        t.name,
+		DefaultExpression:           defaultExpression,
+		SatisfiesTriangleInequality: t.satisfiesTriangleInequality,
+	}
+	for element := t.startElement; element != nil; element = element.next {
+		if element.expression == t.defaultExpression {
+			continue
+		}
+		expression, err := encoder.Expression(element.expression)
+		if err != nil {
+			return "", nil, err
+		}
+		record.Elements = append(record.Elements, timeDependentElementRecord{
+			Start:      element.start,
+			End:        element.end,
+			Expression: expression,
+		})
+	}
+	return marshalRecord(timeDependentDurationExpressionKind, record)
+}
+
+func (t *timeIndependentDurationExpressionImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	expression, err := encoder.Expression(t.expression)
+	if err != nil {
+		return "", nil, err
+	}
+	return marshalRecord(timeIndependentDurationExpressionKind, wrappedRecord{
+		Name:       t.name,
+		Expression: expression,
+		Flag:       t.satisfiesTriangleInequality,
+	})
+}
+
+func (t *composedPerVehicleTypeExpressionImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	defaultExpression, err := encoder.Expression(t.defaultExpression)
+	if err != nil {
+		return "", nil, err
+	}
+	record := wrappedRecord{
+		Name:        t.name,
+		Expression:  defaultExpression,
+		Expressions: make([]int, len(t.expressions)),
+	}
+	for idx, expression := range t.expressions {
+		if record.Expressions[idx], err = encoder.Expression(expression); err != nil {
+			return "", nil, err
+		}
+	}
+	return marshalRecord(composedExpressionKind, record)
+}
+
+func (n *sumExpressionImpl) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	record := wrappedRecord{
+		Name:        n.name,
+		Expressions: make([]int, len(n.expressions)),
+	}
+	for idx, expression := range n.expressions {
+		var err error
+		if record.Expressions[idx], err = encoder.Expression(expression); err != nil {
+			return "", nil, err
+		}
+	}
+	return marshalRecord(sumExpressionKind, record)
+}
+
+func (t *termExpression) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	expression, err := encoder.Expression(t.expression)
+	if err != nil {
+		return "", nil, err
+	}
+	return marshalRecord(termExpressionKind, wrappedRecord{
+		Name:       t.name,
+		Expression: expression,
+		Value:      t.factor,
+	})
+}
+
+// MarshalModel writes the costs of the measure between the measure indices
+// of the stops of the model as a matrix.
+func (m *measureByIndexExpression) MarshalModel(encoder ModelEncoder) (string, []byte, error) {
+	size := 0
+	for _, stop := range encoder.Model().Stops() {
+		size = max(size, stop.MeasureIndex()+1)
+	}
+	record := measureByIndexRecord{
+		Name:  m.name,
+		Size:  size,
+		Costs: make([]float64, size*size),
+	}
+	for from := 0; from < size; from++ {
+		for to := 0; to < size; to++ {
+			record.Costs[from*size+to] = m.measure.Cost(from, to)
+		}
+	}
+	return marshalRecord(measureByIndexExpressionKind, record)
+}
+
+func (h *haversineExpression) MarshalModel(ModelEncoder) (string, []byte, error) {
+	return marshalRecord(haversineExpressionKind, wrappedRecord{Name: h.name})
+}
+
+// unmarshaler returns a ModelUnmarshalFunc decoding the record of a built-in
+// construct and recreating the construct from it.
+func unmarshaler[R any](
+	create func(decoder ModelDecoder, record R) (any, error),
+) ModelUnmarshalFunc {
+	return func(decoder ModelDecoder, data []byte) (any, error) {
+		var record R
+		if len(data) > 0 {
+			if err := unmarshalRecord(data, &record); err != nil {
+				return nil, err
+			}
+		}
+		return create(decoder, record)
+	}
+}
+
+// expressionAs returns the expression of the reference as T.
+func expressionAs[T any](decoder ModelDecoder, reference int) (T, error) {
+	expression, err := decoder.Expression(reference)
+	if err != nil {
+		var result T
+		return result, err
+	}
+	return as[T](expression)
+}
+
+var expressionUnmarshalers = map[string]ModelUnmarshalFunc{
+	constantExpressionKind: unmarshaler(func(_ ModelDecoder, r wrappedRecord) (any, error) {
+		return &constantExpression{
+			index: NewModelExpressionIndex(),
+			name:  r.Name,
+			value: r.Value,
+		}, nil
+	}),
+	fromExpressionKind: unmarshaler(func(_ ModelDecoder, r valuesRecord) (any, error) {
+		return &fromExpression{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			values:            orEmpty(r.Values),
+			defaultValue:      r.DefaultValue,
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	toExpressionKind: unmarshaler(func(_ ModelDecoder, r valuesRecord) (any, error) {
+		return &toExpression{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			values:            orEmpty(r.Values),
+			defaultValue:      r.DefaultValue,
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	vehicleTypeExpressionKind: unmarshaler(func(_ ModelDecoder, r valuesRecord) (any, error) {
+		return &vehicleTypeExpressionImpl{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			values:            orEmpty(r.Values),
+			defaultValue:      r.DefaultValue,
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	vehicleTypeDistanceExpressionKind: unmarshaler(func(_ ModelDecoder, r valuesRecord) (any, error) {
+		return &vehicleTypeDistanceExpressionImpl{
+			index: NewModelExpressionIndex(),
+			name:  r.Name,
+			values: common.Map(orEmpty(r.Values), func(meters float64) common.Distance {
+				return common.NewDistance(meters, common.Meters)
+			}),
+			defaultValue:      common.NewDistance(r.DefaultValue, common.Meters),
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	fromToExpressionKind: unmarshaler(func(_ ModelDecoder, r fromToRecord) (any, error) {
+		values := r.Values
+		if values == nil {
+			values = map[int]map[int]float64{}
+		}
+		return &fromToExpression{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			values:            values,
+			defaultValue:      r.DefaultValue,
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	vehicleTypeFromToExpressionKind: unmarshaler(func(_ ModelDecoder, r fromToRecord) (any, error) {
+		values := r.VehicleTypeValues
+		if values == nil {
+			values = map[int]map[int]map[int]float64{}
+		}
+		return &vehicleTypeFromToExpression{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			values:            values,
+			defaultValue:      r.DefaultValue,
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	distanceExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		expression, err := d.Expression(r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &distanceExpression{
+			index:           NewModelExpressionIndex(),
+			name:            r.Name,
+			modelExpression: expression,
+			unit:            common.DistanceUnit(r.Unit),
+		}, nil
+	}),
+	scaledDurationExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		expression, err := d.Expression(r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &scaledDurationExpressionImpl{
+			index:      NewModelExpressionIndex(),
+			name:       r.Name,
+			expression: expression,
+			multiplier: r.Value,
+		}, nil
+	}),
+	stopDurationExpressionKind: unmarshaler(func(_ ModelDecoder, r valueMapRecord) (any, error) {
+		return &stopDurationExpressionImpl{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			values:            orEmptyMap(r.Values),
+			defaultValue:      r.DefaultValue,
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	vehicleTypeDurationExpressionKind: unmarshaler(func(_ ModelDecoder, r valueMapRecord) (any, error) {
+		return &vehicleTypeDurationExpressionImpl{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			values:            orEmptyMap(r.Values),
+			defaultValue:      r.DefaultValue,
+			hasPositiveValues: r.HasPositiveValues,
+			hasNegativeValues: r.HasNegativeValues,
+		}, nil
+	}),
+	constantDurationExpressionKind: unmarshaler(func(_ ModelDecoder, r wrappedRecord) (any, error) {
+		return &constantDurationExpressionImpl{
+			index:    NewModelExpressionIndex(),
+			name:     r.Name,
+			duration: time.Duration(r.Value),
+		}, nil
+	}),
+	travelDurationExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		distance, err := expressionAs[DistanceExpression](d, r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &travelDurationExpression{
+			index:              NewModelExpressionIndex(),
+			name:               r.Name,
+			distanceExpression: distance,
+			speed:              common.NewSpeed(r.Value, metersPerHour),
+		}, nil
+	}),
+	timeExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		expression, err := d.Expression(r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &timeExpressionImpl{
+			index:      NewModelExpressionIndex(),
+			name:       r.Name,
+			expression: expression,
+			epoch:      r.Epoch,
+		}, nil
+	}),
+	stopTimeExpressionKind: unmarshaler(func(_ ModelDecoder, r stopTimeRecord) (any, error) {
+		return &stopTimeExpressionImpl{
+			index:        NewModelExpressionIndex(),
+			name:         r.Name,
+			defaultTime:  r.DefaultTime,
+			values:       r.Values,
+			hasValue:     r.HasValue,
+			defaultValue: r.DefaultValue,
+		}, nil
+	}),
+	timeDependentDurationExpressionKind: unmarshaler(func(d ModelDecoder, r timeDependentRecord) (any, error) {
+		defaultExpression, err := expressionAs[DurationExpression](d, r.DefaultExpression)
+		if err != nil {
+			return nil, err
+		}
+		expression, err := NewTimeDependentDurationExpression(d.Model(), defaultExpression)
+		if err != nil {
+			return nil, err
+		}
+		for _, element := range r.Elements {
+			elementExpression, err := expressionAs[DurationExpression](d, element.Expression)
+			if err != nil {
+				return nil, err
+			}
+			err = expression.SetExpression(
+				d.Model().ValueToTime(element.Start),
+				d.Model().ValueToTime(element.End),
+				elementExpression,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		expression.SetSatisfiesTriangleInequality(r.SatisfiesTriangleInequality)
+		expression.(*timeDependentDurationExpressionImpl).name = r.Name
+		return expression, nil
+	}),
+	timeIndependentDurationExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		expression, err := expressionAs[DurationExpression](d, r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &timeIndependentDurationExpressionImpl{
+			name:                        r.Name,
+			expression:                  expression,
+			satisfiesTriangleInequality: r.Flag,
+		}, nil
+	}),
+	composedExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		defaultExpression, err := d.Expression(r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		expressions := make([]ModelExpression, len(r.Expressions))
+		for idx, reference := range r.Expressions {
+			if expressions[idx], err = d.Expression(reference); err != nil {
+				return nil, err
+			}
+		}
+		return &composedPerVehicleTypeExpressionImpl{
+			index:             NewModelExpressionIndex(),
+			name:              r.Name,
+			defaultExpression: defaultExpression,
+			expressions:       expressions,
+		}, nil
+	}),
+	sumExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		expressions := make(ModelExpressions, len(r.Expressions))
+		for idx, reference := range r.Expressions {
+			var err error
+			if expressions[idx], err = d.Expression(reference); err != nil {
+				return nil, err
+			}
+		}
+		return &sumExpressionImpl{
+			index:       NewModelExpressionIndex(),
+			name:        r.Name,
+			expressions: expressions,
+		}, nil
+	}),
+	termExpressionKind: unmarshaler(func(d ModelDecoder, r wrappedRecord) (any, error) {
+		expression, err := d.Expression(r.Expression)
+		if err != nil {
+			return nil, err
+		}
+		return &termExpression{
+			index:      NewModelExpressionIndex(),
+			name:       r.Name,
+			expression: expression,
+			factor:     r.Value,
+		}, nil
+	}),
+	measureByIndexExpressionKind: unmarshaler(func(_ ModelDecoder, r measureByIndexRecord) (any, error) {
+		if len(r.Costs) != r.Size*r.Size {
+			return nil, fmt.Errorf("matrix of size %d has %d costs", r.Size, len(r.Costs))
+		}
+		matrix := make([][]float64, r.Size)
+		for from := range matrix {
+			matrix[from] = r.Costs[from*r.Size : (from+1)*r.Size]
+		}
+		return &measureByIndexExpression{
+			index:   NewModelExpressionIndex(),
+			name:    r.Name,
+			measure: measure.Matrix(matrix),
+		}, nil
+	}),
+	haversineExpressionKind: unmarshaler(func(_ ModelDecoder, r wrappedRecord) (any, error) {
+		return &haversineExpression{
+			index: NewModelExpressionIndex(),
+			name:  r.Name,
+		}, nil
+	}),
+}
+
+// orEmpty returns the values, an empty slice if nil.
+func orEmpty(values []float64) []float64 {
+	if values == nil {
+		return []float64{}
+	}
+	return values
+}
+
+// orEmptyMap returns the values, an empty map if nil.
+func orEmptyMap(values map[int]float64) map[int]float64 {
+	if values == nil {
+		return map[int]float64{}
+	}
+	return values
+}
@@ -0,0 +1,153 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+// forbiddenStopConstraint is a custom constraint opting in to be written.
+type forbiddenStopConstraint struct {
+	stop nextroute.ModelStop
+}
+
+func (c *forbiddenStopConstraint) EstimateIsViolated(
+	move nextroute.SolutionMoveStops,
+) (bool, nextroute.StopPositionsHint) {
+	for _, position := range move.StopPositions() {
+		if position.Stop().ModelStop() == c.stop {
+			return true, nextroute.NoPositionsHint()
+		}
+	}
+	return false, nextroute.NoPositionsHint()
+}
+
+func (c *forbiddenStopConstraint) MarshalModel(nextroute.ModelEncoder) (string, []byte, error) {
+	return "test.forbidden_stop", []byte(strconv.Itoa(c.stop.Index())), nil
+}
+
+func TestWriteReadModel(t *testing.T) {
+	nextroute.RegisterModelUnmarshaler(
+		"test.forbidden_stop",
+		func(decoder nextroute.ModelDecoder, data []byte) (any, error) {
+			index, err := strconv.Atoi(string(data))
+			if err != nil {
+				return nil, err
+			}
+			stop, err := decoder.Model().Stop(index)
+			if err != nil {
+				return nil, err
+			}
+			return &forbiddenStopConstraint{stop: stop}, nil
+		},
+	)
+
+	model, err := createModel(singleVehiclePlanSequenceModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	forbidden := model.PlanStopsUnits()[0].Stops()[0]
+	if err := model.AddConstraint(&forbiddenStopConstraint{stop: forbidden}); err != nil {
+		t.Fatal(err)
+	}
+
+	rush := time.Date(2023, 1, 1, 8, 0, 0, 0, time.UTC)
+	timeDependent, err := nextroute.NewTimeDependentDurationExpression(
+		model,
+		nextroute.NewConstantDurationExpression("normal", time.Minute),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = timeDependent.SetExpression(
+		rush,
+		rush.Add(time.Hour),
+		nextroute.NewConstantDurationExpression("rush", 2*time.Minute),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rushVehicleType, err := model.NewVehicleType(
+		timeDependent,
+		nextroute.NewConstantDurationExpression("service", 0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(2, nextroute.NewVehiclesObjective(
+		nextroute.NewVehicleTypeValueExpression("activation", 1),
+	)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buffer bytes.Buffer
+	if err := nextroute.WriteModel(&buffer, model); err != nil {
+		t.Fatal(err)
+	}
+	read, err := nextroute.ReadModel(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(read.Stops()) != len(model.Stops()) ||
+		len(read.PlanUnits()) != len(model.PlanUnits()) ||
+		len(read.Vehicles()) != len(model.Vehicles()) {
+		t.Fatalf("read model differs in size from the written model")
+	}
+	for idx, stop := range model.Stops() {
+		readStop := read.Stops()[idx]
+		if readStop.ID() != stop.ID() || !readStop.Location().Equals(stop.Location()) {
+			t.Errorf("stop %d is %v, want %v", idx, readStop, stop)
+		}
+	}
+
+	constraint, ok := read.Constraints()[0].(*forbiddenStopConstraint)
+	if !ok || constraint.stop.ID() != forbidden.ID() || constraint.stop.Model() != read {
+		t.Errorf("expected the custom constraint on stop %s, got %v", forbidden.ID(), read.Constraints())
+	}
+
+	terms := read.Objective().Terms()
+	if len(terms) != 1 || terms[0].Factor() != 2 {
+		t.Fatalf("expected a single term with factor 2, got %v", terms)
+	}
+
+	vehicleType := read.VehicleTypes()[rushVehicleType.Index()]
+	readTimeDependent := vehicleType.TravelDurationExpression()
+	for _, at := range []time.Time{rush.Add(-time.Minute), rush, rush.Add(30 * time.Minute), rush.Add(time.Hour)} {
+		want := timeDependent.ValueAtTime(at, rushVehicleType, nil, nil)
+		got := readTimeDependent.ValueAtTime(at, vehicleType, nil, nil)
+		if got != want {
+			t.Errorf("value at %v is %v, want %v", at, got, want)
+		}
+	}
+
+	if _, err := nextroute.NewSolution(read); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteModelUnsupported(t *testing.T) {
+	model, err := createModel(singleVehiclePlanSingleStopsModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expression := nextroute.NewOperatorExpression(
+		"product",
+		nextroute.NewConstantExpression("a", 1),
+		nextroute.NewConstantExpression("b", 2),
+		func(a, b float64) float64 { return a * b },
+	)
+	if _, err := model.Objective().NewTerm(1, nextroute.NewExpressionObjective(expression)); err != nil {
+		t.Fatal(err)
+	}
+	err = nextroute.WriteModel(&bytes.Buffer{}, model)
+	if err == nil || !strings.Contains(err.Error(), "does not implement ModelMarshaler") {
+		t.Errorf("expected an error writing a binary expression, got %v", err)
+	}
+}
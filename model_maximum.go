@@ -280,6 +280,73 @@ func (l *maximumImpl) EstimateIsViolated(
 	return false, constNoPositionsHint
 }
 
+func (l *maximumImpl) ExplainViolation(move SolutionMoveStops) string {
+	moveImpl := move.(*solutionMoveStopsImpl)
+	vehicle := moveImpl.vehicle()
+	vehicleType := vehicle.ModelVehicle().VehicleType()
+	maximum := l.maximumByVehicleType[vehicleType.Index()]
+	expression := l.resourceExpression
+
+	generator := newSolutionStopGenerator(*moveImpl, false, true)
+	defer generator.release()
+
+	previousStop, _ := generator.next()
+	previousModelStop := previousStop.ModelStop()
+	level := previousStop.CumulativeValue(expression)
+	for solutionStop, ok := generator.next(); ok; solutionStop, ok = generator.next() {
+		modelStop := solutionStop.ModelStop()
+		level += expression.Value(vehicleType, previousModelStop, modelStop)
+		previousModelStop = modelStop
+
+		if level > maximum {
+			return fmt.Sprintf(
+				"%v would be %v at stop %s, above the maximum %v of vehicle %s",
+				l,
+				level,
+				modelStop.ID(),
+				maximum,
+				vehicle.ModelVehicle().ID(),
+			)
+		}
+		if level < 0 {
+			return fmt.Sprintf(
+				"%v would be %v at stop %s, below zero",
+				l,
+				level,
+				modelStop.ID(),
+			)
+		}
+	}
+	return ""
+}
+
+func (l *maximumImpl) Relaxation(move SolutionMoveStops) (float64, bool) {
+	moveImpl := move.(*solutionMoveStopsImpl)
+	vehicle := moveImpl.vehicle()
+	vehicleType := vehicle.ModelVehicle().VehicleType()
+	expression := l.resourceExpression
+
+	generator := newSolutionStopGenerator(*moveImpl, false, true)
+	defer generator.release()
+
+	previousStop, _ := generator.next()
+	previousModelStop := previousStop.ModelStop()
+	level := previousStop.CumulativeValue(expression)
+	highest := level
+	for solutionStop, ok := generator.next(); ok; solutionStop, ok = generator.next() {
+		modelStop := solutionStop.ModelStop()
+		level += expression.Value(vehicleType, previousModelStop, modelStop)
+		previousModelStop = modelStop
+
+		// A level below zero is not allowed by a higher maximum.
+		if level < 0 {
+			return 0, false
+		}
+		highest = math.Max(highest, level)
+	}
+	return math.Max(highest-l.maximumByVehicleType[vehicleType.Index()], 0), true
+}
+
 type maximumObjectiveDate struct {
 	hasViolation bool
 }
@@ -32,6 +32,17 @@ type ObjectiveSolutionDataUpdater interface {
 	UpdateObjectiveSolutionData(s Solution) (Copier, error)
 }
 
+// ObjectiveVehicleValuer is the interface an objective can implement to
+// attribute its value to the vehicles of a solution, for example to explain
+// the cost of a route. The values of all vehicles add up to the value of the
+// objective, except for value that does not belong to a vehicle such as the
+// penalty of unplanned stops.
+type ObjectiveVehicleValuer interface {
+	// VehicleValue returns the part of the value of the objective that is
+	// caused by the given vehicle.
+	VehicleValue(vehicle SolutionVehicle) float64
+}
+
 // ModelObjective is an objective function that can be used to optimize a
 // solution.
 type ModelObjective interface {
@@ -126,6 +137,18 @@ func (m *modelObjectiveSumImpl) Value(_ Solution) float64 {
 	panic("use Solution.ObjectiveValue or solution.Score to query objective value")
 }
 
+// VehicleValue returns the sum of the factored vehicle values of the terms
+// whose objective implements ObjectiveVehicleValuer.
+func (m *modelObjectiveSumImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	value := 0.0
+	for _, term := range m.terms {
+		if valuer, ok := term.Objective().(ObjectiveVehicleValuer); ok {
+			value += term.Factor() * valuer.VehicleValue(vehicle)
+		}
+	}
+	return value
+}
+
 func (m *modelObjectiveSumImpl) Terms() ModelObjectiveTerms {
 	return m.terms
 }
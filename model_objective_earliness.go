@@ -99,19 +99,25 @@ func (l *earlinessObjectiveImpl) earliness(stop SolutionStop) float64 {
 func (l *earlinessObjectiveImpl) Value(solution Solution) float64 {
 	value := 0.0
 	for _, vehicle := range solution.Vehicles() {
-		for s := vehicle.First().Next(); !s.IsLast(); s = s.Next() {
-			earlinessFactor := l.earlinessFactor.Value(
-				nil,
-				nil,
-				s.ModelStop(),
-			)
-			value += l.Earliness(s) * earlinessFactor
-		}
+		value += l.VehicleValue(vehicle)
 	}
 
 	return value
 }
 
+func (l *earlinessObjectiveImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	value := 0.0
+	for s := vehicle.First().Next(); !s.IsLast(); s = s.Next() {
+		earlinessFactor := l.earlinessFactor.Value(
+			nil,
+			nil,
+			s.ModelStop(),
+		)
+		value += l.Earliness(s) * earlinessFactor
+	}
+	return value
+}
+
 func (l *earlinessObjectiveImpl) EstimateDeltaValue(
 	move SolutionMoveStops,
 ) float64 {
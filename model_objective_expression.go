@@ -38,11 +38,15 @@ func (e *expressionObjectiveImpl) Index() int {
 func (e *expressionObjectiveImpl) Value(solution Solution) float64 {
 	score := 0.0
 	for _, r := range solution.Vehicles() {
-		score += r.Last().CumulativeValue(e.expression)
+		score += e.VehicleValue(r)
 	}
 	return score
 }
 
+func (e *expressionObjectiveImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	return vehicle.Last().CumulativeValue(e.expression)
+}
+
 func (e *expressionObjectiveImpl) EstimateDeltaValue(
 	move SolutionMoveStops,
 ) float64 {
@@ -53,21 +53,26 @@ func (t *minStopsObjectiveImpl) Value(solution Solution) float64 {
 	solutionImpl := solution.(*solutionImpl)
 	penaltySum := 0.0
 	for _, vehicle := range solutionImpl.vehicles {
-		vehicleNumberOfStops := vehicle.NumberOfStops()
-		if vehicleNumberOfStops == 0 {
-			continue
-		}
-		modelVehicle := vehicle.ModelVehicle().(*modelVehicleImpl)
-		minimum := int(t.minStops.ValueForVehicleType(modelVehicle.vehicleType))
-		if vehicleNumberOfStops < minimum {
-			penaltySum += t.minStopsPenalty.ValueForVehicleType(modelVehicle.vehicleType) *
-				(float64(minimum) - float64(vehicleNumberOfStops)) *
-				(float64(minimum) - float64(vehicleNumberOfStops))
-		}
+		penaltySum += t.VehicleValue(vehicle)
 	}
 	return penaltySum
 }
 
+func (t *minStopsObjectiveImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	vehicleNumberOfStops := vehicle.NumberOfStops()
+	if vehicleNumberOfStops == 0 {
+		return 0
+	}
+	modelVehicle := vehicle.ModelVehicle().(*modelVehicleImpl)
+	minimum := int(t.minStops.ValueForVehicleType(modelVehicle.vehicleType))
+	if vehicleNumberOfStops >= minimum {
+		return 0
+	}
+	return t.minStopsPenalty.ValueForVehicleType(modelVehicle.vehicleType) *
+		(float64(minimum) - float64(vehicleNumberOfStops)) *
+		(float64(minimum) - float64(vehicleNumberOfStops))
+}
+
 func (t *minStopsObjectiveImpl) String() string {
 	return "min_stops"
 }
@@ -28,11 +28,15 @@ func (t *travelDurationObjectiveImpl) Value(solution Solution) float64 {
 
 	score := 0.0
 	for _, vehicle := range solutionImp.vehicles {
-		score += vehicle.Last().CumulativeTravelDurationValue()
+		score += t.VehicleValue(vehicle)
 	}
 	return score
 }
 
+func (t *travelDurationObjectiveImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	return vehicle.Last().CumulativeTravelDurationValue()
+}
+
 func (t *travelDurationObjectiveImpl) String() string {
 	return "travel_duration"
 }
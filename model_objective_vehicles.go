@@ -46,17 +46,22 @@ func (t *vehiclesObjectiveImpl) EstimateDeltaValue(move SolutionMoveStops) float
 func (t *vehiclesObjectiveImpl) Value(solution Solution) float64 {
 	vehicleCost := 0.0
 	for _, vehicle := range solution.(*solutionImpl).vehiclesMutable() {
-		if vehicle.NumberOfStops() > 0 {
-			vehicleCost += t.expression.Value(
-				vehicle.ModelVehicle().VehicleType(),
-				nil,
-				nil,
-			)
-		}
+		vehicleCost += t.VehicleValue(vehicle)
 	}
 	return vehicleCost
 }
 
+func (t *vehiclesObjectiveImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	if vehicle.NumberOfStops() == 0 {
+		return 0
+	}
+	return t.expression.Value(
+		vehicle.ModelVehicle().VehicleType(),
+		nil,
+		nil,
+	)
+}
+
 func (t *vehiclesObjectiveImpl) String() string {
 	return "vehicle_activation_penalty"
 }
@@ -115,11 +115,15 @@ func (t *vehiclesDurationObjectiveImpl) Value(
 	solutionImp := solution.(*solutionImpl)
 	score := 0.0
 	for _, r := range solutionImp.vehicles {
-		score += r.DurationValue()
+		score += t.VehicleValue(r)
 	}
 	return score
 }
 
+func (t *vehiclesDurationObjectiveImpl) VehicleValue(vehicle SolutionVehicle) float64 {
+	return vehicle.DurationValue()
+}
+
 func (t *vehiclesDurationObjectiveImpl) String() string {
 	return "vehicles_duration"
 }
@@ -3,7 +3,9 @@
 package nextroute
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 
 	"github.com/nextmv-io/nextroute/common"
 	"gonum.org/v1/gonum/spatial/kdtree"
@@ -14,11 +16,12 @@ type ModelStopsDistanceQueries interface {
 	// ModelStops returns the original set of stops that the distance queries
 	// were created from.
 	ModelStops() ModelStops
-	// NearestStops returns the n nearest stops to the given stop, the stop must
-	// be present in the original set of stops.
+	// NearestStops returns the n nearest stops to the given stop ordered by
+	// distance, the stop must be present in the original set of stops.
 	NearestStops(stop ModelStop, n int) (ModelStops, error)
 	// WithinDistanceStops returns the stops within the given distance of the
-	// given stop, the stop must be present in the original set of stops.
+	// given stop ordered by distance, the stop must be present in the original
+	// set of stops.
 	WithinDistanceStops(
 		stop ModelStop,
 		distance common.Distance,
@@ -71,6 +74,7 @@ func (m modelStopsDistanceQueryImpl) WithinDistanceStops(
 	km := distance.Value(common.Kilometers)
 	keep := kdtree.NewDistKeeper(km * km)
 	m.tree.NearestSet(keep, modelStopWrapper{stop: stop})
+	sortByDistance(keep.Heap)
 	stops := make(ModelStops, 0)
 	for _, c := range keep.Heap {
 		s := c.Comparable.(modelStopWrapper).stop
@@ -106,6 +110,7 @@ func (m modelStopsDistanceQueryImpl) NearestStops(
 	}
 	keep := kdtree.NewNKeeper(n + 1)
 	m.tree.NearestSet(keep, modelStopWrapper{stop: stop})
+	sortByDistance(keep.Heap)
 	stops := make(ModelStops, 0, n)
 	for _, c := range keep.Heap {
 		s := c.Comparable.(modelStopWrapper).stop
@@ -117,6 +122,21 @@ func (m modelStopsDistanceQueryImpl) NearestStops(
 	return stops, nil
 }
 
+// sortByDistance sorts the stops found by a query by distance and index. The
+// order of the heap of a query depends on the shape of the tree, which is
+// partitioned using random pivots.
+func sortByDistance(found kdtree.Heap) {
+	slices.SortFunc(found, func(a, b kdtree.ComparableDist) int {
+		if a.Dist != b.Dist {
+			return cmp.Compare(a.Dist, b.Dist)
+		}
+		return cmp.Compare(
+			a.Comparable.(modelStopWrapper).stop.Index(),
+			b.Comparable.(modelStopWrapper).stop.Index(),
+		)
+	})
+}
+
 type modelStopWrapper struct {
 	stop ModelStop
 }
@@ -3,6 +3,7 @@
 package nextroute_test
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"testing"
@@ -440,6 +441,45 @@ func singleVehiclePlanSequenceModel() Input {
 	)
 }
 
+// stopsAt returns count single stops named s0, s1, ... at the locations
+// returned by location for the index of the stop.
+func stopsAt(count int, location func(idx int) Location) []PlanSingleStop {
+	stops := make([]PlanSingleStop, count)
+	for idx := range stops {
+		stops[idx] = PlanSingleStop{
+			Stop: Stop{
+				Name:     fmt.Sprintf("s%d", idx),
+				Location: location(idx),
+			},
+		}
+	}
+	return stops
+}
+
+// planRoutes plans the stops of the model with the indices of each route at
+// the end of the vehicle with the index of the route, in the order of the
+// route.
+func planRoutes(t *testing.T, solution nextroute.Solution, routes [][]int) {
+	t.Helper()
+	for vehicleIndex, indices := range routes {
+		vehicle := solution.Vehicles()[vehicleIndex]
+		for _, index := range indices {
+			stop := solution.SolutionStop(solution.Model().Stops()[index])
+			position, err := nextroute.NewStopPosition(vehicle.Last().Previous(), stop, vehicle.Last())
+			if err != nil {
+				t.Fatal(err)
+			}
+			move, err := nextroute.NewMoveStops(stop.PlanStopsUnit(), nextroute.StopPositions{position})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if planned, err := move.Execute(context.Background()); err != nil || !planned {
+				t.Fatalf("planning stop %v: %v", index, err)
+			}
+		}
+	}
+}
+
 func createInput(
 	seed int64,
 	planSingleStop []PlanSingleStop,
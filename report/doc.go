@@ -0,0 +1,21 @@
+// © 2019-present nextmv.io inc
+
+/*
+Package report renders a solution as a self-contained HTML page to share
+plans with planners. The page has no external tiles, styles or scripts, it
+can be opened offline or attached to an email.
+
+The report shows the routes and stops on an SVG map using an equirectangular
+projection of their locations, a Gantt chart per vehicle with the travel,
+waiting and service time of each stop, the objective breakdown and the
+unplanned stops. It is rendered from the same data as the JSON output, a
+[schema.SolutionOutput]:
+
+	file, err := os.Create("report.html")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = report.WriteHTML(file, factory.ToSolutionOutput(solution))
+*/
+package report
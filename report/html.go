@@ -0,0 +1,331 @@
+// © 2019-present nextmv.io inc
+
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"time"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+//go:embed report.html.tmpl
+var reportTemplate string
+
+var page = template.Must(template.New("report").Parse(reportTemplate))
+
+const (
+	mapWidth     = 800.0
+	mapHeight    = 600.0
+	mapMargin    = 20.0
+	ganttWidth   = 800.0
+	ganttLabel   = 120.0
+	ganttRow     = 24.0
+	ganttAxis    = 20.0
+	maximumTicks = 10
+)
+
+// tickSteps are the candidate distances in seconds between the ticks of the
+// time axis of the Gantt chart.
+var tickSteps = []int{
+	60, 300, 600, 900, 1800, 3600, 7200, 14400, 21600, 43200, 86400,
+}
+
+type view struct {
+	Title      string
+	Vehicles   int
+	Used       int
+	Planned    int
+	Objective  float64
+	Map        mapView
+	Gantt      ganttView
+	Objectives []schema.ObjectiveOutput
+	Unplanned  []schema.StopOutput
+}
+
+type mapView struct {
+	Width     float64
+	Height    float64
+	Routes    []mapRoute
+	Stops     []mapPoint
+	Unplanned []mapPoint
+}
+
+type mapRoute struct {
+	VehicleID string
+	Color     string
+	Points    string
+}
+
+type mapPoint struct {
+	X     float64
+	Y     float64
+	Color string
+	Label string
+}
+
+type ganttView struct {
+	Width  float64
+	Height float64
+	Rows   []ganttRowView
+	Ticks  []ganttTick
+}
+
+type ganttRowView struct {
+	VehicleID string
+	Y         float64
+	Bars      []ganttBar
+}
+
+type ganttBar struct {
+	X     float64
+	Y     float64
+	Width float64
+	Class string
+	Title string
+}
+
+type ganttTick struct {
+	X     float64
+	Label string
+}
+
+// WriteHTML writes a self-contained HTML report of the solution with an SVG
+// map of the routes and stops, a Gantt chart of the vehicles, the objective
+// breakdown and the unplanned stops.
+func WriteHTML(writer io.Writer, solution schema.SolutionOutput) error {
+	v := view{
+		Title:      "Solution report",
+		Vehicles:   len(solution.Vehicles),
+		Objective:  solution.Objective.Value,
+		Objectives: solution.Objective.Objectives,
+		Unplanned:  solution.Unplanned,
+	}
+	for _, vehicle := range solution.Vehicles {
+		if isUsed(vehicle) {
+			v.Used++
+		}
+		for _, stop := range vehicle.Route {
+			if !isVehicleStop(vehicle, stop) {
+				v.Planned++
+			}
+		}
+	}
+	v.Map = newMapView(solution)
+	v.Gantt = newGanttView(solution)
+	return page.Execute(writer, v)
+}
+
+// isUsed returns true if the vehicle moves or serves a stop.
+func isUsed(vehicle schema.VehicleOutput) bool {
+	return vehicle.RouteDuration > 0
+}
+
+// isVehicleStop returns true if the stop is the start or end of the vehicle,
+// which the factory names after the vehicle.
+func isVehicleStop(vehicle schema.VehicleOutput, stop schema.PlannedStopOutput) bool {
+	return stop.Stop.ID == vehicle.ID+"-start" || stop.Stop.ID == vehicle.ID+"-end"
+}
+
+// color returns a distinct color for the vehicle with the given index.
+func color(index int) string {
+	return fmt.Sprintf("hsl(%.0f, 65%%, 45%%)", math.Mod(float64(index)*137.508, 360))
+}
+
+func newMapView(solution schema.SolutionOutput) mapView {
+	var locations []schema.Location
+	for _, vehicle := range solution.Vehicles {
+		for _, stop := range vehicle.Route {
+			locations = append(locations, stop.Stop.Location)
+		}
+	}
+	for _, stop := range solution.Unplanned {
+		locations = append(locations, stop.Location)
+	}
+	view := mapView{Width: mapWidth, Height: mapHeight}
+	if len(locations) == 0 {
+		return view
+	}
+
+	minLon, maxLon := math.Inf(1), math.Inf(-1)
+	minLat, maxLat := math.Inf(1), math.Inf(-1)
+	for _, location := range locations {
+		minLon, maxLon = math.Min(minLon, location.Lon), math.Max(maxLon, location.Lon)
+		minLat, maxLat = math.Min(minLat, location.Lat), math.Max(maxLat, location.Lat)
+	}
+	// An equirectangular projection around the center of the locations.
+	aspect := math.Cos((minLat + maxLat) / 2 * math.Pi / 180)
+	width := (maxLon - minLon) * aspect
+	height := maxLat - minLat
+	scale := 1.0
+	if width > 0 || height > 0 {
+		scale = math.Min(
+			(mapWidth-2*mapMargin)/math.Max(width, 1e-12),
+			(mapHeight-2*mapMargin)/math.Max(height, 1e-12),
+		)
+	}
+	view.Height = math.Max(height*scale+2*mapMargin, 2*mapMargin)
+	project := func(location schema.Location) (float64, float64) {
+		return mapMargin + (location.Lon-minLon)*aspect*scale,
+			view.Height - mapMargin - (location.Lat-minLat)*scale
+	}
+
+	for idx, vehicle := range solution.Vehicles {
+		c := color(idx)
+		points := ""
+		for s, stop := range vehicle.Route {
+			x, y := project(stop.Stop.Location)
+			if s > 0 {
+				points += " "
+			}
+			points += fmt.Sprintf("%.1f,%.1f", x, y)
+			view.Stops = append(view.Stops, mapPoint{
+				X:     x,
+				Y:     y,
+				Color: c,
+				Label: fmt.Sprintf("%s (%s, %d)", stop.Stop.ID, vehicle.ID, s),
+			})
+		}
+		if isUsed(vehicle) {
+			view.Routes = append(view.Routes, mapRoute{
+				VehicleID: vehicle.ID,
+				Color:     c,
+				Points:    points,
+			})
+		}
+	}
+	for _, stop := range solution.Unplanned {
+		x, y := project(stop.Location)
+		view.Unplanned = append(view.Unplanned, mapPoint{
+			X:     x,
+			Y:     y,
+			Label: stop.ID + " (unplanned)",
+		})
+	}
+	return view
+}
+
+// segment is a part of the timeline of a vehicle in seconds after the
+// origin of the chart.
+type segment struct {
+	from  float64
+	to    float64
+	class string
+	title string
+}
+
+func newGanttView(solution schema.SolutionOutput) ganttView {
+	// The timeline of a stop is travel, waiting and service. Arrival times
+	// are only part of the output if the vehicles have a start time,
+	// otherwise the timeline of each vehicle starts at zero.
+	var origin *time.Time
+	for _, vehicle := range solution.Vehicles {
+		for _, stop := range vehicle.Route {
+			if stop.ArrivalTime == nil {
+				continue
+			}
+			departure := stop.ArrivalTime.Add(
+				-time.Duration(stop.TravelDuration) * time.Second,
+			)
+			if origin == nil || departure.Before(*origin) {
+				origin = &departure
+			}
+		}
+	}
+
+	var rows [][]segment
+	var vehicles []string
+	end := 0.0
+	for _, vehicle := range solution.Vehicles {
+		if !isUsed(vehicle) {
+			continue
+		}
+		var segments []segment
+		arrival := 0.0
+		for _, stop := range vehicle.Route {
+			if origin != nil && stop.ArrivalTime != nil {
+				arrival = stop.ArrivalTime.Sub(*origin).Seconds()
+			} else {
+				arrival += float64(stop.TravelDuration)
+			}
+			start := arrival + float64(stop.WaitingDuration)
+			departure := start + float64(stop.Duration)
+			segments = append(
+				segments,
+				segment{
+					from:  arrival - float64(stop.TravelDuration),
+					to:    arrival,
+					class: "travel",
+					title: fmt.Sprintf("travel to %s: %ds", stop.Stop.ID, stop.TravelDuration),
+				},
+				segment{
+					from:  arrival,
+					to:    start,
+					class: "waiting",
+					title: fmt.Sprintf("waiting at %s: %ds", stop.Stop.ID, stop.WaitingDuration),
+				},
+				segment{
+					from:  start,
+					to:    departure,
+					class: "service",
+					title: fmt.Sprintf("service at %s: %ds", stop.Stop.ID, stop.Duration),
+				},
+			)
+			end = math.Max(end, departure)
+			arrival = departure
+		}
+		rows = append(rows, segments)
+		vehicles = append(vehicles, vehicle.ID)
+	}
+
+	view := ganttView{
+		Width:  ganttWidth,
+		Height: ganttAxis + float64(len(rows))*ganttRow,
+	}
+	scale := 0.0
+	if end > 0 {
+		scale = (ganttWidth - ganttLabel) / end
+	}
+	for idx, segments := range rows {
+		row := ganttRowView{
+			VehicleID: vehicles[idx],
+			Y:         ganttAxis + float64(idx)*ganttRow,
+		}
+		for _, s := range segments {
+			if s.to <= s.from {
+				continue
+			}
+			row.Bars = append(row.Bars, ganttBar{
+				X:     ganttLabel + s.from*scale,
+				Y:     row.Y + 3,
+				Width: (s.to - s.from) * scale,
+				Class: s.class,
+				Title: s.title,
+			})
+		}
+		view.Rows = append(view.Rows, row)
+	}
+
+	step := tickSteps[len(tickSteps)-1]
+	for _, candidate := range tickSteps {
+		if end/float64(candidate) <= maximumTicks {
+			step = candidate
+			break
+		}
+	}
+	for offset := 0; float64(offset) <= end && end > 0; offset += step {
+		label := fmt.Sprintf("+%d:%02d", offset/3600, offset%3600/60)
+		if origin != nil {
+			label = origin.Add(time.Duration(offset) * time.Second).Format("15:04")
+		}
+		view.Ticks = append(view.Ticks, ganttTick{
+			X:     ganttLabel + float64(offset)*scale,
+			Label: label,
+		})
+	}
+	return view
+}
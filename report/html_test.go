@@ -0,0 +1,99 @@
+// © 2019-present nextmv.io inc
+
+package report_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute/report"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+func TestWriteHTML(t *testing.T) {
+	start := time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	arrival := start.Add(10 * time.Minute)
+	begin := arrival.Add(5 * time.Minute)
+	end := begin.Add(20 * time.Minute)
+	solution := schema.SolutionOutput{
+		Vehicles: []schema.VehicleOutput{
+			{
+				ID: "truck",
+				Route: []schema.PlannedStopOutput{
+					{
+						Stop: schema.StopOutput{
+							ID:       "truck-start",
+							Location: schema.Location{Lon: 7.60, Lat: 51.95},
+						},
+						ArrivalTime: &start,
+						StartTime:   &start,
+						EndTime:     &start,
+					},
+					{
+						Stop: schema.StopOutput{
+							ID:       "<s1>",
+							Location: schema.Location{Lon: 7.62, Lat: 51.96},
+						},
+						TravelDuration:  600,
+						ArrivalTime:     &arrival,
+						WaitingDuration: 300,
+						StartTime:       &begin,
+						Duration:        1200,
+						EndTime:         &end,
+					},
+					{
+						Stop: schema.StopOutput{
+							ID:       "truck-end",
+							Location: schema.Location{Lon: 7.60, Lat: 51.95},
+						},
+						ArrivalTime: &end,
+						StartTime:   &end,
+						EndTime:     &end,
+					},
+				},
+				RouteDuration: 2100,
+			},
+			{ID: "idle"},
+		},
+		Unplanned: []schema.StopOutput{
+			{ID: "s2", Location: schema.Location{Lon: 7.63, Lat: 51.97}},
+		},
+		Objective: schema.ObjectiveOutput{
+			Name: "1 * travel_duration",
+			Objectives: []schema.ObjectiveOutput{
+				{Name: "travel_duration", Factor: 1, Base: 600, Value: 600},
+			},
+			Value: 600,
+		},
+	}
+
+	var buffer bytes.Buffer
+	if err := report.WriteHTML(&buffer, solution); err != nil {
+		t.Fatal(err)
+	}
+	html := buffer.String()
+
+	for _, expected := range []string{
+		"<polyline",
+		`class="travel"`,
+		`class="waiting"`,
+		`class="service"`,
+		`class="unplanned"`,
+		"travel_duration",
+		"1 of 2",
+		"09:00",
+		"&lt;s1&gt;",
+		`<tr><th>Planned stops</th><td class="number">1</td></tr>`,
+	} {
+		if !strings.Contains(html, expected) {
+			t.Errorf("expected report to contain %s", expected)
+		}
+	}
+	for _, external := range []string{"<script", "<link", "src=", "<s1>"} {
+		if strings.Contains(html, external) {
+			t.Errorf("expected report not to contain %s", external)
+		}
+	}
+}
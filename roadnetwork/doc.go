@@ -0,0 +1,17 @@
+// © 2019-present nextmv.io inc
+
+/*
+Package roadnetwork provides an offline road network that can be used to
+compute duration and distance matrices without access to a routing engine.
+
+A road network is a directed graph of nodes (locations) connected by edges
+with a distance and a speed. It can be loaded from a local file, either a CSV
+edge list or a GeoJSON FeatureCollection of LineStrings. Locations are snapped
+to the closest node of the network and the matrices are computed using a
+many-to-many Dijkstra search minimizing the travel duration.
+
+The resulting [Matrices] can be used directly as the duration and distance
+matrices of a [schema.Input] or be turned into expressions using
+[Matrices.DurationExpression] and [Matrices.DistanceExpression].
+*/
+package roadnetwork
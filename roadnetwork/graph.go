@@ -0,0 +1,158 @@
+// © 2019-present nextmv.io inc
+
+package roadnetwork
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/nextmv-io/nextroute/common"
+)
+
+// Graph is a directed road network. Nodes are identified by their location,
+// adding an edge between two locations adds the nodes if they are not yet
+// part of the graph. A graph is not safe for concurrent modification.
+type Graph interface {
+	// AddEdge adds a directed edge from one location to another location. The
+	// distance is the length of the edge and the speed is the speed at which
+	// the edge is traversed. Returns an error if one of the locations is
+	// invalid or the speed is not positive.
+	AddEdge(
+		from common.Location,
+		to common.Location,
+		distance common.Distance,
+		speed common.Speed,
+	) error
+
+	// Matrices computes the duration and distance matrices between the given
+	// locations. Each location is snapped to the closest node of the graph,
+	// the leg between the location and the node is travelled at the given
+	// access speed. Invalid locations result in a row and column of zeros.
+	// Returns an error if a location can not be reached from another
+	// location.
+	Matrices(
+		locations common.Locations,
+		accessSpeed common.Speed,
+	) (Matrices, error)
+
+	// NumberOfEdges returns the number of directed edges in the graph.
+	NumberOfEdges() int
+	// NumberOfNodes returns the number of nodes in the graph.
+	NumberOfNodes() int
+
+	// Snap returns the index of the node closest to the given location and
+	// the distance to that node. Only nodes that can be entered and left are
+	// considered. Returns an error if the location is invalid or the graph
+	// has no such nodes.
+	Snap(location common.Location) (int, common.Distance, error)
+}
+
+// NewGraph creates a new empty road network.
+func NewGraph() Graph {
+	return &graphImpl{
+		nodeIndices: make(map[common.Location]int),
+	}
+}
+
+type edge struct {
+	to       int
+	distance float64
+	duration float64
+}
+
+type graphImpl struct {
+	nodeIndices map[common.Location]int
+	index       *gridIndex
+	nodes       common.Locations
+	outgoing    [][]edge
+	hasIncoming []bool
+	edges       int
+}
+
+func (g *graphImpl) NumberOfEdges() int {
+	return g.edges
+}
+
+func (g *graphImpl) NumberOfNodes() int {
+	return len(g.nodes)
+}
+
+func (g *graphImpl) node(location common.Location) int {
+	if index, ok := g.nodeIndices[location]; ok {
+		return index
+	}
+	index := len(g.nodes)
+	g.nodeIndices[location] = index
+	g.nodes = append(g.nodes, location)
+	g.outgoing = append(g.outgoing, nil)
+	g.hasIncoming = append(g.hasIncoming, false)
+	return index
+}
+
+func (g *graphImpl) AddEdge(
+	from common.Location,
+	to common.Location,
+	distance common.Distance,
+	speed common.Speed,
+) error {
+	if !from.IsValid() || !to.IsValid() {
+		return fmt.Errorf(
+			"edge from %v to %v has an invalid location",
+			from,
+			to,
+		)
+	}
+	metersPerSecond := speed.Value(common.MetersPerSecond)
+	if metersPerSecond <= 0 || math.IsNaN(metersPerSecond) {
+		return fmt.Errorf(
+			"edge from %v to %v has a non-positive speed of %v meters per second",
+			from,
+			to,
+			metersPerSecond,
+		)
+	}
+	meters := distance.Value(common.Meters)
+	if meters < 0 || math.IsNaN(meters) {
+		return fmt.Errorf(
+			"edge from %v to %v has a negative distance %v",
+			from,
+			to,
+			meters,
+		)
+	}
+
+	fromIndex := g.node(from)
+	toIndex := g.node(to)
+
+	g.outgoing[fromIndex] = append(g.outgoing[fromIndex], edge{
+		to:       toIndex,
+		distance: meters,
+		duration: meters / metersPerSecond,
+	})
+	g.hasIncoming[toIndex] = true
+	g.edges++
+	g.index = nil
+
+	return nil
+}
+
+func (g *graphImpl) Snap(location common.Location) (int, common.Distance, error) {
+	if !location.IsValid() {
+		return -1, common.Distance{}, fmt.Errorf(
+			"can not snap invalid location %v",
+			location,
+		)
+	}
+	if g.index == nil {
+		g.index = newGridIndex(g.nodes, func(node int) bool {
+			return g.hasIncoming[node] && len(g.outgoing[node]) > 0
+		})
+	}
+	node, meters := g.index.nearest(location)
+	if node < 0 {
+		return -1, common.Distance{}, fmt.Errorf(
+			"road network has no nodes that can be entered and left",
+		)
+	}
+	return node, common.NewDistance(meters, common.Meters), nil
+}
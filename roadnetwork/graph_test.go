@@ -0,0 +1,175 @@
+// © 2019-present nextmv.io inc
+
+package roadnetwork_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/nextroute/roadnetwork"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// network is a small road network: a two-way road from a to b to c and a
+// one-way shortcut from a to c that is slower than the detour.
+const network = `from_lon,from_lat,to_lon,to_lat,speed,distance,oneway
+0.00,0.00,0.01,0.00,10,1000,false
+0.01,0.00,0.02,0.00,10,1000,false
+0.00,0.00,0.02,0.00,1,1500,true
+`
+
+func location(t *testing.T, lon, lat float64) common.Location {
+	t.Helper()
+	l, err := common.NewLocation(lon, lat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestReadCSV(t *testing.T) {
+	graph, err := roadnetwork.ReadCSV(strings.NewReader(network))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if graph.NumberOfNodes() != 3 {
+		t.Errorf("expected 3 nodes, got %v", graph.NumberOfNodes())
+	}
+	if graph.NumberOfEdges() != 5 {
+		t.Errorf("expected 5 edges, got %v", graph.NumberOfEdges())
+	}
+
+	_, err = roadnetwork.ReadCSV(strings.NewReader(
+		"from_lon,from_lat,to_lon,to_lat,speed\n0,0,1,x,10\n",
+	))
+	if err == nil || !strings.Contains(err.Error(), "row 2 column `to_lat`") {
+		t.Errorf("expected error on row 2 column `to_lat`, got %v", err)
+	}
+}
+
+func TestMatrices(t *testing.T) {
+	graph, err := roadnetwork.ReadCSV(strings.NewReader(network))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locations := common.Locations{
+		location(t, 0.00, 0.00),
+		location(t, 0.02, 0.00),
+		common.NewInvalidLocation(),
+	}
+	matrices, err := graph.Matrices(
+		locations,
+		common.NewSpeed(5, common.MetersPerSecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]float64{{0, 200, 0}, {200, 0, 0}, {0, 0, 0}}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(matrices.Duration[i][j]-expected[i][j]) > 1e-6 {
+				t.Errorf(
+					"expected duration %v from %v to %v, got %v",
+					expected[i][j],
+					i,
+					j,
+					matrices.Duration[i][j],
+				)
+			}
+		}
+	}
+	if math.Abs(matrices.Distance[0][1]-2000) > 1e-6 {
+		t.Errorf("expected distance 2000, got %v", matrices.Distance[0][1])
+	}
+}
+
+func TestMatricesAccess(t *testing.T) {
+	graph, err := roadnetwork.ReadCSV(strings.NewReader(network))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	off := location(t, 0.00, 0.001)
+	node, distance, err := graph.Snap(off)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node != 0 {
+		t.Errorf("expected location to snap to node 0, got %v", node)
+	}
+
+	matrices, err := graph.Matrices(
+		common.Locations{off, location(t, 0.01, 0.00)},
+		common.NewSpeed(5, common.MetersPerSecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 100 + distance.Value(common.Meters)/5
+	if math.Abs(matrices.Duration[0][1]-expected) > 1e-6 {
+		t.Errorf("expected duration %v, got %v", expected, matrices.Duration[0][1])
+	}
+}
+
+func TestMatricesUnreachable(t *testing.T) {
+	graph := roadnetwork.NewGraph()
+	speed := common.NewSpeed(10, common.MetersPerSecond)
+	distance := common.NewDistance(1000, common.Meters)
+	a, b := location(t, 0, 0), location(t, 0.01, 0)
+	c, d := location(t, 1, 1), location(t, 1.01, 1)
+	for _, e := range [][2]common.Location{{a, b}, {b, a}, {c, d}, {d, c}} {
+		if err := graph.AddEdge(e[0], e[1], distance, speed); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := graph.Matrices(common.Locations{a, c}, speed)
+	if err == nil {
+		t.Error("expected an error for unreachable locations")
+	}
+}
+
+func TestSetMatrices(t *testing.T) {
+	graph, err := roadnetwork.ReadCSV(strings.NewReader(network))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := schema.Input{
+		Defaults: &schema.Defaults{
+			Vehicles: &schema.VehicleDefaults{
+				StartLocation: &schema.Location{Lon: 0, Lat: 0},
+			},
+		},
+		Stops: []schema.Stop{
+			{ID: "s1", Location: schema.Location{Lon: 0.02, Lat: 0}},
+		},
+		Vehicles: []schema.Vehicle{{ID: "v1"}},
+	}
+
+	input, err = roadnetwork.SetMatrices(
+		input,
+		graph,
+		common.NewSpeed(5, common.MetersPerSecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Vehicles[0].StartLocation != nil {
+		t.Error("expected the input vehicles not to be modified")
+	}
+	duration := *input.DurationMatrix
+	if len(duration) != 3 {
+		t.Fatalf("expected a matrix of size 3, got %v", len(duration))
+	}
+	if math.Abs(duration[1][0]-200) > 1e-6 {
+		t.Errorf("expected duration 200 from start to stop, got %v", duration[1][0])
+	}
+	if duration[2][0] != 0 || duration[0][2] != 0 {
+		t.Error("expected zero duration to and from a missing end location")
+	}
+}
@@ -0,0 +1,141 @@
+// © 2019-present nextmv.io inc
+
+package roadnetwork
+
+import (
+	"math"
+
+	"github.com/nextmv-io/nextroute/common"
+)
+
+// cellSize is the size of a grid cell in degrees, roughly a kilometer at the
+// equator.
+const cellSize = 0.01
+
+// metersPerDegree is the length of a degree of latitude in meters.
+const metersPerDegree = 111_320.0
+
+type cell struct {
+	x int
+	y int
+}
+
+// gridIndex is a uniform grid over the nodes of a graph used to find the
+// closest node of a location without visiting all nodes.
+type gridIndex struct {
+	nodes common.Locations
+	cells map[cell][]int
+	minX  int
+	maxX  int
+	minY  int
+	maxY  int
+}
+
+func toCell(location common.Location) cell {
+	return cell{
+		x: int(math.Floor(location.Longitude() / cellSize)),
+		y: int(math.Floor(location.Latitude() / cellSize)),
+	}
+}
+
+// newGridIndex creates a grid index of the nodes for which include returns
+// true.
+func newGridIndex(nodes common.Locations, include func(int) bool) *gridIndex {
+	index := &gridIndex{
+		nodes: nodes,
+		cells: make(map[cell][]int),
+		minX:  math.MaxInt,
+		maxX:  math.MinInt,
+		minY:  math.MaxInt,
+		maxY:  math.MinInt,
+	}
+	for node, location := range nodes {
+		if !include(node) {
+			continue
+		}
+		c := toCell(location)
+		index.cells[c] = append(index.cells[c], node)
+		index.minX = min(index.minX, c.x)
+		index.maxX = max(index.maxX, c.x)
+		index.minY = min(index.minY, c.y)
+		index.maxY = max(index.maxY, c.y)
+	}
+	return index
+}
+
+// nearest returns the node closest to the location and the distance in
+// meters. Returns -1 if the index is empty. The cells are searched in rings
+// around the cell of the location until no closer node can exist.
+func (g *gridIndex) nearest(location common.Location) (int, float64) {
+	if len(g.cells) == 0 {
+		return -1, math.Inf(1)
+	}
+
+	center := toCell(location)
+	best := -1
+	bestMeters := math.Inf(1)
+
+	// A degree of longitude is shorter than a degree of latitude, use it as a
+	// lower bound for the distance covered by a ring of cells.
+	cosine := math.Max(math.Cos(location.Latitude()*math.Pi/180.0), 0.01)
+	ringMeters := cellSize * metersPerDegree * cosine
+
+	maxRing := max(
+		abs(center.x-g.minX),
+		abs(center.x-g.maxX),
+		abs(center.y-g.minY),
+		abs(center.y-g.maxY),
+	)
+
+	for ring := 0; ring <= maxRing; ring++ {
+		if best >= 0 && float64(ring-1)*ringMeters > bestMeters {
+			break
+		}
+		for _, c := range ringCells(center, ring) {
+			for _, node := range g.cells[c] {
+				distance, err := common.Haversine(location, g.nodes[node])
+				if err != nil {
+					continue
+				}
+				meters := distance.Value(common.Meters)
+				if meters < bestMeters {
+					best = node
+					bestMeters = meters
+				}
+			}
+		}
+	}
+
+	return best, bestMeters
+}
+
+// ringCells returns the cells on the border of the square of cells with the
+// given ring distance around the center.
+func ringCells(center cell, ring int) []cell {
+	if ring == 0 {
+		return []cell{center}
+	}
+	cells := make([]cell, 0, 8*ring)
+	for x := center.x - ring; x <= center.x+ring; x++ {
+		cells = append(
+			cells,
+			cell{x: x, y: center.y - ring},
+			cell{x: x, y: center.y + ring},
+		)
+	}
+	for y := center.y - ring + 1; y < center.y+ring; y++ {
+		cells = append(
+			cells,
+			cell{x: center.x - ring, y: y},
+			cell{x: center.x + ring, y: y},
+		)
+	}
+	return cells
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,59 @@
+// © 2019-present nextmv.io inc
+
+package roadnetwork
+
+import (
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+// Options configure the use of a road network to create the matrices of an
+// input.
+type Options struct {
+	// Path is the path to the road network file, see [Load]. If empty, no
+	// road network is used.
+	Path string `json:"path" usage:"path to a road network file (.csv, .json or .geojson) used to compute missing matrices"`
+	// AccessSpeed is the speed in meters per second used to travel between a
+	// location and the closest node of the road network.
+	AccessSpeed float64 `json:"access_speed" usage:"speed in meters per second between a location and the road network" default:"5"`
+}
+
+// NewMatrixProvider returns a [factory.MatrixProvider] that computes the
+// matrices on the graph using the given access speed, see [Graph.Matrices].
+func NewMatrixProvider(
+	graph Graph,
+	accessSpeed common.Speed,
+) factory.MatrixProvider {
+	return &matrixProviderImpl{
+		graph:       graph,
+		accessSpeed: accessSpeed,
+	}
+}
+
+type matrixProviderImpl struct {
+	graph       Graph
+	accessSpeed common.Speed
+}
+
+func (p *matrixProviderImpl) Matrices(
+	locations common.Locations,
+) ([][]float64, [][]float64, error) {
+	matrices, err := p.graph.Matrices(locations, p.accessSpeed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return matrices.Duration, matrices.Distance, nil
+}
+
+// SetMatrices computes the duration and distance matrices of the input on
+// the road network and sets them on the input. Matrices already present on
+// the input are not replaced. The locations are indexed as described by
+// [factory.MatrixLocations].
+func SetMatrices(
+	input schema.Input,
+	graph Graph,
+	accessSpeed common.Speed,
+) (schema.Input, error) {
+	return factory.SetMatrices(input, NewMatrixProvider(graph, accessSpeed))
+}
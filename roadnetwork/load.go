@@ -0,0 +1,326 @@
+// © 2019-present nextmv.io inc
+
+package roadnetwork
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/nextmv-io/nextroute/common"
+)
+
+// Load loads a road network from a file. The format is determined by the
+// extension of the file: `.csv` for a CSV edge list, `.json` or `.geojson`
+// for a GeoJSON FeatureCollection.
+func Load(path string) (Graph, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ReadCSV(file)
+	case ".json", ".geojson":
+		return ReadGeoJSON(file)
+	}
+	return nil, fmt.Errorf(
+		"road network file %s has an unsupported extension, use .csv, .json or .geojson",
+		path,
+	)
+}
+
+// ReadCSV reads a road network from a CSV edge list. The first row is a
+// header, the columns are identified by name:
+//   - from_lon, from_lat: the location the edge starts at (required).
+//   - to_lon, to_lat: the location the edge ends at (required).
+//   - speed: the speed in meters per second on the edge (required).
+//   - distance: the length of the edge in meters (optional, defaults to the
+//     haversine distance between the two locations).
+//   - oneway: true if the edge can only be traversed from start to end
+//     (optional, defaults to false).
+func ReadCSV(reader io.Reader) (Graph, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading road network header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for idx, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = idx
+	}
+	for _, name := range []string{"from_lon", "from_lat", "to_lon", "to_lat", "speed"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("road network header is missing column `%s`", name)
+		}
+	}
+
+	graph := NewGraph()
+	for row := 2; ; row++ {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading road network row %v: %w", row, err)
+		}
+
+		value := func(name string) (float64, bool, error) {
+			column, ok := columns[name]
+			if !ok || strings.TrimSpace(record[column]) == "" {
+				return 0, false, nil
+			}
+			v, err := strconv.ParseFloat(strings.TrimSpace(record[column]), 64)
+			if err != nil {
+				return 0, false, fmt.Errorf(
+					"road network row %v column `%s`: %w",
+					row,
+					name,
+					err,
+				)
+			}
+			return v, true, nil
+		}
+
+		values := make(map[string]float64, 6)
+		for _, name := range []string{"from_lon", "from_lat", "to_lon", "to_lat", "speed", "distance"} {
+			v, ok, err := value(name)
+			if err != nil {
+				return nil, err
+			}
+			if !ok && name != "distance" {
+				return nil, fmt.Errorf(
+					"road network row %v column `%s` is empty",
+					row,
+					name,
+				)
+			}
+			if ok {
+				values[name] = v
+			}
+		}
+
+		oneWay := false
+		if column, ok := columns["oneway"]; ok && strings.TrimSpace(record[column]) != "" {
+			oneWay, err = parseBool(record[column])
+			if err != nil {
+				return nil, fmt.Errorf(
+					"road network row %v column `oneway`: %w",
+					row,
+					err,
+				)
+			}
+		}
+
+		from, err := common.NewLocation(values["from_lon"], values["from_lat"])
+		if err != nil {
+			return nil, fmt.Errorf("road network row %v: %w", row, err)
+		}
+		to, err := common.NewLocation(values["to_lon"], values["to_lat"])
+		if err != nil {
+			return nil, fmt.Errorf("road network row %v: %w", row, err)
+		}
+
+		var distance *float64
+		if d, ok := values["distance"]; ok {
+			distance = &d
+		}
+
+		if err := addRoad(
+			graph,
+			common.Locations{from, to},
+			values["speed"],
+			distance,
+			oneWay,
+		); err != nil {
+			return nil, fmt.Errorf("road network row %v: %w", row, err)
+		}
+	}
+
+	return graph, nil
+}
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Geometry   geometry          `json:"geometry"`
+	Properties featureProperties `json:"properties"`
+}
+
+type geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type featureProperties struct {
+	Speed    *float64 `json:"speed"`
+	Distance *float64 `json:"distance"`
+	OneWay   any      `json:"oneway"`
+}
+
+// ReadGeoJSON reads a road network from a GeoJSON FeatureCollection. Each
+// feature with a LineString or MultiLineString geometry is a road, each pair
+// of consecutive coordinates is an edge. Features with other geometries are
+// ignored. The properties of a road are:
+//   - speed: the speed in meters per second on the road (required).
+//   - distance: the length of the road in meters (optional, defaults to the
+//     haversine distance along the coordinates). It is distributed over the
+//     edges proportionally to their haversine distance.
+//   - oneway: true if the road can only be traversed in the order of the
+//     coordinates (optional, defaults to false).
+func ReadGeoJSON(reader io.Reader) (Graph, error) {
+	var collection featureCollection
+	if err := json.NewDecoder(reader).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("reading road network: %w", err)
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf(
+			"road network must be a FeatureCollection, it is `%s`",
+			collection.Type,
+		)
+	}
+
+	graph := NewGraph()
+	for idx, f := range collection.Features {
+		var lines [][][]float64
+		switch f.Geometry.Type {
+		case "LineString":
+			var line [][]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &line); err != nil {
+				return nil, fmt.Errorf("road network feature %v: %w", idx, err)
+			}
+			lines = append(lines, line)
+		case "MultiLineString":
+			if err := json.Unmarshal(f.Geometry.Coordinates, &lines); err != nil {
+				return nil, fmt.Errorf("road network feature %v: %w", idx, err)
+			}
+		default:
+			continue
+		}
+
+		if f.Properties.Speed == nil {
+			return nil, fmt.Errorf("road network feature %v has no speed", idx)
+		}
+
+		oneWay := false
+		switch v := f.Properties.OneWay.(type) {
+		case nil:
+		case bool:
+			oneWay = v
+		case string:
+			b, err := parseBool(v)
+			if err != nil {
+				return nil, fmt.Errorf("road network feature %v oneway: %w", idx, err)
+			}
+			oneWay = b
+		default:
+			return nil, fmt.Errorf(
+				"road network feature %v oneway must be a boolean, it is %v",
+				idx,
+				v,
+			)
+		}
+
+		for _, line := range lines {
+			locations := make(common.Locations, len(line))
+			for c, coordinate := range line {
+				if len(coordinate) < 2 {
+					return nil, fmt.Errorf(
+						"road network feature %v coordinate %v must have a longitude and latitude",
+						idx,
+						c,
+					)
+				}
+				location, err := common.NewLocation(coordinate[0], coordinate[1])
+				if err != nil {
+					return nil, fmt.Errorf("road network feature %v: %w", idx, err)
+				}
+				locations[c] = location
+			}
+			if err := addRoad(
+				graph,
+				locations,
+				*f.Properties.Speed,
+				f.Properties.Distance,
+				oneWay,
+			); err != nil {
+				return nil, fmt.Errorf("road network feature %v: %w", idx, err)
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// addRoad adds the edges between consecutive locations to the graph. If a
+// distance is given it is distributed proportionally to the haversine
+// distance of the edges.
+func addRoad(
+	graph Graph,
+	locations common.Locations,
+	speed float64,
+	distance *float64,
+	oneWay bool,
+) error {
+	if len(locations) < 2 {
+		return fmt.Errorf("a road needs at least two locations")
+	}
+
+	meters := make([]float64, len(locations)-1)
+	total := 0.0
+	for idx := 1; idx < len(locations); idx++ {
+		d, err := common.Haversine(locations[idx-1], locations[idx])
+		if err != nil {
+			return err
+		}
+		meters[idx-1] = d.Value(common.Meters)
+		total += meters[idx-1]
+	}
+	if distance != nil {
+		for idx := range meters {
+			if total > 0 {
+				meters[idx] *= *distance / total
+			} else {
+				meters[idx] = *distance / float64(len(meters))
+			}
+		}
+	}
+
+	s := common.NewSpeed(speed, common.MetersPerSecond)
+	for idx := 1; idx < len(locations); idx++ {
+		d := common.NewDistance(meters[idx-1], common.Meters)
+		if err := graph.AddEdge(locations[idx-1], locations[idx], d, s); err != nil {
+			return err
+		}
+		if !oneWay {
+			if err := graph.AddEdge(locations[idx], locations[idx-1], d, s); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func parseBool(value string) (bool, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "yes", "1":
+		return true, nil
+	case "false", "no", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("`%s` is not a boolean", value)
+}
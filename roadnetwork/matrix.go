@@ -0,0 +1,228 @@
+// © 2019-present nextmv.io inc
+
+package roadnetwork
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/sdk/measure"
+)
+
+// Matrices holds the duration and distance matrices computed on a road
+// network. The matrices are indexed in the order of the locations they have
+// been computed for.
+type Matrices struct {
+	// Duration is the travel duration in seconds between the locations.
+	Duration [][]float64
+	// Distance is the travel distance in meters between the locations.
+	Distance [][]float64
+}
+
+// DurationExpression returns the duration matrix as a
+// [nextroute.DurationExpression] using the measure index of the stops.
+func (m Matrices) DurationExpression() nextroute.DurationExpression {
+	return nextroute.NewDurationExpression(
+		"roadNetworkTravelDuration",
+		nextroute.NewMeasureByIndexExpression(measure.Matrix(m.Duration)),
+		common.Second,
+	)
+}
+
+// DistanceExpression returns the distance matrix as a
+// [nextroute.DistanceExpression] using the measure index of the stops.
+func (m Matrices) DistanceExpression() nextroute.DistanceExpression {
+	return nextroute.NewDistanceExpression(
+		"roadNetworkTravelDistance",
+		nextroute.NewMeasureByIndexExpression(measure.Matrix(m.Distance)),
+		common.Meters,
+	)
+}
+
+// snappedLocation is a location snapped to a node of the graph, the access
+// distance and duration are the cost of the leg between the location and the
+// node.
+type snappedLocation struct {
+	node           int
+	accessDistance float64
+	accessDuration float64
+}
+
+func (g *graphImpl) Matrices(
+	locations common.Locations,
+	accessSpeed common.Speed,
+) (Matrices, error) {
+	metersPerSecond := accessSpeed.Value(common.MetersPerSecond)
+	if metersPerSecond <= 0 || math.IsNaN(metersPerSecond) {
+		return Matrices{}, fmt.Errorf(
+			"access speed must be positive, it is %v meters per second",
+			metersPerSecond,
+		)
+	}
+
+	snapped := make([]snappedLocation, len(locations))
+	sources := make([]int, 0, len(locations))
+	sourceIndices := make(map[int]int, len(locations))
+	for idx, location := range locations {
+		if !location.IsValid() {
+			snapped[idx] = snappedLocation{node: -1}
+			continue
+		}
+		node, distance, err := g.Snap(location)
+		if err != nil {
+			return Matrices{}, err
+		}
+		meters := distance.Value(common.Meters)
+		snapped[idx] = snappedLocation{
+			node:           node,
+			accessDistance: meters,
+			accessDuration: meters / metersPerSecond,
+		}
+		if _, ok := sourceIndices[node]; !ok {
+			sourceIndices[node] = len(sources)
+			sources = append(sources, node)
+		}
+	}
+
+	targets := make([]bool, len(g.nodes))
+	for _, node := range sources {
+		targets[node] = true
+	}
+
+	trees := make([]shortestPaths, len(sources))
+	jobs := make(chan int)
+	var waitGroup sync.WaitGroup
+	for w := 0; w < runtime.NumCPU(); w++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			for source := range jobs {
+				trees[source] = g.dijkstra(sources[source], targets, len(sources))
+			}
+		}()
+	}
+	for source := range sources {
+		jobs <- source
+	}
+	close(jobs)
+	waitGroup.Wait()
+
+	matrices := Matrices{
+		Duration: make([][]float64, len(locations)),
+		Distance: make([][]float64, len(locations)),
+	}
+	for i, from := range snapped {
+		matrices.Duration[i] = make([]float64, len(locations))
+		matrices.Distance[i] = make([]float64, len(locations))
+		if from.node < 0 {
+			continue
+		}
+		tree := trees[sourceIndices[from.node]]
+		for j, to := range snapped {
+			if i == j || to.node < 0 {
+				continue
+			}
+			duration, ok := tree.duration[to.node]
+			if !ok {
+				return Matrices{}, fmt.Errorf(
+					"location %v at index %v can not be reached from location %v at index %v on the road network",
+					locations[j],
+					j,
+					locations[i],
+					i,
+				)
+			}
+			matrices.Duration[i][j] = from.accessDuration + duration + to.accessDuration
+			matrices.Distance[i][j] = from.accessDistance + tree.distance[to.node] + to.accessDistance
+		}
+	}
+
+	return matrices, nil
+}
+
+// shortestPaths holds the fastest duration and the distance along the
+// fastest path from a source node to the target nodes.
+type shortestPaths struct {
+	duration map[int]float64
+	distance map[int]float64
+}
+
+// dijkstra computes the fastest paths from the source to all the targets. The
+// search stops as soon as all targets are settled.
+func (g *graphImpl) dijkstra(
+	source int,
+	targets []bool,
+	numberOfTargets int,
+) shortestPaths {
+	result := shortestPaths{
+		duration: make(map[int]float64, numberOfTargets),
+		distance: make(map[int]float64, numberOfTargets),
+	}
+
+	duration := map[int]float64{source: 0}
+	distance := map[int]float64{source: 0}
+	settled := make(map[int]bool)
+
+	queue := &priorityQueue{{node: source, duration: 0}}
+	for queue.Len() > 0 && len(result.duration) < numberOfTargets {
+		item := heap.Pop(queue).(queueItem)
+		if settled[item.node] {
+			continue
+		}
+		settled[item.node] = true
+		if targets[item.node] {
+			result.duration[item.node] = duration[item.node]
+			result.distance[item.node] = distance[item.node]
+		}
+		for _, e := range g.outgoing[item.node] {
+			if settled[e.to] {
+				continue
+			}
+			candidate := item.duration + e.duration
+			if current, ok := duration[e.to]; ok && current <= candidate {
+				continue
+			}
+			duration[e.to] = candidate
+			distance[e.to] = distance[item.node] + e.distance
+			heap.Push(queue, queueItem{node: e.to, duration: candidate})
+		}
+	}
+
+	return result
+}
+
+type queueItem struct {
+	node     int
+	duration float64
+}
+
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int {
+	return len(q)
+}
+
+func (q priorityQueue) Less(i, j int) bool {
+	return q[i].duration < q[j].duration
+}
+
+func (q priorityQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+}
+
+func (q *priorityQueue) Push(x any) {
+	*q = append(*q, x.(queueItem))
+}
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
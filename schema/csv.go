@@ -0,0 +1,593 @@
+// © 2019-present nextmv.io inc
+
+package schema
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSV file names read by [LoadCSV].
+const (
+	StopsCSV    = "stops.csv"
+	VehiclesCSV = "vehicles.csv"
+)
+
+// CSVListSeparator separates the elements of a list in a CSV cell, for
+// example the compatibility attributes of a stop: `cold;fragile`.
+const CSVListSeparator = ";"
+
+// CSVError is returned when a CSV file can not be read. It identifies the
+// file, the row (the header is row 1) and the column of the error.
+type CSVError struct {
+	File   string
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e *CSVError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("%s row %v: %v", e.File, e.Row, e.Err)
+	}
+	return fmt.Sprintf("%s row %v column `%s`: %v", e.File, e.Row, e.Column, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+// LoadCSV reads the stops and vehicles from the files `stops.csv` and
+// `vehicles.csv` in the directory, see [ReadCSV].
+func LoadCSV(directory string) (Input, error) {
+	stops, err := os.Open(filepath.Join(directory, StopsCSV))
+	if err != nil {
+		return Input{}, err
+	}
+	defer stops.Close()
+
+	vehicles, err := os.Open(filepath.Join(directory, VehiclesCSV))
+	if err != nil {
+		return Input{}, err
+	}
+	defer vehicles.Close()
+
+	return ReadCSV(stops, vehicles)
+}
+
+// ReadCSV reads an input from CSV data of stops and vehicles. The first row
+// of each is a header naming the columns. Columns map to the fields of
+// [Stop] and [Vehicle] using their JSON names, empty cells are ignored.
+//
+// Stops have the columns id, lon, lat, duration, max_wait,
+// unplanned_penalty, early_arrival_time_penalty, late_arrival_time_penalty,
+// target_arrival_time, start_time_window, quantity, compatibility_attributes,
+// precedes and succeeds. Vehicles have the columns id, start_lon, start_lat,
+// end_lon, end_lat, start_time, end_time, speed, capacity, start_level,
+// compatibility_attributes, max_distance, max_duration, max_stops, max_wait,
+// min_stops, min_stops_penalty, activation_penalty, stop_duration_multiplier,
+// alternate_stops and initial_stops.
+//
+// Times use RFC3339. A start time window is given as `start/end`, multiple
+// windows are separated by `;`. Alternatively, a single window can be given
+// with the columns start_time_window_start and start_time_window_end.
+// Quantities, capacities and start levels of a named resource use the
+// columns quantity_<name>, capacity_<name> and start_level_<name>. Lists
+// (attributes, precedence, alternate and initial stops) are separated by `;`.
+func ReadCSV(stops io.Reader, vehicles io.Reader) (Input, error) {
+	input := Input{}
+
+	err := readCSV(StopsCSV, stops, stopColumns, func() *Stop {
+		input.Stops = append(input.Stops, Stop{})
+		return &input.Stops[len(input.Stops)-1]
+	})
+	if err != nil {
+		return Input{}, err
+	}
+
+	err = readCSV(VehiclesCSV, vehicles, vehicleColumns, func() *Vehicle {
+		input.Vehicles = append(input.Vehicles, Vehicle{})
+		return &input.Vehicles[len(input.Vehicles)-1]
+	})
+	if err != nil {
+		return Input{}, err
+	}
+
+	return input, nil
+}
+
+// csvSetter sets the value of a cell on an entity.
+type csvSetter[T any] func(entity *T, value string) error
+
+// csvColumns defines the columns of an entity. Fixed maps a column name to a
+// setter. Resource maps a prefix of a named resource column, such as
+// `quantity_`, to a setter taking the resource name. Finish validates an
+// entity after all cells of its row have been set, it returns the column of
+// an error.
+type csvColumns[T any] struct {
+	fixed    map[string]csvSetter[T]
+	resource map[string]func(entity *T, name string, value string) error
+	finish   func(entity *T) (string, error)
+	required []string
+}
+
+func readCSV[T any](
+	file string,
+	reader io.Reader,
+	columns csvColumns[T],
+	next func() *T,
+) error {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return &CSVError{File: file, Row: 1, Err: err}
+	}
+
+	setters := make([]func(entity *T, value string) error, len(header))
+	names := make([]string, len(header))
+	present := make(map[string]bool, len(header))
+	for idx, column := range header {
+		name := strings.ToLower(strings.TrimSpace(column))
+		if present[name] {
+			return &CSVError{
+				File:   file,
+				Row:    1,
+				Column: name,
+				Err:    errors.New("duplicate column"),
+			}
+		}
+		present[name] = true
+		names[idx] = name
+
+		if setter, ok := columns.fixed[name]; ok {
+			setters[idx] = setter
+			continue
+		}
+		for prefix, setter := range columns.resource {
+			if resource, ok := strings.CutPrefix(name, prefix); ok && resource != "" {
+				setters[idx] = func(entity *T, value string) error {
+					return setter(entity, resource, value)
+				}
+				break
+			}
+		}
+		if setters[idx] == nil {
+			return &CSVError{
+				File:   file,
+				Row:    1,
+				Column: name,
+				Err:    errors.New("unknown column"),
+			}
+		}
+	}
+	for _, name := range columns.required {
+		if !present[name] {
+			return &CSVError{
+				File:   file,
+				Row:    1,
+				Column: name,
+				Err:    errors.New("missing required column"),
+			}
+		}
+	}
+
+	for row := 2; ; row++ {
+		record, err := csvReader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return &CSVError{File: file, Row: row, Err: err}
+		}
+
+		entity := next()
+		for idx, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				if isRequired(columns.required, names[idx]) {
+					return &CSVError{
+						File:   file,
+						Row:    row,
+						Column: names[idx],
+						Err:    errors.New("value is required"),
+					}
+				}
+				continue
+			}
+			if err := setters[idx](entity, value); err != nil {
+				return &CSVError{
+					File:   file,
+					Row:    row,
+					Column: names[idx],
+					Err:    err,
+				}
+			}
+		}
+		if columns.finish != nil {
+			if column, err := columns.finish(entity); err != nil {
+				return &CSVError{
+					File:   file,
+					Row:    row,
+					Column: column,
+					Err:    err,
+				}
+			}
+		}
+	}
+}
+
+func isRequired(required []string, name string) bool {
+	for _, r := range required {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+var stopColumns = csvColumns[Stop]{
+	required: []string{"id", "lon", "lat"},
+	fixed: map[string]csvSetter[Stop]{
+		"id": func(s *Stop, v string) error {
+			s.ID = v
+			return nil
+		},
+		"lon": func(s *Stop, v string) error {
+			return parseFloatTo(&s.Location.Lon, v)
+		},
+		"lat": func(s *Stop, v string) error {
+			return parseFloatTo(&s.Location.Lat, v)
+		},
+		"duration": func(s *Stop, v string) error {
+			return parseIntPointer(&s.Duration, v)
+		},
+		"max_wait": func(s *Stop, v string) error {
+			return parseIntPointer(&s.MaxWait, v)
+		},
+		"unplanned_penalty": func(s *Stop, v string) error {
+			return parseIntPointer(&s.UnplannedPenalty, v)
+		},
+		"early_arrival_time_penalty": func(s *Stop, v string) error {
+			return parseFloatPointer(&s.EarlyArrivalTimePenalty, v)
+		},
+		"late_arrival_time_penalty": func(s *Stop, v string) error {
+			return parseFloatPointer(&s.LateArrivalTimePenalty, v)
+		},
+		"target_arrival_time": func(s *Stop, v string) error {
+			return parseTimePointer(&s.TargetArrivalTime, v)
+		},
+		"start_time_window": func(s *Stop, v string) error {
+			windows := []any{}
+			for _, window := range strings.Split(v, CSVListSeparator) {
+				start, end, ok := strings.Cut(strings.TrimSpace(window), "/")
+				if !ok {
+					return fmt.Errorf("time window `%s` is not of the form start/end", window)
+				}
+				w, err := timeWindow(start, end)
+				if err != nil {
+					return err
+				}
+				windows = append(windows, w)
+			}
+			if len(windows) == 1 {
+				s.StartTimeWindow = windows[0]
+				return nil
+			}
+			s.StartTimeWindow = windows
+			return nil
+		},
+		"start_time_window_start": func(s *Stop, v string) error {
+			return setWindowBound(s, v, 0)
+		},
+		"start_time_window_end": func(s *Stop, v string) error {
+			return setWindowBound(s, v, 1)
+		},
+		"quantity": func(s *Stop, v string) error {
+			return parseQuantity(&s.Quantity, v)
+		},
+		"compatibility_attributes": func(s *Stop, v string) error {
+			attributes := splitList(v)
+			s.CompatibilityAttributes = &attributes
+			return nil
+		},
+		"precedes": func(s *Stop, v string) error {
+			s.Precedes = anyList(splitList(v))
+			return nil
+		},
+		"succeeds": func(s *Stop, v string) error {
+			s.Succeeds = anyList(splitList(v))
+			return nil
+		},
+	},
+	resource: map[string]func(*Stop, string, string) error{
+		"quantity_": func(s *Stop, name string, v string) error {
+			return parseResource(&s.Quantity, name, v)
+		},
+	},
+	finish: func(s *Stop) (string, error) {
+		if window, ok := s.StartTimeWindow.([]any); ok && len(window) == 2 {
+			start, _ := window[0].(string)
+			end, _ := window[1].(string)
+			if start == "" && end != "" {
+				return "start_time_window_start", errors.New("value is required when the end is given")
+			}
+			if end == "" && start != "" {
+				return "start_time_window_end", errors.New("value is required when the start is given")
+			}
+			if start != "" && end != "" {
+				if _, err := timeWindow(start, end); err != nil {
+					return "start_time_window_end", err
+				}
+			}
+		}
+		return "", nil
+	},
+}
+
+var vehicleColumns = csvColumns[Vehicle]{
+	required: []string{"id"},
+	fixed: map[string]csvSetter[Vehicle]{
+		"id": func(vehicle *Vehicle, v string) error {
+			vehicle.ID = v
+			return nil
+		},
+		"start_lon": func(vehicle *Vehicle, v string) error {
+			return parseLocationPart(&vehicle.StartLocation, v, 0)
+		},
+		"start_lat": func(vehicle *Vehicle, v string) error {
+			return parseLocationPart(&vehicle.StartLocation, v, 1)
+		},
+		"end_lon": func(vehicle *Vehicle, v string) error {
+			return parseLocationPart(&vehicle.EndLocation, v, 0)
+		},
+		"end_lat": func(vehicle *Vehicle, v string) error {
+			return parseLocationPart(&vehicle.EndLocation, v, 1)
+		},
+		"start_time": func(vehicle *Vehicle, v string) error {
+			return parseTimePointer(&vehicle.StartTime, v)
+		},
+		"end_time": func(vehicle *Vehicle, v string) error {
+			return parseTimePointer(&vehicle.EndTime, v)
+		},
+		"speed": func(vehicle *Vehicle, v string) error {
+			return parseFloatPointer(&vehicle.Speed, v)
+		},
+		"capacity": func(vehicle *Vehicle, v string) error {
+			return parseQuantity(&vehicle.Capacity, v)
+		},
+		"start_level": func(vehicle *Vehicle, v string) error {
+			return parseQuantity(&vehicle.StartLevel, v)
+		},
+		"compatibility_attributes": func(vehicle *Vehicle, v string) error {
+			attributes := splitList(v)
+			vehicle.CompatibilityAttributes = &attributes
+			return nil
+		},
+		"max_distance": func(vehicle *Vehicle, v string) error {
+			return parseIntPointer(&vehicle.MaxDistance, v)
+		},
+		"max_duration": func(vehicle *Vehicle, v string) error {
+			return parseIntPointer(&vehicle.MaxDuration, v)
+		},
+		"max_stops": func(vehicle *Vehicle, v string) error {
+			return parseIntPointer(&vehicle.MaxStops, v)
+		},
+		"max_wait": func(vehicle *Vehicle, v string) error {
+			return parseIntPointer(&vehicle.MaxWait, v)
+		},
+		"min_stops": func(vehicle *Vehicle, v string) error {
+			return parseIntPointer(&vehicle.MinStops, v)
+		},
+		"min_stops_penalty": func(vehicle *Vehicle, v string) error {
+			return parseFloatPointer(&vehicle.MinStopsPenalty, v)
+		},
+		"activation_penalty": func(vehicle *Vehicle, v string) error {
+			return parseIntPointer(&vehicle.ActivationPenalty, v)
+		},
+		"stop_duration_multiplier": func(vehicle *Vehicle, v string) error {
+			return parseFloatPointer(&vehicle.StopDurationMultiplier, v)
+		},
+		"alternate_stops": func(vehicle *Vehicle, v string) error {
+			alternates := splitList(v)
+			vehicle.AlternateStops = &alternates
+			return nil
+		},
+		"initial_stops": func(vehicle *Vehicle, v string) error {
+			ids := splitList(v)
+			initialStops := make([]InitialStop, len(ids))
+			for idx, id := range ids {
+				initialStops[idx] = InitialStop{ID: id}
+			}
+			vehicle.InitialStops = &initialStops
+			return nil
+		},
+	},
+	resource: map[string]func(*Vehicle, string, string) error{
+		"capacity_": func(vehicle *Vehicle, name string, v string) error {
+			return parseResource(&vehicle.Capacity, name, v)
+		},
+		"start_level_": func(vehicle *Vehicle, name string, v string) error {
+			return parseResource(&vehicle.StartLevel, name, v)
+		},
+	},
+	finish: func(vehicle *Vehicle) (string, error) {
+		for _, location := range []struct {
+			location *Location
+			prefix   string
+		}{
+			{vehicle.StartLocation, "start_"},
+			{vehicle.EndLocation, "end_"},
+		} {
+			if location.location == nil {
+				continue
+			}
+			if math.IsNaN(location.location.Lon) {
+				return location.prefix + "lon", errors.New("value is required when the latitude is given")
+			}
+			if math.IsNaN(location.location.Lat) {
+				return location.prefix + "lat", errors.New("value is required when the longitude is given")
+			}
+		}
+		return "", nil
+	},
+}
+
+func parseFloatTo(target *float64, value string) error {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("`%s` is not a number", value)
+	}
+	*target = v
+	return nil
+}
+
+func parseFloatPointer(target **float64, value string) error {
+	var v float64
+	if err := parseFloatTo(&v, value); err != nil {
+		return err
+	}
+	*target = &v
+	return nil
+}
+
+func parseIntPointer(target **int, value string) error {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("`%s` is not an integer", value)
+	}
+	*target = &v
+	return nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("`%s` is not a RFC3339 time", value)
+	}
+	return t, nil
+}
+
+func parseTimePointer(target **time.Time, value string) error {
+	t, err := parseTime(value)
+	if err != nil {
+		return err
+	}
+	*target = &t
+	return nil
+}
+
+// timeWindow returns a time window the way it is decoded from JSON, a slice
+// of two RFC3339 strings.
+func timeWindow(start, end string) ([]any, error) {
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	s, err := parseTime(start)
+	if err != nil {
+		return nil, err
+	}
+	e, err := parseTime(end)
+	if err != nil {
+		return nil, err
+	}
+	if e.Before(s) {
+		return nil, fmt.Errorf("time window ends at %s before it starts at %s", end, start)
+	}
+	return []any{start, end}, nil
+}
+
+// setWindowBound sets the start (bound 0) or end (bound 1) of a single start
+// time window.
+func setWindowBound(s *Stop, value string, bound int) error {
+	if _, err := parseTime(value); err != nil {
+		return err
+	}
+	window, ok := s.StartTimeWindow.([]any)
+	if s.StartTimeWindow == nil {
+		window, ok = []any{"", ""}, true
+	}
+	if !ok || len(window) != 2 {
+		return errors.New("start time window is already set")
+	}
+	if _, isString := window[0].(string); !isString {
+		return errors.New("start time window is already set")
+	}
+	window[bound] = value
+	s.StartTimeWindow = window
+	return nil
+}
+
+// parseQuantity sets a single quantity of the default resource.
+func parseQuantity(target *any, value string) error {
+	if _, ok := (*target).(map[string]any); ok {
+		return errors.New("quantity of the default resource can not be combined with named resources")
+	}
+	var v float64
+	if err := parseFloatTo(&v, value); err != nil {
+		return err
+	}
+	*target = v
+	return nil
+}
+
+// parseResource sets the quantity of a named resource.
+func parseResource(target *any, name string, value string) error {
+	if *target == nil {
+		*target = map[string]any{}
+	}
+	resources, ok := (*target).(map[string]any)
+	if !ok {
+		return errors.New("named resources can not be combined with the quantity of the default resource")
+	}
+	var v float64
+	if err := parseFloatTo(&v, value); err != nil {
+		return err
+	}
+	resources[name] = v
+	return nil
+}
+
+// parseLocationPart sets the longitude (part 0) or latitude (part 1) of a
+// location, creating the location if needed.
+func parseLocationPart(target **Location, value string, part int) error {
+	var v float64
+	if err := parseFloatTo(&v, value); err != nil {
+		return err
+	}
+	if *target == nil {
+		*target = &Location{Lon: math.NaN(), Lat: math.NaN()}
+	}
+	if part == 0 {
+		(*target).Lon = v
+	} else {
+		(*target).Lat = v
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	parts := strings.Split(value, CSVListSeparator)
+	list := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
+func anyList(values []string) []any {
+	list := make([]any, len(values))
+	for idx, value := range values {
+		list[idx] = value
+	}
+	return list
+}
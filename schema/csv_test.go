@@ -0,0 +1,141 @@
+// © 2019-present nextmv.io inc
+
+package schema_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+const stopsCSV = `id,lon,lat,duration,start_time_window,quantity_weight,quantity_volume,compatibility_attributes,precedes
+pickup,7.62,51.96,120,2023-01-01T09:00:00Z/2023-01-01T12:00:00Z,-2,-1,cold;fragile,delivery
+delivery,7.63,51.97,60,2023-01-01T09:00:00Z/2023-01-01T10:00:00Z;2023-01-01T11:00:00Z/2023-01-01T13:00:00Z,2,1,cold,
+`
+
+const vehiclesCSV = `id,start_lon,start_lat,start_time,speed,capacity_weight,capacity_volume,compatibility_attributes
+truck,7.60,51.95,2023-01-01T08:00:00Z,10,10,5,cold;fragile
+`
+
+func TestReadCSV(t *testing.T) {
+	input, err := schema.ReadCSV(
+		strings.NewReader(stopsCSV),
+		strings.NewReader(vehiclesCSV),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(input.Stops) != 2 || len(input.Vehicles) != 1 {
+		t.Fatalf(
+			"expected 2 stops and 1 vehicle, got %v and %v",
+			len(input.Stops),
+			len(input.Vehicles),
+		)
+	}
+
+	pickup := input.Stops[0]
+	if pickup.Location.Lon != 7.62 || pickup.Location.Lat != 51.96 {
+		t.Errorf("unexpected location %v", pickup.Location)
+	}
+	if pickup.Duration == nil || *pickup.Duration != 120 {
+		t.Errorf("expected duration 120, got %v", pickup.Duration)
+	}
+	expectedQuantity := map[string]any{"weight": -2.0, "volume": -1.0}
+	if !reflect.DeepEqual(pickup.Quantity, expectedQuantity) {
+		t.Errorf("expected quantity %v, got %v", expectedQuantity, pickup.Quantity)
+	}
+	if !reflect.DeepEqual(pickup.Precedes, []any{"delivery"}) {
+		t.Errorf("expected precedes [delivery], got %v", pickup.Precedes)
+	}
+	if windows, ok := input.Stops[1].StartTimeWindow.([]any); !ok || len(windows) != 2 {
+		t.Errorf("expected two time windows, got %v", input.Stops[1].StartTimeWindow)
+	}
+
+	truck := input.Vehicles[0]
+	if truck.StartLocation == nil || truck.StartLocation.Lat != 51.95 {
+		t.Errorf("unexpected start location %v", truck.StartLocation)
+	}
+	if truck.EndLocation != nil {
+		t.Errorf("expected no end location, got %v", truck.EndLocation)
+	}
+
+	model, err := factory.NewModel(input, factory.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.NumberOfStops() != 4 {
+		t.Errorf("expected 4 stops in the model, got %v", model.NumberOfStops())
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		stops    string
+		vehicles string
+		row      int
+		column   string
+	}{
+		{
+			name:     "invalid number",
+			stops:    "id,lon,lat,duration\ns1,1,2,3\ns2,1,2,x\n",
+			vehicles: "id\nv1\n",
+			row:      3,
+			column:   "duration",
+		},
+		{
+			name:     "unknown column",
+			stops:    "id,lon,lat,colour\n",
+			vehicles: "id\nv1\n",
+			row:      1,
+			column:   "colour",
+		},
+		{
+			name:     "missing required value",
+			stops:    "id,lon,lat\n,1,2\n",
+			vehicles: "id\nv1\n",
+			row:      2,
+			column:   "id",
+		},
+		{
+			name:     "incomplete location",
+			stops:    "id,lon,lat\ns1,1,2\n",
+			vehicles: "id,start_lon,start_lat\nv1,1,\n",
+			row:      2,
+			column:   "start_lat",
+		},
+		{
+			name:     "invalid time window",
+			stops:    "id,lon,lat,start_time_window\ns1,1,2,2023-01-01T09:00:00Z\n",
+			vehicles: "id\nv1\n",
+			row:      2,
+			column:   "start_time_window",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := schema.ReadCSV(
+				strings.NewReader(test.stops),
+				strings.NewReader(test.vehicles),
+			)
+			var csvError *schema.CSVError
+			if !errors.As(err, &csvError) {
+				t.Fatalf("expected a CSV error, got %v", err)
+			}
+			if csvError.Row != test.row || csvError.Column != test.column {
+				t.Errorf(
+					"expected error at row %v column %s, got %v",
+					test.row,
+					test.column,
+					err,
+				)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+// © 2019-present nextmv.io inc
+
+package schema
+
+import (
+	"time"
+)
+
+// FeatureCollection is a GeoJSON feature collection, see RFC 7946.
+type FeatureCollection struct {
+	// Type is always FeatureCollection.
+	Type string `json:"type"`
+	// Features are the features of the collection.
+	Features []Feature `json:"features"`
+}
+
+// Feature is a GeoJSON feature.
+type Feature struct {
+	// Type is always Feature.
+	Type string `json:"type"`
+	// Geometry is the geometry of the feature.
+	Geometry Geometry `json:"geometry"`
+	// Properties are the properties of the feature.
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry is a GeoJSON Point or LineString geometry.
+type Geometry struct {
+	// Type is Point or LineString.
+	Type string `json:"type"`
+	// Coordinates is a position for a Point and a list of positions for a
+	// LineString. A position is a longitude followed by a latitude.
+	Coordinates any `json:"coordinates"`
+}
+
+// ToFeatureCollection converts a solution output to a GeoJSON feature
+// collection. Each vehicle route is a LineString with straight legs between
+// its stops, routes without any movement are left out. Each stop of a route
+// is a Point with the vehicle ID, the sequence in the route and the arrival,
+// start and end times. Unplanned stops are Points flagged as unplanned.
+func ToFeatureCollection(output SolutionOutput) FeatureCollection {
+	collection := FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []Feature{},
+	}
+
+	for _, vehicle := range output.Vehicles {
+		positions := make([][2]float64, len(vehicle.Route))
+		moves := false
+		for idx, stop := range vehicle.Route {
+			positions[idx] = geoJSONPosition(stop.Stop.Location)
+			if idx > 0 && positions[idx] != positions[idx-1] {
+				moves = true
+			}
+		}
+		if moves {
+			collection.Features = append(collection.Features, Feature{
+				Type: "Feature",
+				Geometry: Geometry{
+					Type:        "LineString",
+					Coordinates: positions,
+				},
+				Properties: map[string]any{
+					"type":                  "route",
+					"vehicle_id":            vehicle.ID,
+					"route_duration":        vehicle.RouteDuration,
+					"route_travel_duration": vehicle.RouteTravelDuration,
+					"route_travel_distance": vehicle.RouteTravelDistance,
+				},
+			})
+		}
+
+		for idx, stop := range vehicle.Route {
+			properties := map[string]any{
+				"type":       "stop",
+				"stop_id":    stop.Stop.ID,
+				"vehicle_id": vehicle.ID,
+				"sequence":   idx,
+				"unplanned":  false,
+			}
+			setGeoJSONTime(properties, "arrival_time", stop.ArrivalTime)
+			setGeoJSONTime(properties, "start_time", stop.StartTime)
+			setGeoJSONTime(properties, "end_time", stop.EndTime)
+			collection.Features = append(collection.Features, geoJSONPoint(
+				stop.Stop.Location,
+				properties,
+			))
+		}
+	}
+
+	for _, stop := range output.Unplanned {
+		collection.Features = append(collection.Features, geoJSONPoint(
+			stop.Location,
+			map[string]any{
+				"type":      "stop",
+				"stop_id":   stop.ID,
+				"unplanned": true,
+			},
+		))
+	}
+
+	return collection
+}
+
+func geoJSONPosition(location Location) [2]float64 {
+	return [2]float64{location.Lon, location.Lat}
+}
+
+func geoJSONPoint(location Location, properties map[string]any) Feature {
+	return Feature{
+		Type: "Feature",
+		Geometry: Geometry{
+			Type:        "Point",
+			Coordinates: geoJSONPosition(location),
+		},
+		Properties: properties,
+	}
+}
+
+func setGeoJSONTime(properties map[string]any, key string, value *time.Time) {
+	if value != nil {
+		properties[key] = value.Format(time.RFC3339)
+	}
+}
@@ -0,0 +1,80 @@
+// © 2019-present nextmv.io inc
+
+package schema_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute/schema"
+)
+
+func TestToFeatureCollection(t *testing.T) {
+	arrival := time.Date(2023, 1, 1, 9, 0, 0, 0, time.UTC)
+	depot := schema.Location{Lon: 7.60, Lat: 51.95}
+	output := schema.SolutionOutput{
+		Vehicles: []schema.VehicleOutput{
+			{
+				ID: "truck",
+				Route: []schema.PlannedStopOutput{
+					{Stop: schema.StopOutput{ID: "truck-start", Location: depot}},
+					{
+						Stop: schema.StopOutput{
+							ID:       "s1",
+							Location: schema.Location{Lon: 7.62, Lat: 51.96},
+						},
+						ArrivalTime: &arrival,
+					},
+				},
+			},
+			{
+				ID: "idle",
+				Route: []schema.PlannedStopOutput{
+					{Stop: schema.StopOutput{ID: "idle-start", Location: depot}},
+					{Stop: schema.StopOutput{ID: "idle-end", Location: depot}},
+				},
+			},
+		},
+		Unplanned: []schema.StopOutput{
+			{ID: "s2", Location: schema.Location{Lon: 7.63, Lat: 51.97}},
+		},
+	}
+
+	collection := schema.ToFeatureCollection(output)
+	if collection.Type != "FeatureCollection" {
+		t.Errorf("expected a FeatureCollection, got %v", collection.Type)
+	}
+
+	// One route of the truck, the idle vehicle does not move, and five
+	// stops.
+	lines, points := 0, 0
+	for _, feature := range collection.Features {
+		switch feature.Geometry.Type {
+		case "LineString":
+			lines++
+			if feature.Properties["vehicle_id"] != "truck" {
+				t.Errorf("unexpected route %v", feature.Properties)
+			}
+			positions := feature.Geometry.Coordinates.([][2]float64)
+			if len(positions) != 2 || positions[1] != [2]float64{7.62, 51.96} {
+				t.Errorf("unexpected route coordinates %v", positions)
+			}
+		case "Point":
+			points++
+		}
+	}
+	if lines != 1 || points != 5 {
+		t.Errorf("expected 1 line and 5 points, got %v and %v", lines, points)
+	}
+
+	stop := collection.Features[2].Properties
+	if stop["stop_id"] != "s1" ||
+		stop["sequence"] != 1 ||
+		stop["arrival_time"] != "2023-01-01T09:00:00Z" {
+		t.Errorf("unexpected stop properties %v", stop)
+	}
+	unplanned := collection.Features[len(collection.Features)-1].Properties
+	if unplanned["stop_id"] != "s2" || unplanned["unplanned"] != true {
+		t.Errorf("unexpected unplanned properties %v", unplanned)
+	}
+}
@@ -50,6 +50,9 @@ type VehicleOutput struct {
 	CustomData any `json:"custom_data,omitempty"`
 	// AlternateStops is the list of alternate stops selected.
 	AlternateStops *[]string `json:"alternate_stops,omitempty"`
+	// Objective is the part of the objective caused by the vehicle, for the
+	// objectives that attribute their value to vehicles.
+	Objective *ObjectiveOutput `json:"objective,omitempty"`
 }
 
 // PlannedStopOutput adds information to the input stop.
@@ -0,0 +1,23 @@
+// © 2019-present nextmv.io inc
+
+/*
+Package server serves a solver over HTTP so inputs can be solved without
+starting a process per request. A [Server] is created with [NewServer] from
+a [Solver], the default options and the size of the worker pool. Solve
+requests hold the input and, optionally, options overriding the defaults:
+
+	{"input": {...}, "options": {"solve": {"duration": 10000000000}}}
+
+A request to POST /solve waits for the output, with async=true it returns a
+[Job] right away that is polled with GET /jobs/{id}. DELETE /jobs/{id}
+cancels the context of the solver, the job holds the best output found so
+far. Jobs wait in a bounded queue for a free worker, requests are rejected
+once the queue is full. Requests can only override the sections of the
+options listed in [Options], never an option naming a path, URL or
+directory.
+
+The command in cmd exposes a server with `serve`:
+
+	go run cmd/main.go serve -serve.address :8080 -solve.duration 10s
+*/
+package server
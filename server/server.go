@@ -0,0 +1,482 @@
+// © 2019-present nextmv.io inc
+
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nextmv-io/nextroute/schema"
+	"github.com/nextmv-io/sdk/run"
+	runSchema "github.com/nextmv-io/sdk/run/schema"
+)
+
+// Solver solves an input with the given options. The context is cancelled
+// when the job is cancelled, the solver is expected to return the best
+// solution found so far.
+type Solver[O any] func(
+	ctx context.Context,
+	input schema.Input,
+	options O,
+) (runSchema.Output, error)
+
+// Status is the status of a job.
+type Status string
+
+const (
+	// StatusQueued is the status of a job waiting for a worker.
+	StatusQueued Status = "queued"
+	// StatusRunning is the status of a job being solved.
+	StatusRunning Status = "running"
+	// StatusSucceeded is the status of a job that finished with an output.
+	StatusSucceeded Status = "succeeded"
+	// StatusFailed is the status of a job that finished with an error.
+	StatusFailed Status = "failed"
+	// StatusCancelled is the status of a cancelled job. A job cancelled while
+	// running holds the best output found until it was cancelled.
+	StatusCancelled Status = "cancelled"
+)
+
+// Request is the body of a solve request.
+type Request struct {
+	// Input is the input to solve.
+	Input schema.Input `json:"input"`
+	// Options override the default options of the server. Fields that are
+	// not given keep their default value.
+	Options json.RawMessage `json:"options,omitempty"`
+}
+
+// Job is the state of a solve request as returned by the server.
+type Job struct {
+	// ID is the ID of the job.
+	ID string `json:"id"`
+	// Status is the status of the job.
+	Status Status `json:"status"`
+	// Submitted is the time the job was submitted.
+	Submitted time.Time `json:"submitted"`
+	// Started is the time a worker started solving the job.
+	Started *time.Time `json:"started,omitempty"`
+	// Finished is the time the job finished.
+	Finished *time.Time `json:"finished,omitempty"`
+	// Output is the output of a finished job.
+	Output *runSchema.Output `json:"output,omitempty"`
+	// Error is the error of a failed job.
+	Error string `json:"error,omitempty"`
+}
+
+// Options configure a server.
+type Options struct {
+	// Workers is the number of jobs solved concurrently. If zero, 1 is used.
+	Workers int
+	// Queue is the number of jobs that wait for a worker, more jobs are
+	// rejected with 503 Service Unavailable. If zero, 100 is used.
+	Queue int
+	// Retention is the duration finished jobs are kept. If zero, an hour is
+	// used.
+	Retention time.Duration
+	// Overrides are the sections of the options, their top-level fields, a
+	// request may set. Requests setting any other section, or an option
+	// named path, url or directory in any section, are rejected with 400 Bad
+	// Request as these give access to the files and the network of the
+	// server. If empty, requests cannot set options.
+	Overrides []string
+}
+
+// restricted are the names of the options a request may not set in any
+// section, compared case-insensitively like the fields of the options are
+// decoded.
+var restricted = []string{"path", "url", "directory"}
+
+// Server is an [http.Handler] solving inputs with a bounded pool of workers.
+// It serves:
+//
+//   - POST /solve solves the [Request] in the body and responds with the
+//     output once done. With the query parameter async=true it responds
+//     with the queued [Job] instead.
+//   - GET /jobs/{id} responds with the [Job].
+//   - DELETE /jobs/{id} cancels the job if it has not finished, waits for
+//     the best output found so far, removes the job and responds with it.
+type Server interface {
+	http.Handler
+	// Close cancels all jobs and waits for the workers to stop.
+	Close()
+}
+
+// NewServer creates a new server solving inputs with the solver. The options
+// of a request are applied on top of the defaults.
+func NewServer[O any](
+	solver Solver[O],
+	defaults O,
+	options Options,
+) (Server, error) {
+	defaultOptions, err := json.Marshal(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("encoding default options: %w", err)
+	}
+	if options.Workers <= 0 {
+		options.Workers = 1
+	}
+	if options.Queue <= 0 {
+		options.Queue = 100
+	}
+	if options.Retention <= 0 {
+		options.Retention = time.Hour
+	}
+
+	s := &serverImpl[O]{
+		solver:   solver,
+		defaults: defaultOptions,
+		options:  options,
+		queue:    make(chan *job[O], options.Queue),
+		jobs:     make(map[string]*job[O]),
+	}
+	s.workers.Add(options.Workers)
+	for i := 0; i < options.Workers; i++ {
+		go s.work()
+	}
+	return s, nil
+}
+
+type job[O any] struct {
+	// mutex guards the state, the other fields do not change.
+	mutex   sync.Mutex
+	state   Job
+	input   schema.Input
+	options O
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func (j *job[O]) snapshot() Job {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.state
+}
+
+type serverImpl[O any] struct {
+	solver   Solver[O]
+	defaults []byte
+	options  Options
+	queue    chan *job[O]
+	workers  sync.WaitGroup
+	mutex    sync.Mutex
+	jobs     map[string]*job[O]
+	closed   bool
+}
+
+func (s *serverImpl[O]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/solve":
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		s.solve(w, r)
+	case strings.HasPrefix(r.URL.Path, "/jobs/"):
+		id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+		switch r.Method {
+		case http.MethodGet:
+			s.get(w, id)
+		case http.MethodDelete:
+			s.delete(w, id)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *serverImpl[O]) Close() {
+	s.mutex.Lock()
+	if s.closed {
+		s.mutex.Unlock()
+		return
+	}
+	s.closed = true
+	for _, j := range s.jobs {
+		j.cancel()
+	}
+	close(s.queue)
+	s.mutex.Unlock()
+	s.workers.Wait()
+}
+
+func (s *serverImpl[O]) solve(w http.ResponseWriter, r *http.Request) {
+	async := false
+	if value := r.URL.Query().Get("async"); value != "" {
+		var err error
+		if async, err = strconv.ParseBool(value); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid async parameter")
+			return
+		}
+	}
+
+	var request Request
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("decoding request: %v", err))
+		return
+	}
+	var options O
+	if err := json.Unmarshal(s.defaults, &options); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(request.Options) > 0 {
+		if err := s.checkOverrides(request.Options); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if err := json.Unmarshal(request.Options, &options); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("decoding options: %v", err))
+			return
+		}
+	}
+
+	j, status, err := s.submit(request.Input, options)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+
+	if async {
+		w.Header().Set("Location", "/jobs/"+j.state.ID)
+		writeJSON(w, http.StatusAccepted, j.snapshot())
+		return
+	}
+
+	select {
+	case <-j.done:
+	case <-r.Context().Done():
+		// The client is gone, nobody is waiting for the result.
+		j.cancel()
+		s.remove(j.state.ID)
+		return
+	}
+	s.remove(j.state.ID)
+	state := j.snapshot()
+	if state.Status == StatusFailed {
+		writeError(w, http.StatusInternalServerError, state.Error)
+		return
+	}
+	writeJSON(w, http.StatusOK, state.Output)
+}
+
+// checkOverrides returns an error if the options of a request set a section
+// that may not be overridden or a restricted option.
+func (s *serverImpl[O]) checkOverrides(options json.RawMessage) error {
+	var sections map[string]any
+	if err := json.Unmarshal(options, &sections); err != nil {
+		return fmt.Errorf("decoding options: %w", err)
+	}
+	for section, value := range sections {
+		if !slices.ContainsFunc(s.options.Overrides, func(override string) bool {
+			return strings.EqualFold(override, section)
+		}) {
+			return fmt.Errorf("option %q cannot be set by a request", section)
+		}
+		if name, ok := restrictedOption(value); ok {
+			return fmt.Errorf("option %q of %q cannot be set by a request", name, section)
+		}
+	}
+	return nil
+}
+
+// restrictedOption returns the name of the first restricted option in the
+// decoded value, if any.
+func restrictedOption(value any) (string, bool) {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, field := range v {
+			if slices.ContainsFunc(restricted, func(r string) bool {
+				return strings.EqualFold(r, name)
+			}) {
+				return name, true
+			}
+			if name, ok := restrictedOption(field); ok {
+				return name, true
+			}
+		}
+	case []any:
+		for _, element := range v {
+			if name, ok := restrictedOption(element); ok {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// submit queues a new job. Returns the HTTP status to respond with if the
+// job cannot be queued.
+func (s *serverImpl[O]) submit(input schema.Input, options O) (*job[O], int, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	j := &job[O]{
+		state: Job{
+			ID:        id,
+			Status:    StatusQueued,
+			Submitted: time.Now(),
+		},
+		input:   input,
+		options: options,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closed {
+		cancel()
+		return nil, http.StatusServiceUnavailable, errors.New("server is closed")
+	}
+	s.expire()
+	select {
+	case s.queue <- j:
+	default:
+		cancel()
+		return nil, http.StatusServiceUnavailable, errors.New("queue is full")
+	}
+	s.jobs[id] = j
+	return j, http.StatusAccepted, nil
+}
+
+// expire removes the jobs that finished longer than the retention ago. The
+// mutex of the server must be held.
+func (s *serverImpl[O]) expire() {
+	threshold := time.Now().Add(-s.options.Retention)
+	for id, j := range s.jobs {
+		state := j.snapshot()
+		if state.Finished != nil && state.Finished.Before(threshold) {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *serverImpl[O]) job(id string) (*job[O], bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok
+}
+
+func (s *serverImpl[O]) remove(id string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.jobs, id)
+}
+
+func (s *serverImpl[O]) get(w http.ResponseWriter, id string) {
+	j, ok := s.job(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("job %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+func (s *serverImpl[O]) delete(w http.ResponseWriter, id string) {
+	j, ok := s.job(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("job %s not found", id))
+		return
+	}
+	j.cancel()
+	<-j.done
+	s.remove(id)
+	writeJSON(w, http.StatusOK, j.snapshot())
+}
+
+func (s *serverImpl[O]) work() {
+	defer s.workers.Done()
+	for j := range s.queue {
+		s.run(j)
+	}
+}
+
+// run solves the job, unless it was cancelled while queued.
+func (s *serverImpl[O]) run(j *job[O]) {
+	defer close(j.done)
+	defer j.cancel()
+
+	start := time.Now()
+	j.mutex.Lock()
+	if j.ctx.Err() != nil {
+		j.state.Status = StatusCancelled
+		j.state.Finished = &start
+		j.mutex.Unlock()
+		return
+	}
+	j.state.Status = StatusRunning
+	j.state.Started = &start
+	j.mutex.Unlock()
+
+	// The solver measures its duration from the start of the run and keeps
+	// run data in the context, as it does when run by a runner.
+	ctx := context.WithValue(j.ctx, run.Start, start)
+	ctx = context.WithValue(ctx, run.Data, &sync.Map{})
+	output, err := s.safeSolve(ctx, j)
+
+	finished := time.Now()
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.state.Finished = &finished
+	switch {
+	case err != nil:
+		j.state.Status = StatusFailed
+		j.state.Error = err.Error()
+	case j.ctx.Err() != nil:
+		j.state.Status = StatusCancelled
+		j.state.Output = &output
+	default:
+		j.state.Status = StatusSucceeded
+		j.state.Output = &output
+	}
+}
+
+// safeSolve calls the solver and turns a panic into an error, a failing
+// input must not stop the server.
+func (s *serverImpl[O]) safeSolve(
+	ctx context.Context,
+	j *job[O],
+) (output runSchema.Output, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("solver panicked: %v", r)
+		}
+	}()
+	return s.solver(ctx, j.input, j.options)
+}
+
+func newID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
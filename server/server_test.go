@@ -0,0 +1,262 @@
+// © 2019-present nextmv.io inc
+
+package server_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/factory"
+	"github.com/nextmv-io/nextroute/schema"
+	"github.com/nextmv-io/nextroute/server"
+	runSchema "github.com/nextmv-io/sdk/run/schema"
+)
+
+type testOptions struct {
+	Block  bool   `json:"block"`
+	Name   string `json:"name"`
+	Stream struct {
+		Path string `json:"path"`
+	} `json:"stream"`
+}
+
+// overrides are the options the requests of the tests may set.
+var overrides = []string{"block", "name"}
+
+// testSolver responds with the name of the options. If the options block,
+// it waits for the context to be cancelled and responds with the best
+// solution so far.
+func testSolver(
+	ctx context.Context,
+	input schema.Input,
+	options testOptions,
+) (runSchema.Output, error) {
+	if options.Block {
+		<-ctx.Done()
+		return runSchema.Output{Solutions: []any{"best so far"}}, nil
+	}
+	return runSchema.Output{Solutions: []any{options.Name, len(input.Stops)}}, nil
+}
+
+func post(t *testing.T, url string, body string) *http.Response {
+	t.Helper()
+	response, err := http.Post(url, "application/json", bytes.NewBufferString(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response
+}
+
+func do(t *testing.T, method string, url string) (int, server.Job) {
+	t.Helper()
+	request, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var job server.Job
+	_ = json.NewDecoder(response.Body).Decode(&job)
+	return response.StatusCode, job
+}
+
+func newTestServer(t *testing.T, options server.Options) *httptest.Server {
+	t.Helper()
+	s, err := server.NewServer(testSolver, testOptions{Name: "default"}, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(s)
+	t.Cleanup(func() {
+		httpServer.Close()
+		s.Close()
+	})
+	return httpServer
+}
+
+func TestSolveSync(t *testing.T) {
+	httpServer := newTestServer(t, server.Options{Overrides: overrides})
+
+	tests := []struct {
+		body     string
+		expected []any
+	}{
+		{`{"input": {"stops": [{"id": "s1"}]}}`, []any{"default", 1.0}},
+		{`{"input": {}, "options": {"name": "custom"}}`, []any{"custom", 0.0}},
+	}
+	for _, test := range tests {
+		response := post(t, httpServer.URL+"/solve", test.body)
+		var output runSchema.Output
+		if err := json.NewDecoder(response.Body).Decode(&output); err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if response.StatusCode != http.StatusOK {
+			t.Fatalf("expected status 200, got %v", response.StatusCode)
+		}
+		if len(output.Solutions) != 2 ||
+			output.Solutions[0] != test.expected[0] ||
+			output.Solutions[1] != test.expected[1] {
+			t.Errorf("expected solutions %v, got %v", test.expected, output.Solutions)
+		}
+	}
+
+	response := post(t, httpServer.URL+"/solve", `{"input": `)
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid body, got %v", response.StatusCode)
+	}
+}
+
+func TestSolveOverrides(t *testing.T) {
+	httpServer := newTestServer(t, server.Options{Overrides: []string{"name", "stream"}})
+
+	tests := []struct {
+		options string
+		status  int
+	}{
+		{`{"name": "custom"}`, http.StatusOK},
+		{`{"NAME": "custom"}`, http.StatusOK},
+		{`{"block": true}`, http.StatusBadRequest},
+		{`{"stream": {}}`, http.StatusOK},
+		{`{"stream": {"path": "/tmp/solutions.json"}}`, http.StatusBadRequest},
+		{`{"stream": {"Path": "/tmp/solutions.json"}}`, http.StatusBadRequest},
+		{`{"name": "custom", "Stream": {"path": "/tmp/solutions.json"}}`, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		response := post(t, httpServer.URL+"/solve", `{"input": {}, "options": `+test.options+`}`)
+		response.Body.Close()
+		if response.StatusCode != test.status {
+			t.Errorf("options %v: expected status %v, got %v", test.options, test.status, response.StatusCode)
+		}
+	}
+
+	defaults := newTestServer(t, server.Options{})
+	response := post(t, defaults.URL+"/solve", `{"input": {}, "options": {"name": "custom"}}`)
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status 400 without overrides, got %v", response.StatusCode)
+	}
+}
+
+func TestSolveAsyncCancel(t *testing.T) {
+	httpServer := newTestServer(t, server.Options{Workers: 1, Queue: 1, Overrides: overrides})
+
+	response := post(
+		t,
+		httpServer.URL+"/solve?async=true",
+		`{"input": {}, "options": {"block": true}}`,
+	)
+	var job server.Job
+	if err := json.NewDecoder(response.Body).Decode(&job); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusAccepted || job.Status != server.StatusQueued {
+		t.Fatalf("expected a queued job, got %v %v", response.StatusCode, job)
+	}
+
+	// Wait for the worker to pick up the job, then fill the queue.
+	for job.Status != server.StatusRunning {
+		time.Sleep(10 * time.Millisecond)
+		_, job = do(t, http.MethodGet, httpServer.URL+"/jobs/"+job.ID)
+	}
+	queued := post(t, httpServer.URL+"/solve?async=true", `{"input": {}}`)
+	queued.Body.Close()
+	rejected := post(t, httpServer.URL+"/solve?async=true", `{"input": {}}`)
+	rejected.Body.Close()
+	if queued.StatusCode != http.StatusAccepted ||
+		rejected.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf(
+			"expected a queued and a rejected job, got %v and %v",
+			queued.StatusCode,
+			rejected.StatusCode,
+		)
+	}
+
+	status, cancelled := do(t, http.MethodDelete, httpServer.URL+"/jobs/"+job.ID)
+	if status != http.StatusOK || cancelled.Status != server.StatusCancelled {
+		t.Fatalf("expected a cancelled job, got %v %v", status, cancelled)
+	}
+	if cancelled.Output == nil || cancelled.Output.Solutions[0] != "best so far" {
+		t.Errorf("expected the best solution so far, got %v", cancelled.Output)
+	}
+	if status, _ := do(t, http.MethodGet, httpServer.URL+"/jobs/"+job.ID); status != http.StatusNotFound {
+		t.Errorf("expected a removed job, got status %v", status)
+	}
+}
+
+func TestSolveCancelParallelSolver(t *testing.T) {
+	solver := func(
+		ctx context.Context,
+		input schema.Input,
+		options nextroute.ParallelSolveOptions,
+	) (runSchema.Output, error) {
+		model, err := factory.NewModel(input, factory.Options{})
+		if err != nil {
+			return runSchema.Output{}, err
+		}
+		solver, err := nextroute.NewParallelSolver(model)
+		if err != nil {
+			return runSchema.Output{}, err
+		}
+		solutions, err := solver.Solve(ctx, options)
+		if err != nil {
+			return runSchema.Output{}, err
+		}
+		last, err := solutions.Last()
+		if err != nil {
+			return runSchema.Output{}, err
+		}
+		return factory.Format(ctx, options, solver, last), nil
+	}
+	s, err := server.NewServer(solver, nextroute.ParallelSolveOptions{
+		Duration:     time.Minute,
+		Iterations:   -1,
+		ParallelRuns: 1,
+	}, server.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	httpServer := httptest.NewServer(s)
+	defer httpServer.Close()
+
+	body := `{"input": {
+		"stops": [
+			{"id": "s1", "location": {"lon": 7.62, "lat": 51.96}},
+			{"id": "s2", "location": {"lon": 7.63, "lat": 51.97}}
+		],
+		"vehicles": [
+			{"id": "v1", "start_location": {"lon": 7.60, "lat": 51.95}, "speed": 10}
+		]
+	}}`
+	response := post(t, httpServer.URL+"/solve?async=true", body)
+	var job server.Job
+	if err := json.NewDecoder(response.Body).Decode(&job); err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+
+	time.Sleep(200 * time.Millisecond)
+	start := time.Now()
+	status, cancelled := do(t, http.MethodDelete, httpServer.URL+"/jobs/"+job.ID)
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("expected the solver to stop when cancelled")
+	}
+	if status != http.StatusOK ||
+		cancelled.Status != server.StatusCancelled ||
+		cancelled.Output == nil ||
+		len(cancelled.Output.Solutions) != 1 {
+		t.Fatalf("expected a cancelled job with a solution, got %v %v", status, cancelled)
+	}
+}
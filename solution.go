@@ -1058,21 +1058,25 @@ func NewPreAllocatedMoveContainer(planUnit SolutionPlanUnit) *PreAllocatedMoveCo
 	return &allocations
 }
 
+// newNotExecutableMoveFor returns a not executable move for the plan unit.
+// The move has the most likely type the moves of the plan unit will have so
+// it can be used to take the best move in place.
+func newNotExecutableMoveFor(planUnit SolutionPlanUnit) SolutionMove {
+	switch planUnit.(type) {
+	case SolutionPlanStopsUnit:
+		return newNotExecutableSolutionMoveStops(planUnit.(*solutionPlanStopsUnitImpl))
+	case SolutionPlanUnitsUnit:
+		return newNotExecutableSolutionMoveUnits(planUnit.(*solutionPlanUnitsUnitImpl))
+	}
+	return NotExecutableMove
+}
+
 func (s *solutionImpl) BestMove(ctx context.Context, planUnit SolutionPlanUnit) SolutionMove {
 	if planUnit.Solution().(*solutionImpl) != s {
 		panic("plan planUnit does not belong to this solution")
 	}
 
-	var bestMove SolutionMove
-	// we initialize bestMove with the most likely type the moves will have
-	switch planUnit.(type) {
-	case SolutionPlanStopsUnit:
-		bestMove = newNotExecutableSolutionMoveStops(planUnit.(*solutionPlanStopsUnitImpl))
-	case SolutionPlanUnitsUnit:
-		bestMove = newNotExecutableSolutionMoveUnits(planUnit.(*solutionPlanUnitsUnitImpl))
-	default:
-		bestMove = NotExecutableMove
-	}
+	bestMove := newNotExecutableMoveFor(planUnit)
 
 	s.model.OnBestMove(s)
 
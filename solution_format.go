@@ -57,6 +57,16 @@ func Format(
 				output.Statistics.Run.Iterations = &iterations
 			}
 		}
+		if stored, ok := data.Load(OperatorWeights); ok && output.Statistics.Run != nil {
+			if weights, ok := stored.(*operatorWeights); ok {
+				if run, best, ok := weights.best(); ok {
+					output.Statistics.Run.Custom = map[string]any{
+						OperatorWeights:    best,
+						OperatorWeightsRun: run,
+					}
+				}
+			}
+		}
 	}
 
 	if len(solutions) == 0 {
@@ -7,6 +7,33 @@ import (
 	"fmt"
 )
 
+// NewMoveUnits creates a new move planning a plan units unit that plans all of
+// its plan units, one move per plan unit. The moves are executed in the given
+// order, a move can position its stops relative to the stops planned by the
+// moves before it.
+func NewMoveUnits(
+	planUnit SolutionPlanUnitsUnit,
+	moves SolutionMoves,
+) (SolutionMove, error) {
+	if planUnit == nil {
+		return nil, fmt.Errorf("planUnit is nil")
+	}
+	if !planUnit.ModelPlanUnitsUnit().PlanAll() {
+		return nil, fmt.Errorf(
+			"plan unit %v plans one of its plan units, use the move of that plan unit",
+			planUnit.ModelPlanUnitsUnit(),
+		)
+	}
+	if len(moves) != len(planUnit.SolutionPlanUnits()) {
+		return nil, fmt.Errorf(
+			"moves and plan units must have the same length: %v != %v",
+			len(moves),
+			len(planUnit.SolutionPlanUnits()),
+		)
+	}
+	return newSolutionMoveUnits(planUnit.(*solutionPlanUnitsUnitImpl), moves), nil
+}
+
 func newSolutionMoveUnits(
 	planUnit *solutionPlanUnitsUnitImpl,
 	moves SolutionMoves,
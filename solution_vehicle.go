@@ -447,6 +447,28 @@ func (v SolutionVehicle) BestMove(
 	return v.bestMove(ctx, planUnit, allocations)
 }
 
+// BestMoves returns the best move for the given solution plan unit on
+// each of the invoking vehicles, in the order of the vehicles. A move is not
+// executable if the plan unit can not be planned on the vehicle, see
+// SolutionVehicle.BestMove. The moves do not share any state, they can be
+// compared and one of them can be executed.
+func (v SolutionVehicles) BestMoves(
+	ctx context.Context,
+	planUnit SolutionPlanUnit,
+) SolutionMoves {
+	moves := make(SolutionMoves, len(v))
+	sharedMoveContainer := NewPreAllocatedMoveContainer(planUnit)
+	for idx, vehicle := range v {
+		// The move of a vehicle can be the shared move container, taking it
+		// into a new move copies it.
+		moves[idx] = takeBestInPlace(
+			newNotExecutableMoveFor(planUnit),
+			vehicle.bestMove(ctx, planUnit, sharedMoveContainer),
+		)
+	}
+	return moves
+}
+
 func (v SolutionVehicle) bestMove(
 	ctx context.Context,
 	planUnit SolutionPlanUnit,
@@ -86,3 +86,44 @@ func BenchmarkSolutionVehicleImpl_BestMove(b *testing.B) {
 		_ = solutionVehicle.BestMove(context.Background(), solutionPlanUnit)
 	}
 }
+
+func TestSolutionVehicles_BestMoves(t *testing.T) {
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 3),
+		planSingleStops(),
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planUnit := solution.UnPlannedPlanUnits().SolutionPlanUnits()[0]
+
+	moves := solution.Vehicles().BestMoves(context.Background(), planUnit)
+	if len(moves) != 3 {
+		t.Fatalf("expected a move per vehicle, got %v", len(moves))
+	}
+	for idx, move := range moves {
+		if !move.IsExecutable() {
+			t.Fatalf("move %v should be executable", idx)
+		}
+		if vehicle := move.(nextroute.SolutionMoveStops).Vehicle(); vehicle.Index() != idx {
+			t.Errorf("move %v should be on vehicle %v, got %v", idx, idx, vehicle.Index())
+		}
+	}
+
+	planned, err := moves[2].Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !planned {
+		t.Fatal("move should be planned")
+	}
+	if solution.SolutionStop(planUnit.ModelPlanUnit().(nextroute.ModelPlanStopsUnit).Stops()[0]).Vehicle().Index() != 2 {
+		t.Error("plan unit should be planned on the last vehicle")
+	}
+}
@@ -0,0 +1,262 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"fmt"
+	"math"
+)
+
+// The acceptance criteria which can be selected in [AcceptanceOptions].
+const (
+	// AcceptanceNone accepts every work solution, the work solution drifts
+	// until it is reset.
+	AcceptanceNone = "none"
+	// AcceptanceSimulatedAnnealing accepts worse work solutions with a
+	// probability decreasing with the temperature, see
+	// [NewSimulatedAnnealingCriterion].
+	AcceptanceSimulatedAnnealing = "simulated_annealing"
+	// AcceptanceRecordToRecord accepts work solutions within a deviation of
+	// the best solution, see [NewRecordToRecordCriterion].
+	AcceptanceRecordToRecord = "record_to_record"
+	// AcceptanceThreshold accepts work solutions within a decreasing
+	// threshold of the current solution, see [NewThresholdCriterion].
+	AcceptanceThreshold = "threshold"
+	// AcceptanceLateAcceptance accepts work solutions not worse than the
+	// current solution of a number of iterations ago, see
+	// [NewLateAcceptanceCriterion].
+	AcceptanceLateAcceptance = "late_acceptance"
+)
+
+// AcceptanceOptions are the options to create an acceptance criterion, see
+// [NewAcceptanceCriterion].
+type AcceptanceOptions struct {
+	// Criterion is the acceptance criterion, empty if not set.
+	Criterion string `json:"criterion" usage:"acceptance criterion of the work solution at the end of an iteration (none, simulated_annealing, record_to_record, threshold, late_acceptance)" default:"none"`
+	// Temperature is the start temperature of simulated annealing relative
+	// to the score of the start solution.
+	Temperature float64 `json:"temperature" usage:"start temperature of simulated annealing relative to the score of the start solution" default:"0.01"`
+	// FinalTemperature is the final temperature of simulated annealing
+	// relative to the score of the start solution.
+	FinalTemperature float64 `json:"final_temperature" usage:"final temperature of simulated annealing relative to the score of the start solution" default:"0.0001"`
+	// Deviation is the deviation of record-to-record travel relative to the
+	// score of the best solution.
+	Deviation float64 `json:"deviation" usage:"deviation of record-to-record travel relative to the score of the best solution" default:"0.01"`
+	// Threshold is the start threshold of threshold accepting relative to the
+	// score of the current solution.
+	Threshold float64 `json:"threshold" usage:"start threshold of threshold accepting relative to the score of the current solution" default:"0.01"`
+	// HistoryLength is the number of iterations of late acceptance.
+	HistoryLength int `json:"history_length" usage:"number of iterations of late acceptance" default:"100"`
+}
+
+// DefaultAcceptanceOptions returns the default acceptance options, no
+// acceptance criterion is selected.
+func DefaultAcceptanceOptions() AcceptanceOptions {
+	return AcceptanceOptions{
+		Criterion:        AcceptanceNone,
+		Temperature:      0.01,
+		FinalTemperature: 0.0001,
+		Deviation:        0.01,
+		Threshold:        0.01,
+		HistoryLength:    100,
+	}
+}
+
+// AcceptanceInformation is the information passed to an acceptance criterion
+// at the end of an iteration.
+type AcceptanceInformation struct {
+	// SolveInformation is the information of the solve.
+	SolveInformation SolveInformation
+	// Progress is the part of the solve which has passed, between 0 and 1. It
+	// is the maximum of the elapsed part of the duration and of the
+	// iterations.
+	Progress float64
+	// Current is the score of the current solution, the last accepted work
+	// solution.
+	Current float64
+	// Candidate is the score of the work solution at the end of the
+	// iteration.
+	Candidate float64
+	// Best is the score of the best solution.
+	Best float64
+}
+
+// AcceptanceCriterion decides at the end of each iteration whether the work
+// solution is accepted as the current solution. If it is not accepted the
+// work solution is reset to the current solution.
+type AcceptanceCriterion interface {
+	// Start is called when a solve starts with the score of the start
+	// solution.
+	Start(score float64)
+	// Accept returns true if the work solution is accepted.
+	Accept(information AcceptanceInformation) bool
+}
+
+// NewAcceptanceCriterion creates a new acceptance criterion from the
+// options. It returns nil if the criterion is empty or [AcceptanceNone].
+func NewAcceptanceCriterion(options AcceptanceOptions) (AcceptanceCriterion, error) {
+	switch options.Criterion {
+	case "", AcceptanceNone:
+		return nil, nil
+	case AcceptanceSimulatedAnnealing:
+		return NewSimulatedAnnealingCriterion(options.Temperature, options.FinalTemperature)
+	case AcceptanceRecordToRecord:
+		return NewRecordToRecordCriterion(options.Deviation)
+	case AcceptanceThreshold:
+		return NewThresholdCriterion(options.Threshold)
+	case AcceptanceLateAcceptance:
+		return NewLateAcceptanceCriterion(options.HistoryLength)
+	}
+	return nil, fmt.Errorf(
+		"unknown acceptance criterion %q, must be one of %v, %v, %v, %v or %v",
+		options.Criterion,
+		AcceptanceNone,
+		AcceptanceSimulatedAnnealing,
+		AcceptanceRecordToRecord,
+		AcceptanceThreshold,
+		AcceptanceLateAcceptance,
+	)
+}
+
+// NewSimulatedAnnealingCriterion creates a new simulated annealing
+// acceptance criterion. A work solution worse than the current solution by
+// delta is accepted with probability exp(-delta / T). The temperature T cools
+// exponentially with the progress of the solve from the start temperature to
+// the final temperature, both relative to the score of the start solution.
+func NewSimulatedAnnealingCriterion(
+	temperature float64,
+	finalTemperature float64,
+) (AcceptanceCriterion, error) {
+	if temperature <= 0 || finalTemperature <= 0 || finalTemperature > temperature {
+		return nil, fmt.Errorf(
+			"temperatures %v and %v must be larger than 0, the final temperature"+
+				" must not be larger than the start temperature",
+			temperature,
+			finalTemperature,
+		)
+	}
+	return &simulatedAnnealingImpl{
+		temperature:      temperature,
+		finalTemperature: finalTemperature,
+	}, nil
+}
+
+type simulatedAnnealingImpl struct {
+	temperature      float64
+	finalTemperature float64
+	score            float64
+}
+
+func (s *simulatedAnnealingImpl) Start(score float64) {
+	s.score = math.Abs(score)
+}
+
+func (s *simulatedAnnealingImpl) Accept(information AcceptanceInformation) bool {
+	delta := information.Candidate - information.Current
+	if delta <= 0 {
+		return true
+	}
+	temperature := s.score * s.temperature *
+		math.Pow(s.finalTemperature/s.temperature, information.Progress)
+	if temperature <= 0 {
+		return false
+	}
+	random := information.SolveInformation.Solver().Random()
+	return random.Float64() < math.Exp(-delta/temperature)
+}
+
+// NewRecordToRecordCriterion creates a new record-to-record travel
+// acceptance criterion. A work solution is accepted if its score is less than
+// the score of the best solution plus the deviation relative to the score of
+// the best solution.
+func NewRecordToRecordCriterion(deviation float64) (AcceptanceCriterion, error) {
+	if deviation < 0 {
+		return nil, fmt.Errorf(
+			"deviation %v must not be negative",
+			deviation,
+		)
+	}
+	return &recordToRecordImpl{
+		deviation: deviation,
+	}, nil
+}
+
+type recordToRecordImpl struct {
+	deviation float64
+}
+
+func (r *recordToRecordImpl) Start(_ float64) {
+}
+
+func (r *recordToRecordImpl) Accept(information AcceptanceInformation) bool {
+	return information.Candidate <= information.Current ||
+		information.Candidate-information.Best <= r.deviation*math.Abs(information.Best)
+}
+
+// NewThresholdCriterion creates a new threshold accepting criterion. A work
+// solution is accepted if its score is less than the score of the current
+// solution plus the threshold relative to the score of the current solution.
+// The threshold decreases linearly with the progress of the solve to 0.
+func NewThresholdCriterion(threshold float64) (AcceptanceCriterion, error) {
+	if threshold < 0 {
+		return nil, fmt.Errorf(
+			"threshold %v must not be negative",
+			threshold,
+		)
+	}
+	return &thresholdImpl{
+		threshold: threshold,
+	}, nil
+}
+
+type thresholdImpl struct {
+	threshold float64
+}
+
+func (t *thresholdImpl) Start(_ float64) {
+}
+
+func (t *thresholdImpl) Accept(information AcceptanceInformation) bool {
+	threshold := t.threshold * (1 - information.Progress) * math.Abs(information.Current)
+	return information.Candidate-information.Current <= threshold
+}
+
+// NewLateAcceptanceCriterion creates a new late acceptance criterion. A work
+// solution is accepted if its score is not worse than the score of the
+// current solution or of the current solution history length iterations ago.
+func NewLateAcceptanceCriterion(historyLength int) (AcceptanceCriterion, error) {
+	if historyLength < 1 {
+		return nil, fmt.Errorf(
+			"history length %v must be at least 1",
+			historyLength,
+		)
+	}
+	return &lateAcceptanceImpl{
+		history: make([]float64, historyLength),
+	}, nil
+}
+
+type lateAcceptanceImpl struct {
+	history   []float64
+	iteration int
+}
+
+func (l *lateAcceptanceImpl) Start(score float64) {
+	for i := range l.history {
+		l.history[i] = score
+	}
+	l.iteration = 0
+}
+
+func (l *lateAcceptanceImpl) Accept(information AcceptanceInformation) bool {
+	index := l.iteration % len(l.history)
+	l.iteration++
+	accept := information.Candidate <= information.Current ||
+		information.Candidate <= l.history[index]
+	if accept {
+		l.history[index] = information.Candidate
+	} else {
+		l.history[index] = information.Current
+	}
+	return accept
+}
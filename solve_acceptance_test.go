@@ -0,0 +1,221 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestAcceptanceCriteria(t *testing.T) {
+	recordToRecord, err := nextroute.NewRecordToRecordCriterion(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	threshold, err := nextroute.NewThresholdCriterion(0.1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lateAcceptance, err := nextroute.NewLateAcceptanceCriterion(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lateAcceptance.Start(100)
+
+	tests := []struct {
+		name      string
+		criterion nextroute.AcceptanceCriterion
+		info      nextroute.AcceptanceInformation
+		accept    bool
+	}{
+		{
+			name:      "record to record within deviation of best",
+			criterion: recordToRecord,
+			info:      nextroute.AcceptanceInformation{Best: 100, Current: 100, Candidate: 109},
+			accept:    true,
+		},
+		{
+			name:      "record to record beyond deviation of best",
+			criterion: recordToRecord,
+			info:      nextroute.AcceptanceInformation{Best: 100, Current: 105, Candidate: 111},
+			accept:    false,
+		},
+		{
+			name:      "threshold at start",
+			criterion: threshold,
+			info:      nextroute.AcceptanceInformation{Progress: 0, Current: 100, Candidate: 109},
+			accept:    true,
+		},
+		{
+			name:      "threshold decreased by progress",
+			criterion: threshold,
+			info:      nextroute.AcceptanceInformation{Progress: 0.5, Current: 100, Candidate: 109},
+			accept:    false,
+		},
+		{
+			name:      "late acceptance not worse than history",
+			criterion: lateAcceptance,
+			info:      nextroute.AcceptanceInformation{Current: 90, Candidate: 100},
+			accept:    true,
+		},
+		{
+			name:      "late acceptance worse than history",
+			criterion: lateAcceptance,
+			info:      nextroute.AcceptanceInformation{Current: 90, Candidate: 101},
+			accept:    false,
+		},
+		{
+			name:      "late acceptance history updated",
+			criterion: lateAcceptance,
+			info:      nextroute.AcceptanceInformation{Current: 90, Candidate: 101},
+			accept:    false,
+		},
+	}
+	for _, test := range tests {
+		if accept := test.criterion.Accept(test.info); accept != test.accept {
+			t.Errorf("%v: expected %v, got %v", test.name, test.accept, accept)
+		}
+	}
+
+	if _, err := nextroute.NewSimulatedAnnealingCriterion(0.01, 0.1); err == nil {
+		t.Error("expected an error for a final temperature larger than the start temperature")
+	}
+	if _, err := nextroute.NewAcceptanceCriterion(nextroute.AcceptanceOptions{Criterion: "unknown"}); err == nil {
+		t.Error("expected an error for an unknown criterion")
+	}
+	if criterion, err := nextroute.NewAcceptanceCriterion(nextroute.DefaultAcceptanceOptions()); err != nil || criterion != nil {
+		t.Errorf("expected no criterion by default, got %v, %v", criterion, err)
+	}
+}
+
+func TestSolveAcceptance(t *testing.T) {
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		planSingleStops(),
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	unplannedPenalty := nextroute.NewStopExpression("unplanned_penalty", 100000)
+	if _, err := model.Objective().NewTerm(1, nextroute.NewUnPlannedObjective(unplannedPenalty)); err != nil {
+		t.Fatal(err)
+	}
+
+	numberOfUnits, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unplan, err := nextroute.NewSolveOperatorUnPlan(numberOfUnits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := nextroute.NewSolveOperatorPlan(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The work solution gets worse in the iterations the un-planned units are
+	// not planned again.
+	if err := plan.SetProbability(0.5); err != nil {
+		t.Fatal(err)
+	}
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(unplan, plan)
+
+	options := nextroute.DefaultAcceptanceOptions()
+	options.Criterion = "unknown"
+	if _, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 10, Duration: time.Minute, Acceptance: options},
+	); err == nil {
+		t.Error("expected an error for an unknown criterion")
+	}
+
+	// Record-to-record travel without deviation only accepts work solutions
+	// not worse than the current solution.
+	options.Criterion = nextroute.AcceptanceRecordToRecord
+	options.Deviation = 0
+	previous := -1.0
+	solver.SolveEvents().Iterated.Register(func(information nextroute.SolveInformation) {
+		score := information.Solver().WorkSolution().Score()
+		if previous >= 0 && score > previous {
+			t.Errorf("iteration %v: expected score %v not to be worse than %v", information.Iteration(), score, previous)
+		}
+		previous = score
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 100, Duration: time.Minute, Acceptance: options},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// rejectAll is an acceptance criterion which rejects every work solution.
+type rejectAll struct{}
+
+func (rejectAll) Start(float64) {}
+
+func (rejectAll) Accept(nextroute.AcceptanceInformation) bool {
+	return false
+}
+
+// TestSolveAcceptanceUnchanged expects the work solution not to be reset if
+// the solve-operators do not change it.
+func TestSolveAcceptanceUnchanged(t *testing.T) {
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 1),
+		planSingleStops(),
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acceptanceSolver, ok := solver.(nextroute.AcceptanceSolver)
+	if !ok {
+		t.Fatal("expected the solver to be an acceptance solver")
+	}
+	acceptanceSolver.SetAcceptanceCriterion(rejectAll{})
+	solver.AddSolveOperators(noOperator{nextroute.NewSolveOperator(1, true, nil)})
+
+	var work nextroute.Solution
+	solver.SolveEvents().Iterated.Register(func(information nextroute.SolveInformation) {
+		if work != nil && information.Solver().WorkSolution() != work {
+			t.Errorf("iteration %v: expected the work solution not to be reset", information.Iteration())
+		}
+		work = information.Solver().WorkSolution()
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 10, Duration: time.Minute},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+}
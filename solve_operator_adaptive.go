@@ -0,0 +1,309 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/nextmv-io/nextroute/common"
+	"github.com/nextmv-io/sdk/run"
+)
+
+// OperatorWeights is the key for the weights of the operators of an adaptive
+// solve-operator, see [NewSolverOperatorAdaptive].
+const OperatorWeights string = "operator_weights"
+
+// OperatorWeightsRun is the key for the run of the weights reported under
+// [OperatorWeights].
+const OperatorWeightsRun string = "operator_weights_run"
+
+// AdaptiveOptions are the options of an adaptive solve-operator, see
+// [NewSolverOperatorAdaptive].
+type AdaptiveOptions struct {
+	// NewBest is the score of an operator if the iteration results in a new
+	// best solution.
+	NewBest float64 `json:"new_best" usage:"score of an operator resulting in a new best solution" default:"33"`
+	// Improving is the score of an operator if the iteration improves the
+	// work solution.
+	Improving float64 `json:"improving" usage:"score of an operator improving the work solution" default:"9"`
+	// Accepted is the score of an operator if the iteration changes the work
+	// solution without improving it.
+	Accepted float64 `json:"accepted" usage:"score of an operator changing the work solution without improving it" default:"13"`
+	// SegmentLength is the number of executions after which the weights are
+	// updated.
+	SegmentLength int `json:"segment_length" usage:"number of executions after which the weights of the operators are updated" default:"100"`
+	// ReactionFactor is the part of a weight replaced by the average score of
+	// the operator in a segment.
+	ReactionFactor float64 `json:"reaction_factor" usage:"reaction factor of the weights of the operators to their scores, between 0 and 1" default:"0.1"`
+}
+
+// DefaultAdaptiveOptions returns the default options of an adaptive
+// solve-operator.
+func DefaultAdaptiveOptions() AdaptiveOptions {
+	return AdaptiveOptions{
+		NewBest:        33,
+		Improving:      9,
+		Accepted:       13,
+		SegmentLength:  100,
+		ReactionFactor: 0.1,
+	}
+}
+
+// SolveOperatorAdaptive is a solve-operator.
+// An adaptive solve-operator selects one of its solve-operators in each
+// iteration like [SolveOperatorOr], the weights of the solve-operators adapt
+// to their success as in Adaptive Large Neighborhood Search (ALNS). The
+// initial weight of a solve-operator is its probability.
+//
+// At the end of each iteration in which it was executed the selected
+// solve-operator earns a score: the new best score if the best solution
+// improved, the improving score if the work solution improved and the
+// accepted score if the work solution changed without improving. After
+// segment length executions the weight of each solve-operator selected in
+// the segment is updated to (1 - r) * weight + r * average score, r being
+// the reaction factor.
+type SolveOperatorAdaptive interface {
+	SolveOperatorOr
+
+	// Options returns the options of the solve-operator.
+	Options() AdaptiveOptions
+	// Weights returns the current weights of the solve-operators, in the
+	// order of Operators.
+	Weights() []float64
+}
+
+// NewSolverOperatorAdaptive creates a new adaptive solve-operator. The
+// probability must be between 0 and 1. The number of operators with
+// probability larger than zero must be greater than 0.
+//
+// If the context of the solve holds run data, see [run.Data], the weights
+// are stored by the name of the solve-operator after each segment and when
+// the solve is done, keyed by the run of the parallel solver. The weights of
+// the run that found the best solution are reported in the run statistics
+// under the key [OperatorWeights], its run under [OperatorWeightsRun]. The
+// names of solve-operators sharing a name are suffixed by their index.
+func NewSolverOperatorAdaptive(
+	probability float64,
+	operators SolveOperators,
+	options AdaptiveOptions,
+) (SolveOperatorAdaptive, error) {
+	if options.SegmentLength < 1 {
+		return nil, fmt.Errorf(
+			"segment length %v must be at least 1",
+			options.SegmentLength,
+		)
+	}
+	if options.ReactionFactor < 0 || options.ReactionFactor > 1 {
+		return nil, fmt.Errorf(
+			"reaction factor %v must be between 0 and 1",
+			options.ReactionFactor,
+		)
+	}
+	if options.NewBest < 0 || options.Improving < 0 || options.Accepted < 0 {
+		return nil, fmt.Errorf(
+			"scores %v, %v and %v must not be negative",
+			options.NewBest,
+			options.Improving,
+			options.Accepted,
+		)
+	}
+	or, err := NewSolverOperatorOr(probability, operators)
+	if err != nil {
+		return nil, err
+	}
+	operators = or.Operators()
+	return &solveOperatorAdaptiveImpl{
+		SolveOperatorOr: or,
+		options:         options,
+		weights: common.Map(operators, func(operator SolveOperator) float64 {
+			return operator.Probability()
+		}),
+		scores:     make([]float64, len(operators)),
+		executions: make([]int, len(operators)),
+		selected:   -1,
+	}, nil
+}
+
+type solveOperatorAdaptiveImpl struct {
+	SolveOperatorOr
+	solver     Solver
+	data       *sync.Map
+	run        int
+	alias      common.Alias
+	options    AdaptiveOptions
+	weights    []float64
+	scores     []float64
+	executions []int
+	selected   int
+	score      float64
+	newBest    bool
+	segment    int
+}
+
+func (s *solveOperatorAdaptiveImpl) Options() AdaptiveOptions {
+	return s.options
+}
+
+func (s *solveOperatorAdaptiveImpl) Weights() []float64 {
+	return append([]float64(nil), s.weights...)
+}
+
+func (s *solveOperatorAdaptiveImpl) Execute(
+	ctx context.Context,
+	runTimeInformation SolveInformation,
+) error {
+	if s.alias == nil {
+		alias, err := common.NewAlias(s.weights)
+		if err != nil {
+			return err
+		}
+		s.alias = alias
+	}
+	if s.data == nil {
+		s.data, _ = ctx.Value(run.Data).(*sync.Map)
+	}
+	if r, ok := ctx.Value(runKey{}).(int); ok {
+		s.run = r
+	}
+	s.selected = s.alias.Sample(runTimeInformation.Solver().Random())
+	s.score = runTimeInformation.Solver().WorkSolution().Score()
+	s.newBest = false
+	s.segment++
+	return s.Operators()[s.selected].Execute(ctx, runTimeInformation)
+}
+
+// onIterated scores the solve-operator selected in the iteration and updates
+// the weights at the end of a segment.
+func (s *solveOperatorAdaptiveImpl) onIterated(solveInformation SolveInformation) {
+	if s.selected < 0 {
+		return
+	}
+	score := solveInformation.Solver().WorkSolution().Score()
+	switch {
+	case s.newBest:
+		s.scores[s.selected] += s.options.NewBest
+	case score < s.score:
+		s.scores[s.selected] += s.options.Improving
+	case score != s.score:
+		s.scores[s.selected] += s.options.Accepted
+	}
+	s.executions[s.selected]++
+	s.selected = -1
+
+	if s.segment < s.options.SegmentLength {
+		return
+	}
+	s.updateWeights()
+}
+
+// onDone updates the weights with the scores of the last segment, if it is
+// incomplete.
+func (s *solveOperatorAdaptiveImpl) onDone(_ SolveInformation) {
+	if s.segment > 0 {
+		s.updateWeights()
+	}
+}
+
+// updateWeights updates the weights with the scores of the segment and
+// stores them.
+func (s *solveOperatorAdaptiveImpl) updateWeights() {
+	s.segment = 0
+	for i := range s.weights {
+		if s.executions[i] > 0 {
+			s.weights[i] = (1-s.options.ReactionFactor)*s.weights[i] +
+				s.options.ReactionFactor*s.scores[i]/float64(s.executions[i])
+		}
+		s.scores[i] = 0
+		s.executions[i] = 0
+	}
+	// The alias is created when the next solve-operator is selected, the
+	// weights must not all be zero.
+	s.alias = nil
+	if !common.Has(s.weights, true, func(weight float64) bool { return weight > 0 }) {
+		for i := range s.weights {
+			s.weights[i] = s.Operators()[i].Probability()
+		}
+	}
+	s.storeWeights()
+}
+
+func (s *solveOperatorAdaptiveImpl) OnStartSolve(solveInformation SolveInformation) {
+	if s.solver != solveInformation.Solver() {
+		s.solver = solveInformation.Solver()
+		s.solver.SolveEvents().Iterated.Register(s.onIterated)
+		s.solver.SolveEvents().Done.Register(s.onDone)
+	}
+	if orInterested, ok := s.SolveOperatorOr.(InterestedInStartSolve); ok {
+		orInterested.OnStartSolve(solveInformation)
+	}
+}
+
+func (s *solveOperatorAdaptiveImpl) OnBetterSolution(solveInformation SolveInformation) {
+	s.newBest = true
+	if orInterested, ok := s.SolveOperatorOr.(InterestedInBetterSolution); ok {
+		orInterested.OnBetterSolution(solveInformation)
+	}
+}
+
+// storeWeights stores the weights of the solve-operators by their name, as
+// in a recording, for the run in the run data of the solve, if any.
+func (s *solveOperatorAdaptiveImpl) storeWeights() {
+	if s.data == nil || s.solver == nil {
+		return
+	}
+	names := common.Map(s.Operators(), operatorName)
+	counts := make(map[string]int, len(names))
+	for _, name := range names {
+		counts[name]++
+	}
+	weights := make(map[string]float64, len(s.weights))
+	for i, name := range names {
+		if counts[name] > 1 {
+			name = fmt.Sprintf("%s#%d", name, i)
+		}
+		weights[name] = s.weights[i]
+	}
+	stored, _ := s.data.LoadOrStore(OperatorWeights, &operatorWeights{})
+	if runs, ok := stored.(*operatorWeights); ok {
+		runs.store(s.run, s.solver.BestSolution().Score(), weights)
+	}
+}
+
+// operatorWeights are the weights of the solve-operators of an adaptive
+// solve-operator by run, with the score of the best solution of the run.
+type operatorWeights struct {
+	mutex sync.Mutex
+	runs  map[int]runOperatorWeights
+}
+
+type runOperatorWeights struct {
+	score   float64
+	weights map[string]float64
+}
+
+func (w *operatorWeights) store(run int, score float64, weights map[string]float64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.runs == nil {
+		w.runs = make(map[int]runOperatorWeights)
+	}
+	w.runs[run] = runOperatorWeights{score: score, weights: weights}
+}
+
+// best returns the weights of the run with the best solution, ties are
+// broken by the lowest run. Returns false if no weights are stored.
+func (w *operatorWeights) best() (int, map[string]float64, bool) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	best, found := 0, false
+	for run, weights := range w.runs {
+		if !found ||
+			weights.score < w.runs[best].score ||
+			weights.score == w.runs[best].score && run < best {
+			best, found = run, true
+		}
+	}
+	return best, w.runs[best].weights, found
+}
@@ -0,0 +1,151 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/sdk/run"
+)
+
+// noOperator is a solve-operator which does not change the solution.
+type noOperator struct {
+	nextroute.SolveOperator
+}
+
+func (noOperator) Execute(context.Context, nextroute.SolveInformation) error {
+	return nil
+}
+
+func TestSolveOperatorAdaptive(t *testing.T) {
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		planSingleStops(),
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	unplannedPenalty := nextroute.NewStopExpression("unplanned_penalty", 100000)
+	if _, err := model.Objective().NewTerm(1, nextroute.NewUnPlannedObjective(unplannedPenalty)); err != nil {
+		t.Fatal(err)
+	}
+
+	numberOfUnits, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unplan, err := nextroute.NewSolveOperatorUnPlan(numberOfUnits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := nextroute.NewSolveOperatorPlan(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	operators := nextroute.SolveOperators{
+		plan,
+		noOperator{nextroute.NewSolveOperator(1, false, nil)},
+		noOperator{nextroute.NewSolveOperator(1, false, nil)},
+	}
+	options := nextroute.DefaultAdaptiveOptions()
+	options.SegmentLength = 10
+	options.ReactionFactor = 0.5
+
+	invalid := options
+	invalid.ReactionFactor = 2
+	if _, err := nextroute.NewSolverOperatorAdaptive(1, operators, invalid); err == nil {
+		t.Error("expected an error for a reaction factor larger than 1")
+	}
+	adaptive, err := nextroute.NewSolverOperatorAdaptive(1, operators, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weights := adaptive.Weights(); weights[0] != 1 || weights[1] != 1 || weights[2] != 1 {
+		t.Errorf("expected the initial weights to be the probabilities, got %v", weights)
+	}
+
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(unplan, adaptive)
+
+	data := &sync.Map{}
+	ctx := context.WithValue(context.Background(), run.Data, data)
+	ctx = context.WithValue(ctx, run.Start, time.Now())
+	// The last segment is incomplete and reported once the solve is done.
+	solutions, err := solver.Solve(
+		ctx,
+		nextroute.SolveOptions{Iterations: 205, Duration: time.Minute},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err := solutions.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The operator which does not change the solution does not earn a score.
+	weights := adaptive.Weights()
+	if weights[0] <= weights[1] || weights[0] <= weights[2] {
+		t.Errorf("expected the weight of the plan operator to be larger, got %v", weights)
+	}
+
+	output := nextroute.Format(ctx, nil, nil, func(nextroute.Solution) any { return nil }, best)
+	custom, ok := output.Statistics.Run.Custom.(map[string]any)
+	if !ok {
+		t.Fatalf("expected the operator weights in the run statistics, got %v", output.Statistics.Run.Custom)
+	}
+	reported, ok := custom[nextroute.OperatorWeights].(map[string]float64)
+	if !ok || len(reported) != 3 {
+		t.Fatalf("expected the weights of 3 operators, got %v", custom[nextroute.OperatorWeights])
+	}
+	for i, name := range []string{"nextroute_test.noOperator#1", "nextroute_test.noOperator#2"} {
+		if reported[name] != weights[i+1] {
+			t.Errorf("expected weight %v for %v, got %v", weights[i+1], name, reported)
+		}
+	}
+	if run, ok := custom[nextroute.OperatorWeightsRun].(int); !ok || run != 0 {
+		t.Errorf("expected the weights of run 0, got %v", custom[nextroute.OperatorWeightsRun])
+	}
+}
+
+func TestSolverFactoryAdaptive(t *testing.T) {
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		planSingleStops(),
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := nextroute.DefaultSolverFactoryOptions()
+	options.Adaptive = true
+	if _, err := nextroute.NewSolverFactory(options)(nil, solution); err == nil {
+		t.Error("expected an error for adaptive without an alternative un-plan operator")
+	}
+	options.UnplanStrings = 1
+	if _, err := nextroute.NewSolverFactory(options)(nil, solution); err != nil {
+		t.Errorf("expected a solver for adaptive with an alternative un-plan operator, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// SolveOperatorInterRoute is a solve-operator that exchanges segments of
+// stops between two nearby routes. It applies first-improvement relocate
+// moves, moving a segment to another route, swap moves, exchanging two
+// stops, and CROSS-exchange moves, exchanging two segments.
+type SolveOperatorInterRoute interface {
+	SolveOperator
+
+	// SegmentLength returns the maximum number of stops of a segment.
+	SegmentLength() int
+}
+
+// NewSolveOperatorInterRoute creates a new SolveOperatorInterRoute executed
+// with the given probability. Each execution selects a random planned stop
+// and tries to exchange a segment starting at the stop with a segment of up
+// to segmentLength stops starting at one of its closest stops, see
+// [ModelStopsDistanceQueries], on another route. Moves are estimated on the
+// travel duration of the vehicle types and executed as [SolutionMoveStops] so
+// all constraints are respected, a move is kept if it improves the score of
+// the solution.
+//
+// A segment only holds complete plan units, the stops of a pickup and
+// delivery are moved together and keep their order. Fixed stops and the stops
+// of plan units which are part of a plan units unit are not moved.
+func NewSolveOperatorInterRoute(
+	probability float64,
+	segmentLength int,
+) (SolveOperatorInterRoute, error) {
+	if probability < 0 || probability > 1 {
+		return nil, fmt.Errorf(
+			"probability %v must be between 0 and 1",
+			probability,
+		)
+	}
+	if segmentLength < 1 {
+		return nil, fmt.Errorf(
+			"segment length %v must be at least 1",
+			segmentLength,
+		)
+	}
+	return &solveOperatorInterRouteImpl{
+		SolveOperator: NewSolveOperator(
+			probability,
+			true,
+			SolveParameters{},
+		),
+		segmentLength: segmentLength,
+	}, nil
+}
+
+type solveOperatorInterRouteImpl struct {
+	SolveOperator
+	segmentLength int
+}
+
+func (d *solveOperatorInterRouteImpl) SegmentLength() int {
+	return d.segmentLength
+}
+
+func (d *solveOperatorInterRouteImpl) Execute(
+	ctx context.Context,
+	runTimeInformation SolveInformation,
+) error {
+	workSolution := runTimeInformation.Solver().WorkSolution().(*solutionImpl)
+	if workSolution.PlannedPlanUnits().Size() == 0 {
+		return nil
+	}
+
+	planUnit, ok := workSolution.PlannedPlanUnits().RandomElement().(SolutionPlanStopsUnit)
+	if !ok {
+		return nil
+	}
+	solutionStops := planUnit.SolutionStops()
+	stop := solutionStops[runTimeInformation.Solver().Random().Intn(len(solutionStops))]
+	if !isMovable(stop.ModelStop()) || !stop.ModelStop().Location().IsValid() {
+		return nil
+	}
+
+	closestStops, err := stop.ModelStop().(*stopImpl).closestStops()
+	if err != nil {
+		return err
+	}
+	for _, closestStop := range closestStops {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			if !isMovable(closestStop) {
+				continue
+			}
+			other := workSolution.SolutionStop(closestStop)
+			if !other.IsPlanned() || other.VehicleIndex() == stop.VehicleIndex() {
+				continue
+			}
+			improved, err := d.exchange(ctx, stop, other)
+			if err != nil || improved {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exchange executes the first exchange of a segment starting at stop a with a
+// segment starting at stop b that improves the score of the solution, an
+// empty segment relocates the other segment in front of its stop. Returns
+// false if there is no such exchange.
+func (d *solveOperatorInterRouteImpl) exchange(
+	ctx context.Context,
+	a, b SolutionStop,
+) (bool, error) {
+	vehicleA, vehicleB := a.Vehicle(), b.Vehicle()
+	routeA, routeB := vehicleA.SolutionStops(), vehicleB.SolutionStops()
+	typeA := vehicleA.ModelVehicle().VehicleType()
+	typeB := vehicleB.ModelVehicle().VehicleType()
+	i, j := a.Position(), b.Position()
+
+	for lengthA := 0; lengthA <= d.segmentLength; lengthA++ {
+		if !isSegment(routeA, i, lengthA) {
+			continue
+		}
+		for lengthB := 0; lengthB <= d.segmentLength; lengthB++ {
+			if !isSegment(routeB, j, lengthB) {
+				continue
+			}
+			if lengthA == 0 && lengthB == 0 {
+				continue
+			}
+			segmentA := routeA[i : i+lengthA]
+			segmentB := routeB[j : j+lengthB]
+			delta := chainTravelDuration(typeA, routeA[i-1], segmentB, routeA[i+lengthA]) +
+				chainTravelDuration(typeB, routeB[j-1], segmentA, routeB[j+lengthB]) -
+				chainTravelDuration(typeA, routeA[i-1], segmentA, routeA[i+lengthA]) -
+				chainTravelDuration(typeB, routeB[j-1], segmentB, routeB[j+lengthB])
+			if delta >= 0 {
+				continue
+			}
+
+			improved, err := resequence(
+				ctx,
+				a.solution,
+				modelStops(segmentA, segmentB),
+				vehicleSequence{
+					vehicle: vehicleA.Index(),
+					stops:   modelStops(routeA[:i], segmentB, routeA[i+lengthA:]),
+				},
+				vehicleSequence{
+					vehicle: vehicleB.Index(),
+					stops:   modelStops(routeB[:j], segmentA, routeB[j+lengthB:]),
+				},
+			)
+			if err != nil || improved {
+				return improved, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// isSegment returns true if the length stops of the route starting at start
+// can be moved to another route. All stops must be movable and hold all stops
+// of their plan units.
+func isSegment(route SolutionStops, start, length int) bool {
+	if start+length >= len(route) {
+		return false
+	}
+	segment := route[start : start+length]
+	for _, stop := range segment {
+		if !isMovable(stop.ModelStop()) {
+			return false
+		}
+		for _, unitStop := range stop.ModelStop().PlanStopsUnit().Stops() {
+			if !slices.ContainsFunc(segment, func(s SolutionStop) bool {
+				return s.ModelStop() == unitStop
+			}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// chainTravelDuration returns the travel duration for the vehicle type from
+// the stop before to the stop after visiting the segment.
+func chainTravelDuration(
+	vehicleType ModelVehicleType,
+	before SolutionStop,
+	segment SolutionStops,
+	after SolutionStop,
+) float64 {
+	duration := 0.0
+	from := before
+	for _, to := range segment {
+		duration += estimateTravelDuration(vehicleType, from, to)
+		from = to
+	}
+	return duration + estimateTravelDuration(vehicleType, from, after)
+}
+
+// modelStops returns the model stops of the concatenated solution stops.
+func modelStops(stops ...SolutionStops) ModelStops {
+	result := make(ModelStops, 0)
+	for _, s := range stops {
+		for _, stop := range s {
+			result = append(result, stop.ModelStop())
+		}
+	}
+	return result
+}
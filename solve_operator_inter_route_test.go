@@ -0,0 +1,87 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestSolveOperatorInterRoute(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		lon := -74.07
+		if idx >= 3 {
+			lon = -74.02
+		}
+		return Location{Lon: lon + 0.002*float64(idx%3), Lat: 4.70}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both vehicles visit a stop of the cluster of the other vehicle.
+	planRoutes(t, solution, [][]int{{0, 1, 3}, {4, 5, 2}})
+
+	if _, err := nextroute.NewSolveOperatorInterRoute(-0.5, 3); err == nil {
+		t.Error("expected an error for a negative probability")
+	}
+	if _, err := nextroute.NewSolveOperatorInterRoute(1, 0); err == nil {
+		t.Error("expected an error for a segment length of 0")
+	}
+	operator, err := nextroute.NewSolveOperatorInterRoute(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(operator)
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 50, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err := solutions.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if best.Score() >= solution.Score() {
+		t.Errorf("expected score %v to improve on %v", best.Score(), solution.Score())
+	}
+	if best.UnPlannedPlanUnits().Size() != 0 {
+		t.Errorf("expected all stops to stay planned, got %v unplanned", best.UnPlannedPlanUnits().Size())
+	}
+	for _, vehicle := range best.Vehicles() {
+		clusters := map[bool]bool{}
+		for _, stop := range vehicle.SolutionStops() {
+			if stop.IsFirst() || stop.IsLast() {
+				continue
+			}
+			clusters[stop.ModelStopIndex() >= 3] = true
+		}
+		if len(clusters) > 1 && vehicle.NumberOfStops() < len(stops) {
+			t.Errorf("expected vehicle %v to visit one cluster, got %v", vehicle.Index(), vehicle.SolutionStops())
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/nextmv-io/nextroute/common"
+)
+
+// SolveOperatorIntraRoute is a solve-operator that improves the route of a
+// vehicle without changing the stops assigned to the vehicle. It applies
+// first-improvement 2-opt moves, reversing a segment of the route, and or-opt
+// moves, relocating a segment of one to three stops within the route.
+type SolveOperatorIntraRoute interface {
+	SolveOperator
+}
+
+// NewSolveOperatorIntraRoute creates a new SolveOperatorIntraRoute executed
+// with the given probability. Each execution improves the route of a random
+// vehicle until no improving 2-opt or or-opt move is left. Moves are
+// estimated on the travel duration of the vehicle type at the current
+// departure of the stops and executed as [SolutionMoveStops] so all
+// constraints are respected, a move is kept if it improves the score of the
+// solution. Fixed stops and the stops of plan units
+// which are part of a plan units unit are not moved.
+func NewSolveOperatorIntraRoute(probability float64) (SolveOperatorIntraRoute, error) {
+	if probability < 0 || probability > 1 {
+		return nil, fmt.Errorf(
+			"probability %v must be between 0 and 1",
+			probability,
+		)
+	}
+	return &solveOperatorIntraRouteImpl{
+		SolveOperator: NewSolveOperator(
+			probability,
+			true,
+			SolveParameters{},
+		),
+	}, nil
+}
+
+// orOptSegmentLength is the maximum number of stops relocated by an or-opt
+// move.
+const orOptSegmentLength = 3
+
+type solveOperatorIntraRouteImpl struct {
+	SolveOperator
+}
+
+func (d *solveOperatorIntraRouteImpl) Execute(
+	ctx context.Context,
+	runTimeInformation SolveInformation,
+) error {
+	workSolution := runTimeInformation.Solver().WorkSolution()
+
+	vehicles := common.Filter(
+		workSolution.Vehicles(),
+		func(vehicle SolutionVehicle) bool {
+			return vehicle.NumberOfStops() > 1
+		},
+	)
+	if len(vehicles) == 0 {
+		return nil
+	}
+	vehicle := vehicles[runTimeInformation.Solver().Random().Intn(len(vehicles))]
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			improved, err := d.improve(ctx, vehicle)
+			if err != nil {
+				return err
+			}
+			if !improved {
+				return nil
+			}
+		}
+	}
+}
+
+// improve executes the first 2-opt or or-opt move on the route of the vehicle
+// that improves the score of the solution. Returns false if there is no such
+// move.
+func (d *solveOperatorIntraRouteImpl) improve(
+	ctx context.Context,
+	vehicle SolutionVehicle,
+) (bool, error) {
+	solutionStops := vehicle.SolutionStops()
+	route := modelStops(solutionStops)
+	vehicleType := vehicle.ModelVehicle().VehicleType()
+	travelDuration := func(from, to int) float64 {
+		return estimateTravelDuration(vehicleType, solutionStops[from], solutionStops[to])
+	}
+	last := len(route) - 1
+
+	// 2-opt, reverse the stops i to j.
+	for i := 1; i < last; i++ {
+		forward, reversed := 0.0, 0.0
+		for j := i; j < last && isMovable(route[j]); j++ {
+			if j > i {
+				forward += travelDuration(j-1, j)
+				reversed += travelDuration(j, j-1)
+			}
+			if j == i {
+				continue
+			}
+			delta := travelDuration(i-1, j) + reversed + travelDuration(i, j+1) -
+				travelDuration(i-1, i) - forward - travelDuration(j, j+1)
+			if delta >= 0 {
+				continue
+			}
+			sequence := slices.Clone(route)
+			slices.Reverse(sequence[i : j+1])
+			improved, err := resequence(
+				ctx,
+				vehicle.solution,
+				route[i:j+1],
+				vehicleSequence{vehicle: vehicle.Index(), stops: sequence},
+			)
+			if err != nil || improved {
+				return improved, err
+			}
+		}
+	}
+
+	// or-opt, relocate the stops i to e between the stops k and k+1.
+	for length := 1; length <= orOptSegmentLength; length++ {
+	Segment:
+		for i := 1; i+length-1 < last; i++ {
+			e := i + length - 1
+			for s := i; s <= e; s++ {
+				if !isMovable(route[s]) {
+					continue Segment
+				}
+			}
+			removed := travelDuration(i-1, i) + travelDuration(e, e+1) -
+				travelDuration(i-1, e+1)
+			for k := 0; k < last; k++ {
+				if k >= i-1 && k <= e {
+					continue
+				}
+				delta := travelDuration(k, i) + travelDuration(e, k+1) -
+					travelDuration(k, k+1) - removed
+				if delta >= 0 {
+					continue
+				}
+				sequence := make(ModelStops, 0, len(route))
+				for s := 0; s < len(route); s++ {
+					if s >= i && s <= e {
+						continue
+					}
+					sequence = append(sequence, route[s])
+					if s == k {
+						sequence = append(sequence, route[i:e+1]...)
+					}
+				}
+				improved, err := resequence(
+					ctx,
+					vehicle.solution,
+					route[i:e+1],
+					vehicleSequence{vehicle: vehicle.Index(), stops: sequence},
+				)
+				if err != nil || improved {
+					return improved, err
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// estimateTravelDuration returns the travel duration from one stop to another
+// for the vehicle type, departing at the current end of the from stop.
+func estimateTravelDuration(vehicleType ModelVehicleType, from, to SolutionStop) float64 {
+	if !from.ModelStop().Location().IsValid() || !to.ModelStop().Location().IsValid() {
+		return 0
+	}
+	return vehicleType.TravelDurationExpression().ValueAtValue(
+		from.EndValue(),
+		vehicleType,
+		from.ModelStop(),
+		to.ModelStop(),
+	)
+}
+
+// isMovable returns true if the stop can be moved to another position.
+func isMovable(stop ModelStop) bool {
+	if stop.IsFixed() || !stop.HasPlanStopsUnit() {
+		return false
+	}
+	_, isMemberOf := stop.PlanStopsUnit().PlanUnitsUnit()
+	return !isMemberOf
+}
+
+// vehicleSequence is a new route of a vehicle, the stops include the first
+// and last stop of the vehicle.
+type vehicleSequence struct {
+	vehicle int
+	stops   ModelStops
+}
+
+// resequence changes the routes of the vehicles to the sequences. The plan
+// units of the moved stops are un-planned and planned in the order of the
+// sequences. If that fails or does not improve the score, the plan units are
+// planned again in their original order. Returns true if the routes have
+// been changed.
+func resequence(
+	ctx context.Context,
+	solution *solutionImpl,
+	moved ModelStops,
+	sequences ...vehicleSequence,
+) (bool, error) {
+	units := make(ModelPlanStopsUnits, 0, len(moved))
+	for _, stop := range moved {
+		if !slices.Contains(units, stop.PlanStopsUnit()) {
+			units = append(units, stop.PlanStopsUnit())
+		}
+	}
+	for _, unit := range units {
+		for _, sequence := range sequences {
+			stops := common.Filter(sequence.stops, func(stop ModelStop) bool {
+				return stop.HasPlanStopsUnit() && stop.PlanStopsUnit() == unit
+			})
+			if len(stops) == 0 {
+				continue
+			}
+			allowed, err := unit.DirectedAcyclicGraph().IsAllowed(stops)
+			if err != nil || !allowed {
+				return false, err
+			}
+		}
+	}
+
+	original := make([]vehicleSequence, len(sequences))
+	for idx, sequence := range sequences {
+		original[idx] = vehicleSequence{
+			vehicle: sequence.vehicle,
+			stops:   modelStops(solution.vehicles[sequence.vehicle].SolutionStops()),
+		}
+	}
+	score := solution.Score()
+	changed, err := replan(ctx, solution, units, sequences)
+	if err != nil {
+		return false, err
+	}
+	if changed && solution.Score() < score {
+		return true, nil
+	}
+	return false, restore(ctx, solution, units, original)
+}
+
+// replan un-plans the units and plans them on the vehicles in the order of
+// the sequences. Returns false if a unit can not be un-planned or planned,
+// the units planned so far stay planned in that case.
+func replan(
+	ctx context.Context,
+	solution *solutionImpl,
+	units ModelPlanStopsUnits,
+	sequences []vehicleSequence,
+) (bool, error) {
+	for _, unit := range units {
+		unplanned, err := solution.SolutionPlanStopsUnit(unit).UnPlan()
+		if err != nil || !unplanned {
+			return false, err
+		}
+	}
+	for _, sequence := range sequences {
+		planned, err := planSequence(ctx, solution.vehicles[sequence.vehicle], sequence.stops, units)
+		if err != nil || !planned {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// restore plans the units on the vehicles in the order of the original
+// sequences, un-planning the units which are planned. The original
+// sequences were planned before, a unit which can still not be planned is
+// left un-planned.
+func restore(
+	ctx context.Context,
+	solution *solutionImpl,
+	units ModelPlanStopsUnits,
+	original []vehicleSequence,
+) error {
+	for _, unit := range units {
+		solutionUnit := solution.SolutionPlanStopsUnit(unit)
+		if !solutionUnit.IsPlanned() {
+			continue
+		}
+		if _, err := solutionUnit.UnPlan(); err != nil {
+			return err
+		}
+	}
+	for _, sequence := range original {
+		vehicle := solution.vehicles[sequence.vehicle]
+		for _, unit := range units {
+			if _, err := planSequence(ctx, vehicle, sequence.stops, ModelPlanStopsUnits{unit}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// planSequence plans the un-planned units which have stops in the sequence on
+// the vehicle in the order of the sequence.
+func planSequence(
+	ctx context.Context,
+	vehicle SolutionVehicle,
+	sequence ModelStops,
+	units ModelPlanStopsUnits,
+) (bool, error) {
+	solution := vehicle.solution
+	solutionStop := func(idx int) SolutionStop {
+		switch idx {
+		case 0:
+			return vehicle.First()
+		case len(sequence) - 1:
+			return vehicle.Last()
+		}
+		return solution.SolutionStop(sequence[idx])
+	}
+	for _, unit := range units {
+		isUnitOrPlanned := func(idx int) bool {
+			return sequence[idx].HasPlanStopsUnit() && sequence[idx].PlanStopsUnit() == unit ||
+				solutionStop(idx).IsPlanned()
+		}
+		stopPositions := make(StopPositions, 0, unit.NumberOfStops())
+		for idx, stop := range sequence {
+			if !stop.HasPlanStopsUnit() || stop.PlanStopsUnit() != unit {
+				continue
+			}
+			previous := idx - 1
+			for !isUnitOrPlanned(previous) {
+				previous--
+			}
+			next := idx + 1
+			for !isUnitOrPlanned(next) {
+				next++
+			}
+			stopPosition, err := NewStopPosition(
+				solutionStop(previous),
+				solutionStop(idx),
+				solutionStop(next),
+			)
+			if err != nil {
+				return false, err
+			}
+			stopPositions = append(stopPositions, stopPosition)
+		}
+		if len(stopPositions) == 0 {
+			continue
+		}
+		move, err := NewMoveStops(solution.SolutionPlanStopsUnit(unit), stopPositions)
+		if err != nil || !move.IsExecutable() {
+			return false, err
+		}
+		planned, err := move.Execute(ctx)
+		if err != nil || !planned {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,171 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestSolveOperatorIntraRoute(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		return Location{Lon: -74.06 + 0.005*float64(idx), Lat: 4.70}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 1),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planRoutes(t, solution, [][]int{{0, 3, 1, 4, 2, 5}})
+
+	if _, err := nextroute.NewSolveOperatorIntraRoute(1.5); err == nil {
+		t.Error("expected an error for a probability larger than 1")
+	}
+	operator, err := nextroute.NewSolveOperatorIntraRoute(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(operator)
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 1, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err := solutions.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if best.Score() >= solution.Score() {
+		t.Errorf("expected score %v to improve on %v", best.Score(), solution.Score())
+	}
+	route := make([]int, 0, len(stops))
+	for _, stop := range best.Vehicles()[0].SolutionStops() {
+		if stop.IsFirst() || stop.IsLast() {
+			continue
+		}
+		route = append(route, stop.ModelStopIndex())
+	}
+	reversed := slices.Clone(route)
+	slices.Reverse(reversed)
+	if !slices.IsSorted(route) && !slices.IsSorted(reversed) {
+		t.Errorf("expected the stops on a line to be visited in order, got %v", route)
+	}
+}
+
+// keepPrevious is a constraint which, once enabled, is violated by planning
+// a stop after another stop than the stop it followed when enabled.
+type keepPrevious struct {
+	previous map[int]int
+}
+
+func (k *keepPrevious) enable(vehicle nextroute.SolutionVehicle) {
+	k.previous = map[int]int{}
+	for _, stop := range vehicle.SolutionStops() {
+		if !stop.IsFirst() {
+			k.previous[stop.ModelStopIndex()] = stop.Previous().ModelStopIndex()
+		}
+	}
+}
+
+func (k *keepPrevious) EstimateIsViolated(
+	move nextroute.SolutionMoveStops,
+) (bool, nextroute.StopPositionsHint) {
+	for _, position := range move.StopPositions() {
+		previous, ok := k.previous[position.Stop().ModelStopIndex()]
+		if ok && position.Previous().ModelStopIndex() != previous {
+			return true, nextroute.NoPositionsHint()
+		}
+	}
+	return false, nextroute.NoPositionsHint()
+}
+
+// TestSolveOperatorIntraRouteRestore expects the route to be restored if the
+// improving moves cannot be planned.
+func TestSolveOperatorIntraRouteRestore(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		return Location{Lon: -74.06 + 0.005*float64(idx), Lat: 4.70}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 1),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	constraint := &keepPrevious{}
+	if err := model.AddConstraint(constraint); err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planRoutes(t, solution, [][]int{{0, 3, 1, 4, 2, 5}})
+	constraint.enable(solution.Vehicles()[0])
+
+	operator, err := nextroute.NewSolveOperatorIntraRoute(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(operator)
+	var route []int
+	var score float64
+	solver.SolveEvents().Iterated.Register(func(information nextroute.SolveInformation) {
+		work := information.Solver().WorkSolution()
+		score = work.Score()
+		for _, stop := range work.Vehicles()[0].SolutionStops() {
+			if !stop.IsFirst() && !stop.IsLast() {
+				route = append(route, stop.ModelStopIndex())
+			}
+		}
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 1, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(route, []int{0, 3, 1, 4, 2, 5}) || score != solution.Score() {
+		t.Errorf("expected the route [0 3 1 4 2 5] with score %v, got %v with score %v", solution.Score(), route, score)
+	}
+}
@@ -0,0 +1,168 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// SolveOperatorPlanRegret is a solve-operator that plans all unplanned
+// plan-units in each iteration using regret-k insertion. Like
+// [SolveOperatorPlan] it selects a random group-size number of unplanned
+// plan-units, instead of executing the best move of the group it executes
+// the best move of the plan-unit with the largest regret. The regret of a
+// plan-unit is the sum of the differences between the value of its best
+// move and the values of its best moves on the next k-1 best vehicles, see
+// [SolutionVehicles.BestMoves]. A plan-unit which can be planned on fewer
+// than k vehicles is planned before plan-units with more options, the fewer
+// options the earlier. Ties are broken by the value of the best move.
+type SolveOperatorPlanRegret interface {
+	SolveOperatorPlan
+
+	// K returns the number of vehicles compared for the regret of a
+	// plan-unit.
+	K() int
+}
+
+// NewSolveOperatorPlanRegret creates a new solve operator for nextroute that
+// plans units using regret-k insertion. K must be at least 2.
+func NewSolveOperatorPlanRegret(
+	groupSize SolveParameter,
+	k int,
+) (SolveOperatorPlanRegret, error) {
+	if k < 2 {
+		return nil, fmt.Errorf(
+			"regret k %v must be at least 2",
+			k,
+		)
+	}
+	return &solveOperatorPlanRegretImpl{
+		SolveOperator: NewSolveOperator(
+			1.0,
+			true,
+			SolveParameters{groupSize},
+		),
+		k: k,
+	}, nil
+}
+
+type solveOperatorPlanRegretImpl struct {
+	SolveOperator
+	k int
+}
+
+func (d *solveOperatorPlanRegretImpl) GroupSize() SolveParameter {
+	return d.Parameters()[0]
+}
+
+func (d *solveOperatorPlanRegretImpl) K() int {
+	return d.k
+}
+
+// regretCandidate is the best move of a plan-unit and its regret.
+type regretCandidate struct {
+	move    SolutionMove
+	options int
+	regret  float64
+}
+
+// compare returns a negative number if the candidate should be planned
+// before the other candidate.
+func (c regretCandidate) compare(other regretCandidate, k int) int {
+	if result := cmp.Compare(min(c.options, k), min(other.options, k)); result != 0 {
+		return result
+	}
+	if result := cmp.Compare(other.regret, c.regret); result != 0 {
+		return result
+	}
+	return cmp.Compare(c.move.Value(), other.move.Value())
+}
+
+func (d *solveOperatorPlanRegretImpl) Execute(
+	ctx context.Context,
+	runTimeInformation SolveInformation,
+) error {
+	workSolution := runTimeInformation.
+		Solver().
+		WorkSolution()
+
+	unplannedPlanUnits := NewSolutionPlanUnitCollection(
+		workSolution.Random(),
+		workSolution.UnPlannedPlanUnits().SolutionPlanUnits(),
+	)
+
+	vehicles := workSolution.Vehicles()
+
+Loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break Loop
+		default:
+			if unplannedPlanUnits.Size() == 0 {
+				break Loop
+			}
+
+			planUnits := unplannedPlanUnits.RandomDraw(
+				d.GroupSize().Value(),
+			)
+
+			var best *regretCandidate
+
+			for _, planUnit := range planUnits {
+				candidate, ok := d.candidate(ctx, vehicles, planUnit)
+				if !ok {
+					unplannedPlanUnits.Remove(planUnit)
+					continue
+				}
+				if best == nil || candidate.compare(*best, d.k) < 0 {
+					best = &candidate
+				}
+			}
+
+			if best != nil {
+				if best.move.Value() <= 0 {
+					_, err := best.move.Execute(ctx)
+					if err != nil {
+						return err
+					}
+				}
+				unplannedPlanUnits.Remove(best.move.PlanUnit())
+			}
+		}
+	}
+	return nil
+}
+
+// candidate returns the best move of the plan-unit and its regret, false if
+// the plan-unit can not be planned on any vehicle.
+func (d *solveOperatorPlanRegretImpl) candidate(
+	ctx context.Context,
+	vehicles SolutionVehicles,
+	planUnit SolutionPlanUnit,
+) (regretCandidate, bool) {
+	moves := vehicles.BestMoves(ctx, planUnit)
+	executable := make(SolutionMoves, 0, len(moves))
+	for _, move := range moves {
+		if move.IsExecutable() {
+			executable = append(executable, move)
+		}
+	}
+	if len(executable) == 0 {
+		return regretCandidate{}, false
+	}
+	slices.SortStableFunc(executable, func(a, b SolutionMove) int {
+		return cmp.Compare(a.Value(), b.Value())
+	})
+	candidate := regretCandidate{
+		move:    executable[0],
+		options: len(executable),
+	}
+	for _, move := range executable[1:min(d.k, len(executable))] {
+		candidate.regret += move.Value() - executable[0].Value()
+	}
+	return candidate, true
+}
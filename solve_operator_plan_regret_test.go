@@ -0,0 +1,103 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestSolveOperatorPlanRegret(t *testing.T) {
+	stops := planSingleStops()[:2]
+	model, err := createModel(input(
+		vehicleTypes("truck", "car"),
+		[]Vehicle{
+			vehicle("truck", stops[1].Stop.Location),
+			vehicle("car", depot()),
+		},
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each vehicle can visit one stop and the first stop can only be visited
+	// by the truck. Greedy insertion plans the second stop on the truck as it
+	// starts at the second stop.
+	maximumStops, err := nextroute.NewMaximumStopsConstraint(
+		nextroute.NewVehicleTypeValueExpression("maximum_stops", 1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := model.AddConstraint(maximumStops); err != nil {
+		t.Fatal(err)
+	}
+	attributes, err := nextroute.NewAttributesConstraint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := model.AddConstraint(attributes); err != nil {
+		t.Fatal(err)
+	}
+	if err := attributes.SetStopAttributes(model.Stops()[0], []string{"truck"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := attributes.SetVehicleTypeAttributes(model.VehicleTypes()[0], []string{"truck"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	unplannedPenalty := nextroute.NewStopExpression("unplanned_penalty", 1000)
+	if _, err := model.Objective().NewTerm(1, nextroute.NewUnPlannedObjective(unplannedPenalty)); err != nil {
+		t.Fatal(err)
+	}
+
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nextroute.NewSolveOperatorPlanRegret(groupSize, 1); err == nil {
+		t.Error("expected an error for a k smaller than 2")
+	}
+	plan, err := nextroute.NewSolveOperatorPlanRegret(groupSize, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.GroupSize() != groupSize || plan.K() != 2 {
+		t.Error("expected the parameters of the operator")
+	}
+
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(plan)
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 1, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, err := solutions.Last()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.UnPlannedPlanUnits().Size() != 0 {
+		t.Errorf("expected all stops to be planned, got %v unplanned", best.UnPlannedPlanUnits().Size())
+	}
+	if !best.SolutionStop(model.Stops()[0]).IsPlanned() ||
+		best.SolutionStop(model.Stops()[0]).Vehicle().Index() != 0 {
+		t.Error("expected the first stop to be planned on the truck")
+	}
+}
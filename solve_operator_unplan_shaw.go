@@ -0,0 +1,274 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/nextmv-io/nextroute/common"
+)
+
+// ShawRelatedness are the weights of the relatedness of two plan units used
+// by [NewSolveOperatorUnPlanShaw].
+type ShawRelatedness struct {
+	// Distance is the weight of the distance between the stops.
+	Distance float64 `json:"distance" usage:"weight of the distance between the stops" default:"9"`
+	// TimeWindow is the weight of the time windows of the stops not
+	// overlapping.
+	TimeWindow float64 `json:"time_window" usage:"weight of the time windows of the stops not overlapping" default:"3"`
+	// Demand is the weight of the difference of the demand of the stops.
+	Demand float64 `json:"demand" usage:"weight of the difference of the demand of the stops" default:"2"`
+}
+
+// DefaultShawRelatedness returns the default weights of the relatedness of
+// two plan units.
+func DefaultShawRelatedness() ShawRelatedness {
+	return ShawRelatedness{
+		Distance:   9,
+		TimeWindow: 3,
+		Demand:     2,
+	}
+}
+
+// SolveOperatorUnPlanShaw is a solve operator that un-plans related plan
+// units.
+type SolveOperatorUnPlanShaw interface {
+	SolveOperator
+
+	// NumberOfUnits returns the number of units un-planned by the solve
+	// operator.
+	NumberOfUnits() SolveParameter
+	// Randomization returns the randomization exponent of the solve operator.
+	Randomization() float64
+	// Relatedness returns the weights of the relatedness of two plan units.
+	Relatedness() ShawRelatedness
+}
+
+// NewSolveOperatorUnPlanShaw creates a new SolveOperatorUnPlanShaw.
+// SolveOperatorUnPlanShaw is a solve-operator which un-plans related plan
+// units as in Shaw removal. A random planned unit is un-planned first, after
+// that a random un-planned unit is selected in each step and the planned
+// units are sorted by their relatedness to it. The relatedness of two units
+// is the weighted sum of the distance between their stops, the time windows
+// of their stops not overlapping and the difference of the demand of their
+// stops, each normalized to a value between 0 and 1. The demand of a stop is
+// the value of the expressions of the [Maximum] constraints of the model
+// which are a [StopExpression], other expressions depend on the vehicle type
+// or the previous stop.
+//
+// The unit at index y^randomization * n is un-planned, see
+// [NewSolveOperatorUnPlanWorst]. The number of units is a solve-parameter
+// which can be configured by the user. The solve operator is executed with
+// the given probability.
+func NewSolveOperatorUnPlanShaw(
+	probability float64,
+	numberOfUnits SolveParameter,
+	randomization float64,
+	relatedness ShawRelatedness,
+) (SolveOperatorUnPlanShaw, error) {
+	if probability < 0 || probability > 1 {
+		return nil, fmt.Errorf(
+			"probability %v must be between 0 and 1",
+			probability,
+		)
+	}
+	if randomization < 1 {
+		return nil, fmt.Errorf(
+			"randomization %v must be at least 1",
+			randomization,
+		)
+	}
+	if relatedness.Distance < 0 || relatedness.TimeWindow < 0 || relatedness.Demand < 0 {
+		return nil, fmt.Errorf(
+			"relatedness weights %+v must not be negative",
+			relatedness,
+		)
+	}
+	return &solveOperatorUnPlanShawImpl{
+		SolveOperator: NewSolveOperator(
+			probability,
+			false,
+			SolveParameters{numberOfUnits},
+		),
+		randomization: randomization,
+		relatedness:   relatedness,
+	}, nil
+}
+
+type solveOperatorUnPlanShawImpl struct {
+	SolveOperator
+	randomization float64
+	relatedness   ShawRelatedness
+}
+
+func (d *solveOperatorUnPlanShawImpl) NumberOfUnits() SolveParameter {
+	return d.Parameters()[0]
+}
+
+func (d *solveOperatorUnPlanShawImpl) Randomization() float64 {
+	return d.randomization
+}
+
+func (d *solveOperatorUnPlanShawImpl) Relatedness() ShawRelatedness {
+	return d.relatedness
+}
+
+// shawCandidate is a planned plan unit and the components of its relatedness
+// to a reference unit.
+type shawCandidate struct {
+	planUnit    SolutionPlanUnit
+	distance    float64
+	timeWindow  float64
+	demand      float64
+	relatedness float64
+}
+
+func (d *solveOperatorUnPlanShawImpl) Execute(
+	ctx context.Context,
+	runTimeInformation SolveInformation,
+) error {
+	workSolution := runTimeInformation.
+		Solver().
+		WorkSolution()
+
+	random := runTimeInformation.Solver().Random()
+
+	numberOfUnits := d.NumberOfUnits().Value()
+
+	planUnits := common.Filter(
+		workSolution.PlannedPlanUnits().SolutionPlanUnits(),
+		func(planUnit SolutionPlanUnit) bool {
+			return !planUnit.IsFixed()
+		},
+	)
+	if len(planUnits) == 0 || numberOfUnits < 1 {
+		return nil
+	}
+
+	demands := make([]StopExpression, 0)
+	for _, constraint := range workSolution.Model().Constraints() {
+		if maximum, ok := constraint.(Maximum); ok {
+			if demand, ok := maximum.Expression().(StopExpression); ok {
+				demands = append(demands, demand)
+			}
+		}
+	}
+
+	seed := planUnits[random.Intn(len(planUnits))]
+	removed := plannedModelStops(seed)
+	if _, err := seed.UnPlan(); err != nil {
+		return err
+	}
+	related := []ModelStops{removed}
+
+	for i := 1; i < numberOfUnits; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			reference := related[random.Intn(len(related))]
+			candidates := make([]shawCandidate, 0, workSolution.PlannedPlanUnits().Size())
+			maxDistance, maxDemand := 0.0, 0.0
+			for _, planUnit := range workSolution.PlannedPlanUnits().SolutionPlanUnits() {
+				if planUnit.IsFixed() {
+					continue
+				}
+				candidate := d.candidate(planUnit, reference, demands)
+				maxDistance = math.Max(maxDistance, candidate.distance)
+				maxDemand = math.Max(maxDemand, candidate.demand)
+				candidates = append(candidates, candidate)
+			}
+			if len(candidates) == 0 {
+				return nil
+			}
+			for c := range candidates {
+				candidates[c].relatedness = d.relatedness.TimeWindow * candidates[c].timeWindow
+				if maxDistance > 0 {
+					candidates[c].relatedness += d.relatedness.Distance * candidates[c].distance / maxDistance
+				}
+				if maxDemand > 0 {
+					candidates[c].relatedness += d.relatedness.Demand * candidates[c].demand / maxDemand
+				}
+			}
+			slices.SortStableFunc(candidates, func(a, b shawCandidate) int {
+				return cmp.Compare(a.relatedness, b.relatedness)
+			})
+			planUnit := candidates[randomizedIndex(random, len(candidates), d.randomization)].planUnit
+			stops := plannedModelStops(planUnit)
+			if _, err := planUnit.UnPlan(); err != nil {
+				return err
+			}
+			related = append(related, stops)
+		}
+	}
+
+	return nil
+}
+
+// candidate returns the components of the relatedness of the plan unit to
+// the reference stops. The stops are compared in the order of their plan
+// units, the components are averaged over the compared stops.
+func (d *solveOperatorUnPlanShawImpl) candidate(
+	planUnit SolutionPlanUnit,
+	reference ModelStops,
+	demands []StopExpression,
+) shawCandidate {
+	candidate := shawCandidate{planUnit: planUnit}
+	stops := plannedModelStops(planUnit)
+	n := min(len(stops), len(reference))
+	for s := 0; s < n; s++ {
+		a, b := stops[s], reference[s]
+		if a.Location().IsValid() && b.Location().IsValid() {
+			candidate.distance += haversineDistance(a.Location(), b.Location()).
+				Value(common.Kilometers)
+		}
+		candidate.timeWindow += timeWindowsDisjointness(a, b)
+		for _, demand := range demands {
+			candidate.demand += math.Abs(demand.Value(nil, nil, a) - demand.Value(nil, nil, b))
+		}
+	}
+	if n > 0 {
+		candidate.distance /= float64(n)
+		candidate.timeWindow /= float64(n)
+		candidate.demand /= float64(n)
+	}
+	return candidate
+}
+
+// timeWindowsDisjointness returns 1 minus the overlap of the spans of the
+// time windows of the stops relative to the shorter span, 0 if one of the
+// stops has no time windows.
+func timeWindowsDisjointness(a, b ModelStop) float64 {
+	windowsA, windowsB := a.Windows(), b.Windows()
+	if len(windowsA) == 0 || len(windowsB) == 0 {
+		return 0
+	}
+	startA, endA := windowsA[0][0], windowsA[len(windowsA)-1][1]
+	startB, endB := windowsB[0][0], windowsB[len(windowsB)-1][1]
+	shorter := math.Min(endA.Sub(startA).Seconds(), endB.Sub(startB).Seconds())
+	if shorter <= 0 {
+		return 0
+	}
+	overlap := endA.Sub(startA).Seconds()
+	if endB.Before(endA) {
+		overlap = endB.Sub(startA).Seconds()
+	}
+	if startB.After(startA) {
+		overlap -= startB.Sub(startA).Seconds()
+	}
+	return 1 - math.Max(0, math.Min(overlap, shorter))/shorter
+}
+
+// plannedModelStops returns the model stops of the planned plan stops units
+// of the plan unit.
+func plannedModelStops(planUnit SolutionPlanUnit) ModelStops {
+	stops := make(ModelStops, 0)
+	for _, planStopsUnit := range planUnit.PlannedPlanStopsUnits() {
+		stops = append(stops, planStopsUnit.ModelPlanStopsUnit().Stops()...)
+	}
+	return stops
+}
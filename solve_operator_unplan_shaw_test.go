@@ -0,0 +1,207 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/nextroute/common"
+)
+
+func TestSolveOperatorUnPlanShaw(t *testing.T) {
+	stops := stopsAt(12, func(idx int) Location {
+		lon := -74.07
+		if idx >= 6 {
+			lon = -73.07
+		}
+		return Location{Lon: lon + 0.002*float64(idx%6), Lat: 4.70}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each vehicle visits the stops of its own cluster.
+	planRoutes(t, solution, [][]int{{0, 1, 2, 3, 4, 5}, {6, 7, 8, 9, 10, 11}})
+
+	numberOfUnits, err := nextroute.NewSolveParameter(4, 1000, 0, 4, 4, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relatedness := nextroute.DefaultShawRelatedness()
+	relatedness.Distance = -1
+	if _, err := nextroute.NewSolveOperatorUnPlanShaw(1, numberOfUnits, 6, relatedness); err == nil {
+		t.Error("expected an error for a negative relatedness weight")
+	}
+	if _, err := nextroute.NewSolveOperatorUnPlanShaw(1, numberOfUnits, 0, nextroute.DefaultShawRelatedness()); err == nil {
+		t.Error("expected an error for a randomization smaller than 1")
+	}
+	// A large randomization always selects the most related plan unit.
+	unplanShaw, err := nextroute.NewSolveOperatorUnPlanShaw(1, numberOfUnits, 1000, nextroute.DefaultShawRelatedness())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unplanShaw.NumberOfUnits() != numberOfUnits ||
+		unplanShaw.Randomization() != 1000 ||
+		unplanShaw.Relatedness() != nextroute.DefaultShawRelatedness() {
+		t.Error("expected the parameters of the operator")
+	}
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := nextroute.NewSolveOperatorPlan(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(unplanShaw, plan)
+	executions := 0
+	solver.SolveEvents().OperatorExecuted.Register(func(information nextroute.SolveInformation) {
+		operators := information.SolveOperators()
+		if operators[len(operators)-1] != unplanShaw {
+			return
+		}
+		executions++
+		unplanned := information.Solver().WorkSolution().UnPlannedPlanUnits().SolutionPlanUnits()
+		if len(unplanned) != 4 {
+			t.Fatalf("expected 4 un-planned plan units, got %v", len(unplanned))
+		}
+		// The related plan units are in the same cluster.
+		clusters := map[bool]bool{}
+		for _, planUnit := range unplanned {
+			clusters[planUnit.ModelPlanUnit().Index() >= 6] = true
+		}
+		if len(clusters) != 1 {
+			t.Errorf("expected the un-planned plan units to be in one cluster, got %v", unplanned)
+		}
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 20, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+	if executions == 0 {
+		t.Error("expected the operator to be executed")
+	}
+}
+
+// TestSolveOperatorUnPlanShawDistanceLimit expects the demand of a model
+// with a distance limit, a maximum of an expression depending on the vehicle
+// type, to be based on its stop expressions only.
+func TestSolveOperatorUnPlanShawDistanceLimit(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		return Location{Lon: -74.06 + 0.002*float64(idx), Lat: 4.70}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 1),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	distance := nextroute.NewComposedPerVehicleTypeExpression(
+		nextroute.NewConstantExpression("constant-route-distance", 0),
+	)
+	distance.Set(model.VehicleTypes()[0], nextroute.NewHaversineExpression())
+	distanceLimit, err := nextroute.NewMaximum(
+		distance,
+		nextroute.NewVehicleTypeDistanceExpression("distance_limit", common.NewDistance(100, common.Kilometers)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quantity := nextroute.NewStopExpression("quantity", 1)
+	capacity, err := nextroute.NewMaximum(
+		quantity,
+		nextroute.NewVehicleTypeValueExpression("capacity", 10),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, constraint := range []nextroute.ModelConstraint{distanceLimit, capacity} {
+		if err := model.AddConstraint(constraint); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planRoutes(t, solution, [][]int{{0, 1, 2, 3, 4, 5}})
+
+	numberOfUnits, err := nextroute.NewSolveParameter(3, 1000, 0, 3, 3, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unplanShaw, err := nextroute.NewSolveOperatorUnPlanShaw(1, numberOfUnits, 6, nextroute.DefaultShawRelatedness())
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := nextroute.NewSolveOperatorPlan(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(unplanShaw, plan)
+	executions := 0
+	solver.SolveEvents().OperatorExecuted.Register(func(information nextroute.SolveInformation) {
+		operators := information.SolveOperators()
+		if operators[len(operators)-1] != unplanShaw {
+			return
+		}
+		executions++
+		unplanned := information.Solver().WorkSolution().UnPlannedPlanUnits().Size()
+		if unplanned != 3 {
+			t.Errorf("expected 3 un-planned plan units, got %v", unplanned)
+		}
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 1, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+	if executions == 0 {
+		t.Error("expected the operator to be executed")
+	}
+}
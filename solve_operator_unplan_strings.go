@@ -0,0 +1,167 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// SolveOperatorUnPlanStrings is a solve operator that un-plans strings of
+// consecutive stops from spatially adjacent routes.
+type SolveOperatorUnPlanStrings interface {
+	SolveOperator
+
+	// NumberOfUnits returns the average number of stops un-planned by the
+	// solve operator.
+	NumberOfUnits() SolveParameter
+	// StringLength returns the average number of stops of a string.
+	StringLength() SolveParameter
+}
+
+// NewSolveOperatorUnPlanStrings creates a new SolveOperatorUnPlanStrings.
+// SolveOperatorUnPlanStrings is a solve-operator which un-plans strings of
+// consecutive stops as in Slack Induction by String Removals (SISR). A random
+// planned stop is selected as the seed, for the seed and its closest stops,
+// see [ModelStopsDistanceQueries], a string containing the stop is un-planned
+// from its route until the number of strings is reached. Each route is
+// ruined once.
+//
+// The length of a string is sampled uniformly between 1 and twice the
+// string length minus 1, limited by the number of stops of the route and the
+// average number of stops of the routes. The number of strings is sampled uniformly
+// so that on average the number of units are un-planned. Both are
+// solve-parameters which can be configured by the user. The solve operator
+// is executed with the given probability.
+func NewSolveOperatorUnPlanStrings(
+	probability float64,
+	numberOfUnits SolveParameter,
+	stringLength SolveParameter,
+) (SolveOperatorUnPlanStrings, error) {
+	if probability < 0 || probability > 1 {
+		return nil, fmt.Errorf(
+			"probability %v must be between 0 and 1",
+			probability,
+		)
+	}
+	return &solveOperatorUnPlanStringsImpl{
+		SolveOperator: NewSolveOperator(
+			probability,
+			false,
+			SolveParameters{numberOfUnits, stringLength},
+		),
+	}, nil
+}
+
+type solveOperatorUnPlanStringsImpl struct {
+	SolveOperator
+}
+
+func (d *solveOperatorUnPlanStringsImpl) NumberOfUnits() SolveParameter {
+	return d.Parameters()[0]
+}
+
+func (d *solveOperatorUnPlanStringsImpl) StringLength() SolveParameter {
+	return d.Parameters()[1]
+}
+
+func (d *solveOperatorUnPlanStringsImpl) Execute(
+	ctx context.Context,
+	runTimeInformation SolveInformation,
+) error {
+	workSolution := runTimeInformation.
+		Solver().
+		WorkSolution()
+
+	random := runTimeInformation.Solver().Random()
+
+	if workSolution.PlannedPlanUnits().Size() == 0 {
+		return nil
+	}
+
+	plannedStops, routes := 0, 0
+	for _, vehicle := range workSolution.Vehicles() {
+		if !vehicle.IsEmpty() {
+			plannedStops += vehicle.NumberOfStops()
+			routes++
+		}
+	}
+	if routes == 0 {
+		return nil
+	}
+
+	maxStringLength := math.Max(1, math.Min(
+		float64(2*d.StringLength().Value()-1),
+		float64(plannedStops)/float64(routes),
+	))
+	maxStrings := 4*float64(d.NumberOfUnits().Value())/(1+maxStringLength) - 1
+	numberOfStrings := int(random.Float64()*math.Max(maxStrings, 1)) + 1
+
+	planUnit := workSolution.PlannedPlanUnits().RandomElement()
+	planStopsUnits := planUnit.PlannedPlanStopsUnits()
+	solutionStops := planStopsUnits[random.Intn(len(planStopsUnits))].SolutionStops()
+	seed := solutionStops[random.Intn(len(solutionStops))].ModelStop()
+
+	stops := ModelStops{seed}
+	if seed.Location().IsValid() {
+		closestStops, err := seed.(*stopImpl).closestStops()
+		if err != nil {
+			return err
+		}
+		stops = append(stops, closestStops...)
+	}
+
+	ruined := make(map[int]bool, numberOfStrings)
+	for _, stop := range stops {
+		if len(ruined) >= numberOfStrings {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			if !stop.HasPlanStopsUnit() {
+				continue
+			}
+			solutionStop := workSolution.SolutionStop(stop)
+			if !solutionStop.IsPlanned() || ruined[solutionStop.VehicleIndex()] {
+				continue
+			}
+			ruined[solutionStop.VehicleIndex()] = true
+			if err := d.unplanString(solutionStop, maxStringLength, random); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// unplanString un-plans a string of consecutive stops containing the stop
+// from its route, the length and the start of the string are random.
+func (d *solveOperatorUnPlanStringsImpl) unplanString(
+	solutionStop SolutionStop,
+	maxStringLength float64,
+	random *rand.Rand,
+) error {
+	route := solutionStop.Vehicle().SolutionStops()
+	numberOfStops := len(route) - 2
+	length := int(random.Float64()*math.Min(maxStringLength, float64(numberOfStops))) + 1
+
+	position := solutionStop.Position()
+	first := max(1, position-length+1)
+	last := min(position, numberOfStops-length+1)
+	start := first + random.Intn(last-first+1)
+
+	for _, stop := range route[start : start+length] {
+		if !stop.IsPlanned() || stop.IsFixed() {
+			continue
+		}
+		if _, err := stop.PlanStopsUnit().UnPlan(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestSolveOperatorUnPlanStrings(t *testing.T) {
+	stops := stopsAt(12, func(idx int) Location {
+		return Location{Lon: -74.06 + 0.002*float64(idx%6), Lat: 4.70 + 0.002*float64(idx/6)}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 3),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planRoutes(t, solution, [][]int{{0, 1, 2, 3, 4, 5}, {6, 7, 8, 9, 10, 11}})
+
+	numberOfUnits, err := nextroute.NewSolveParameter(4, 1000, 0, 4, 4, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stringLength, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nextroute.NewSolveOperatorUnPlanStrings(2, numberOfUnits, stringLength); err == nil {
+		t.Error("expected an error for a probability larger than 1")
+	}
+	unplanStrings, err := nextroute.NewSolveOperatorUnPlanStrings(1, numberOfUnits, stringLength)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unplanStrings.NumberOfUnits() != numberOfUnits || unplanStrings.StringLength() != stringLength {
+		t.Error("expected the parameters of the operator")
+	}
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := nextroute.NewSolveOperatorPlan(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(unplanStrings, plan)
+	isExecuting := func(information nextroute.SolveInformation) bool {
+		operators := information.SolveOperators()
+		return operators[len(operators)-1] == unplanStrings
+	}
+	var before []nextroute.SolutionStops
+	solver.SolveEvents().OperatorExecuting.Register(func(information nextroute.SolveInformation) {
+		if !isExecuting(information) {
+			return
+		}
+		before = before[:0]
+		for _, vehicle := range information.Solver().WorkSolution().Vehicles() {
+			before = append(before, vehicle.SolutionStops())
+		}
+	})
+	executions := 0
+	solver.SolveEvents().OperatorExecuted.Register(func(information nextroute.SolveInformation) {
+		if !isExecuting(information) {
+			return
+		}
+		executions++
+		unplanned := 0
+		for vehicleIndex, route := range before {
+			// The un-planned stops of a route form a single string.
+			last := -1
+			for position, stop := range route {
+				if stop.IsFirst() || stop.IsLast() || stop.IsPlanned() {
+					continue
+				}
+				unplanned++
+				if last != -1 && last != position-1 {
+					t.Errorf("vehicle %v: expected a string of stops, got a gap before %v", vehicleIndex, position)
+				}
+				last = position
+			}
+		}
+		if unplanned == 0 {
+			t.Error("expected stops to be un-planned")
+		}
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 20, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+	if executions == 0 {
+		t.Error("expected the operator to be executed")
+	}
+}
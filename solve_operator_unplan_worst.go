@@ -0,0 +1,183 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+)
+
+// SolveOperatorUnPlanWorst is a solve operator that un-plans the plan units
+// with the largest cost saving when removed.
+type SolveOperatorUnPlanWorst interface {
+	SolveOperator
+
+	// NumberOfUnits returns the number of units un-planned by the solve
+	// operator.
+	NumberOfUnits() SolveParameter
+	// Randomization returns the randomization exponent of the solve operator.
+	Randomization() float64
+}
+
+// NewSolveOperatorUnPlanWorst creates a new SolveOperatorUnPlanWorst.
+// SolveOperatorUnPlanWorst is a solve-operator which un-plans the planned
+// plan units with the largest cost saving one at a time. The cost saving of a
+// plan unit is the decrease of the score of the solution when it is
+// un-planned, it is evaluated by un-planning the plan unit and planning it
+// again at its positions.
+//
+// The plan units are sorted by decreasing cost saving and the unit at index
+// y^randomization * n is un-planned, y being a uniform random number between 0
+// and 1 and n the number of planned units. A randomization of 1 selects the
+// units uniformly, the larger the randomization the more likely the worst
+// unit is un-planned. The randomization must be at least 1. The number of
+// units is a solve-parameter which can be configured by the user. The solve
+// operator is executed with the given probability.
+func NewSolveOperatorUnPlanWorst(
+	probability float64,
+	numberOfUnits SolveParameter,
+	randomization float64,
+) (SolveOperatorUnPlanWorst, error) {
+	if probability < 0 || probability > 1 {
+		return nil, fmt.Errorf(
+			"probability %v must be between 0 and 1",
+			probability,
+		)
+	}
+	if randomization < 1 {
+		return nil, fmt.Errorf(
+			"randomization %v must be at least 1",
+			randomization,
+		)
+	}
+	return &solveOperatorUnPlanWorstImpl{
+		SolveOperator: NewSolveOperator(
+			probability,
+			false,
+			SolveParameters{numberOfUnits},
+		),
+		randomization: randomization,
+	}, nil
+}
+
+type solveOperatorUnPlanWorstImpl struct {
+	SolveOperator
+	randomization float64
+}
+
+func (d *solveOperatorUnPlanWorstImpl) NumberOfUnits() SolveParameter {
+	return d.Parameters()[0]
+}
+
+func (d *solveOperatorUnPlanWorstImpl) Randomization() float64 {
+	return d.randomization
+}
+
+func (d *solveOperatorUnPlanWorstImpl) Execute(
+	ctx context.Context,
+	runTimeInformation SolveInformation,
+) error {
+	workSolution := runTimeInformation.
+		Solver().
+		WorkSolution()
+
+	random := runTimeInformation.Solver().Random()
+
+	numberOfUnits := d.NumberOfUnits().Value()
+
+	type saving struct {
+		planUnit SolutionPlanUnit
+		value    float64
+	}
+	for i := 0; i < numberOfUnits; i++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			planUnits := slices.Clone(workSolution.PlannedPlanUnits().SolutionPlanUnits())
+			savings := make([]saving, 0, len(planUnits))
+			for _, planUnit := range planUnits {
+				if planUnit.IsFixed() {
+					continue
+				}
+				value, ok, err := removalSaving(ctx, planUnit)
+				if err != nil {
+					return err
+				}
+				if ok {
+					savings = append(savings, saving{
+						planUnit: planUnit,
+						value:    value,
+					})
+				}
+			}
+			if len(savings) == 0 {
+				return nil
+			}
+			slices.SortStableFunc(savings, func(a, b saving) int {
+				return cmp.Compare(b.value, a.value)
+			})
+			planUnit := savings[randomizedIndex(random, len(savings), d.randomization)].planUnit
+			if _, err := planUnit.UnPlan(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// removalSaving returns the decrease of the score of the solution when the
+// plan unit is un-planned. The plan unit is un-planned and planned again at
+// its positions, returns false if the plan unit can not be un-planned or
+// planned again, it is left un-planned in the latter case.
+func removalSaving(ctx context.Context, planUnit SolutionPlanUnit) (float64, bool, error) {
+	solution := planUnit.Solution().(*solutionImpl)
+	planStopsUnits := planUnit.PlannedPlanStopsUnits()
+	units := make(ModelPlanStopsUnits, len(planStopsUnits))
+	original := make([]vehicleSequence, 0, len(planStopsUnits))
+	for idx, planStopsUnit := range planStopsUnits {
+		units[idx] = planStopsUnit.ModelPlanStopsUnit()
+		vehicle := planStopsUnit.SolutionStops()[0].Vehicle()
+		if !slices.ContainsFunc(original, func(sequence vehicleSequence) bool {
+			return sequence.vehicle == vehicle.Index()
+		}) {
+			original = append(original, vehicleSequence{
+				vehicle: vehicle.Index(),
+				stops:   modelStops(vehicle.SolutionStops()),
+			})
+		}
+	}
+
+	score := solution.Score()
+	unplanned, err := planUnit.UnPlan()
+	if err != nil || !unplanned {
+		return 0, false, err
+	}
+	saving := score - solution.Score()
+
+	if err := restore(ctx, solution, units, original); err != nil {
+		return 0, false, err
+	}
+	if !planUnit.IsPlanned() {
+		for _, planStopsUnit := range planStopsUnits {
+			if _, err := planStopsUnit.UnPlan(); err != nil {
+				return 0, false, err
+			}
+		}
+		return 0, false, nil
+	}
+	solution.unPlannedPlanUnits.remove(planUnit)
+	solution.plannedPlanUnits.add(planUnit)
+	return saving, true, nil
+}
+
+// randomizedIndex returns a random index of a sorted slice of length n which
+// favors the first elements, see [NewSolveOperatorUnPlanWorst].
+func randomizedIndex(random *rand.Rand, n int, randomization float64) int {
+	return int(math.Pow(random.Float64(), randomization) * float64(n))
+}
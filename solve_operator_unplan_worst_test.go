@@ -0,0 +1,182 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestSolveOperatorUnPlanWorst(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		return Location{Lon: -74.06 + 0.002*float64(idx), Lat: 4.70}
+	})
+	// The stop in the middle of the route is a detour.
+	stops[3].Stop.Location.Lat = 4.80
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 1),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	unplannedPenalty := nextroute.NewStopExpression("unplanned_penalty", 100000)
+	if _, err := model.Objective().NewTerm(1, nextroute.NewUnPlannedObjective(unplannedPenalty)); err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planRoutes(t, solution, [][]int{{0, 1, 2, 3, 4, 5}})
+
+	numberOfUnits, err := nextroute.NewSolveParameter(1, 1000, 0, 1, 1, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nextroute.NewSolveOperatorUnPlanWorst(1, numberOfUnits, 0.5); err == nil {
+		t.Error("expected an error for a randomization smaller than 1")
+	}
+	// A large randomization always selects the worst plan unit.
+	unplanWorst, err := nextroute.NewSolveOperatorUnPlanWorst(1, numberOfUnits, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unplanWorst.NumberOfUnits() != numberOfUnits || unplanWorst.Randomization() != 1000 {
+		t.Error("expected the parameters of the operator")
+	}
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := nextroute.NewSolveOperatorPlan(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(unplanWorst, plan)
+	executions := 0
+	solver.SolveEvents().OperatorExecuted.Register(func(information nextroute.SolveInformation) {
+		operators := information.SolveOperators()
+		if operators[len(operators)-1] != unplanWorst {
+			return
+		}
+		executions++
+		unplanned := information.Solver().WorkSolution().UnPlannedPlanUnits().SolutionPlanUnits()
+		if len(unplanned) != 1 {
+			t.Fatalf("expected 1 un-planned plan unit, got %v", len(unplanned))
+		}
+		if index := unplanned[0].ModelPlanUnit().Index(); index != 3 {
+			t.Errorf("expected the detour to be un-planned, got plan unit %v", index)
+		}
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 10, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+	if executions == 0 {
+		t.Error("expected the operator to be executed")
+	}
+}
+
+// TestSolveOperatorUnPlanWorstScore expects the plan unit which decreases the
+// score most when un-planned to be un-planned, not the largest detour.
+func TestSolveOperatorUnPlanWorstScore(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		return Location{Lon: -74.06 + 0.002*float64(idx), Lat: 4.70}
+	})
+	stops[3].Stop.Location.Lat = 4.80
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 1),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	// Un-planning the detour costs more than it saves, the stop with a small
+	// penalty is the cheapest to un-plan.
+	unplannedPenalty := nextroute.NewStopExpression("unplanned_penalty", 100000)
+	if err := unplannedPenalty.SetValue(model.Stops()[1], 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewUnPlannedObjective(unplannedPenalty)); err != nil {
+		t.Fatal(err)
+	}
+
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planRoutes(t, solution, [][]int{{0, 1, 2, 3, 4, 5}})
+
+	numberOfUnits, err := nextroute.NewSolveParameter(1, 1000, 0, 1, 1, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unplanWorst, err := nextroute.NewSolveOperatorUnPlanWorst(1, numberOfUnits, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	groupSize, err := nextroute.NewSolveParameter(2, 1000, 0, 2, 2, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := nextroute.NewSolveOperatorPlan(groupSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver, err := nextroute.NewSkeletonSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver.AddSolveOperators(unplanWorst, plan)
+	var unplanned []int
+	solver.SolveEvents().OperatorExecuted.Register(func(information nextroute.SolveInformation) {
+		operators := information.SolveOperators()
+		if operators[len(operators)-1] != unplanWorst {
+			return
+		}
+		for _, planUnit := range information.Solver().WorkSolution().UnPlannedPlanUnits().SolutionPlanUnits() {
+			unplanned = append(unplanned, planUnit.ModelPlanUnit().Index())
+		}
+	})
+	solutions, err := solver.Solve(
+		context.Background(),
+		nextroute.SolveOptions{Iterations: 1, Duration: time.Minute},
+		solution,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+	if len(unplanned) != 1 || unplanned[0] != 1 {
+		t.Errorf("expected plan unit 1 to be un-planned, got %v", unplanned)
+	}
+}
@@ -0,0 +1,477 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
+// RecordOptions are the options to record a solve, see [NewSolveRecorder].
+type RecordOptions struct {
+	Path string `json:"path" usage:"record the seeds and the cycles and runs of the solve to this file to replay a run later"`
+	Seed int64  `json:"seed" usage:"seed of the random number generators of a recorded solve, 0 uses a seed based on the current time"`
+}
+
+// ReplayOptions are the options to replay a run of a recorded solve, see
+// [NewSolveReplayer].
+type ReplayOptions struct {
+	Path string `json:"path" usage:"replay a run of the solve recorded in this file instead of solving"`
+	Run  int    `json:"run" usage:"run of the recorded solve to replay"`
+}
+
+// SolveRecording is the recording of a solve of a parallel solver. It holds
+// the seed of the solve and the layout of the cycles and runs, which is
+// enough to replay every run deterministically.
+type SolveRecording struct {
+	// Seed is the seed of the random number generator of the model, used to
+	// create the start solutions.
+	Seed int64 `json:"seed"`
+	// Options are the interpreted options of the solve.
+	Options ParallelSolveOptions `json:"options"`
+	// Runs are the runs of the solve in the order they started.
+	Runs []SolveRecordingRun `json:"runs"`
+}
+
+// SolveRecordingRun is a run of a [SolveRecording].
+type SolveRecordingRun struct {
+	// Cycle is the cycle of the run, see [ParallelSolveInformation].
+	Cycle int `json:"cycle"`
+	// Run is the run, see [ParallelSolveInformation].
+	Run int `json:"run"`
+	// Seed is the seed of the random number generator of the start solution
+	// of the run. The solver and the solutions of the run, and therefore its
+	// operators, draw from random number generators derived from it.
+	Seed int64 `json:"seed"`
+	// Origin is the solution the run started from.
+	Origin SolveRecordingOrigin `json:"origin"`
+	// Iterations is the number of iterations the run completed.
+	Iterations int `json:"iterations"`
+	// ProgressIterations is the number of iterations the progress passed to
+	// the acceptance criterion of the run is based on, see
+	// [AcceptanceInformation]. It is the maximum number of iterations of the
+	// run, the progress is 0 if it is negative.
+	ProgressIterations int `json:"progress_iterations"`
+	// Operators are the operators of the solver of the run with the number of
+	// times they have been executed.
+	Operators []SolveRecordingOperator `json:"operators"`
+	// Solutions is the number of solutions reported by the run, including
+	// the solution it started from.
+	Solutions int `json:"solutions"`
+	// Score is the score of the best solution of the run.
+	Score float64 `json:"score"`
+}
+
+// SolveRecordingOrigin is the solution a run started from. It is either a
+// start solution of the solve or a solution reported by another run.
+type SolveRecordingOrigin struct {
+	// StartSolution is the index of the start solution, -1 if the run
+	// started from a solution of another run.
+	StartSolution int `json:"start_solution"`
+	// Run is the run that reported the solution, if StartSolution is -1.
+	Run int `json:"run,omitempty"`
+	// Solution is the index of the solution in the solutions reported by the
+	// run, 0 is the solution that run started from.
+	Solution int `json:"solution,omitempty"`
+}
+
+// SolveRecordingOperator is an operator of a [SolveRecordingRun].
+type SolveRecordingOperator struct {
+	// Name is the name of the operator.
+	Name string `json:"name"`
+	// Executions is the number of times the operator has been executed.
+	Executions int `json:"executions"`
+}
+
+// SolveRecorder records the solve of the parallel solver it is attached to.
+type SolveRecorder interface {
+	// Recording returns the recording of the last solve. A replayer returns
+	// the recording of the replayed run, which can be compared to the
+	// recorded run.
+	Recording() SolveRecording
+}
+
+// NewSolveRecorder attaches a recorder to the parallel solver. The random
+// number generators of the next solve are seeded with the seed, or with a
+// seed based on the current time if the seed is 0. Each run gets a seed
+// derived from the seed of the solve, the recording holds the seed and the
+// solution every run started from, see [NewSolveReplayer].
+//
+// The progress passed to the acceptance criterion of a recorded run is based
+// on the iterations of the run only, not on the elapsed time, so that the run
+// can be replayed. Therefore, a criterion that cools down with the progress,
+// such as simulated annealing, does not cool down completely if the run is
+// stopped by the duration before its maximum number of iterations. Operators
+// and criteria that depend on the wall clock otherwise cannot be replayed.
+func NewSolveRecorder(solver ParallelSolver, seed int64) (SolveRecorder, error) {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	recorder := &solveRecorderImpl{
+		recording: SolveRecording{Seed: seed},
+	}
+	if err := attachRecorder(solver, recorder); err != nil {
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// NewSolveReplayer attaches a replayer of a run of the recording to the
+// parallel solver. The next solve of the solver does not solve, it replays
+// the run deterministically and returns the solutions reported by the run.
+// The solver must be set up as the recorded one, with the same model,
+// factories and start solutions. The runs the replayed run depends on are
+// replayed as well, in order to recreate the solution it started from. The
+// run completes the recorded number of iterations within the duration of the
+// solve options.
+func NewSolveReplayer(
+	solver ParallelSolver,
+	recording SolveRecording,
+	run int,
+) (SolveRecorder, error) {
+	if recording.find(run) == nil {
+		return nil, fmt.Errorf("run %v is not part of the recording", run)
+	}
+	recorder := &solveRecorderImpl{
+		recording: SolveRecording{
+			Seed:    recording.Seed,
+			Options: recording.Options,
+		},
+		replay: &recording,
+		run:    run,
+	}
+	if err := attachRecorder(solver, recorder); err != nil {
+		return nil, err
+	}
+	return recorder, nil
+}
+
+// find returns the recorded run, nil if the run is not part of the
+// recording.
+func (r *SolveRecording) find(run int) *SolveRecordingRun {
+	for idx := range r.Runs {
+		if r.Runs[idx].Run == run {
+			return &r.Runs[idx]
+		}
+	}
+	return nil
+}
+
+// recordable is implemented by the parallel solvers that can be recorded.
+type recordable interface {
+	setRecorder(recorder *solveRecorderImpl)
+}
+
+func attachRecorder(solver ParallelSolver, recorder *solveRecorderImpl) error {
+	if solver == nil {
+		return fmt.Errorf("solver is nil")
+	}
+	r, ok := solver.(recordable)
+	if !ok {
+		return fmt.Errorf("solver of type %T can not be recorded", solver)
+	}
+	r.setRecorder(recorder)
+	return nil
+}
+
+type solveRecorderImpl struct {
+	mutex     sync.Mutex
+	recording SolveRecording
+	runs      map[int]*SolveRecordingRun
+	// replay is the recording to replay the run of, nil if the solve is
+	// recorded.
+	replay *SolveRecording
+	run    int
+}
+
+func (r *solveRecorderImpl) Recording() SolveRecording {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	recording := r.recording
+	recording.Runs = make([]SolveRecordingRun, 0, len(r.runs))
+	for _, run := range r.runs {
+		recorded := *run
+		recorded.Operators = slices.Clone(run.Operators)
+		recording.Runs = append(recording.Runs, recorded)
+	}
+	slices.SortFunc(recording.Runs, func(a, b SolveRecordingRun) int {
+		return a.Run - b.Run
+	})
+	return recording
+}
+
+// options returns the options of the solve. A replay uses the recorded
+// options, the duration excepted.
+func (r *solveRecorderImpl) options(options ParallelSolveOptions) ParallelSolveOptions {
+	if r.replay == nil {
+		return options
+	}
+	recorded := r.replay.Options
+	recorded.Duration = options.Duration
+	return recorded
+}
+
+// seedModel seeds the random number generator of the model, before the
+// start solutions are created.
+func (r *solveRecorderImpl) seedModel(model Model) {
+	model.SetRandom(rand.New(rand.NewSource(r.recording.Seed)))
+}
+
+// start resets the recording at the start of a solve with the interpreted
+// options.
+func (r *solveRecorderImpl) start(options ParallelSolveOptions) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.runs = make(map[int]*SolveRecordingRun)
+	if r.replay == nil {
+		r.recording.Options = options
+	}
+}
+
+// seed returns the seed of the run in a recorded solve.
+func (r *solveRecorderImpl) seed(run int) int64 {
+	return rand.New(rand.NewSource(r.recording.Seed + int64(run))).Int63()
+}
+
+// startRun records the start of the run with the solver.
+func (r *solveRecorderImpl) startRun(
+	recorded SolveRecordingRun,
+	solver Solver,
+) *SolveRecordingRun {
+	recorded.Iterations = 0
+	recorded.Solutions = 0
+	recorded.Score = 0
+	recorded.Operators = make([]SolveRecordingOperator, 0, len(solver.SolveOperators()))
+	for _, operator := range solver.SolveOperators() {
+		recorded.Operators = append(recorded.Operators, SolveRecordingOperator{
+			Name: operatorName(operator),
+		})
+	}
+
+	run := &recorded
+	r.mutex.Lock()
+	r.runs[recorded.Run] = run
+	r.mutex.Unlock()
+
+	solver.SolveEvents().OperatorExecuted.Register(func(info SolveInformation) {
+		operators := info.SolveOperators()
+		name := operatorName(operators[len(operators)-1])
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		for idx := range run.Operators {
+			if run.Operators[idx].Name == name {
+				run.Operators[idx].Executions++
+				return
+			}
+		}
+		run.Operators = append(run.Operators, SolveRecordingOperator{
+			Name:       name,
+			Executions: 1,
+		})
+	})
+	solver.SolveEvents().Iterated.Register(func(SolveInformation) {
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		run.Iterations++
+	})
+	return run
+}
+
+// iterationProgress bases the progress passed to the acceptance criterion of
+// the run on the iterations of the options and records them.
+func (r *solveRecorderImpl) iterationProgress(
+	run *SolveRecordingRun,
+	options SolveOptions,
+) SolveOptions {
+	options.progressIterations = options.Iterations
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	run.ProgressIterations = options.progressIterations
+	return options
+}
+
+// solution records a solution reported by the run.
+func (r *solveRecorderImpl) solution(run *SolveRecordingRun, solution Solution) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if run.Solutions == 0 || solution.Score() < run.Score {
+		run.Score = solution.Score()
+	}
+	run.Solutions++
+}
+
+// operatorName returns the name of the operator in a recording.
+func operatorName(operator SolveOperator) string {
+	if stringer, ok := operator.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", operator), "*")
+}
+
+// seedSolution seeds the random number generator of the solution, the
+// solvers and solutions of a run derive their random number generators from
+// it.
+func seedSolution(solution Solution, seed int64) error {
+	return solution.SetRandom(rand.New(rand.NewSource(seed)))
+}
+
+// replay replays the run of the recording of the recorder instead of
+// solving, see [NewSolveReplayer]. The runs the replayed run depends on are
+// replayed until they report the solution the next run started from.
+func (s *parallelSolverImpl) replay(
+	ctx context.Context,
+	cancel context.CancelFunc,
+	start time.Time,
+	options ParallelSolveOptions,
+	startSolutions []Solution,
+) (SolutionChannel, error) {
+	recording := s.recorder.replay
+	chain := []*SolveRecordingRun{recording.find(s.recorder.run)}
+	for origin := chain[0].Origin; origin.StartSolution < 0; {
+		run := recording.find(origin.Run)
+		if run == nil || len(chain) > len(recording.Runs) {
+			cancel()
+			return nil, fmt.Errorf(
+				"run %v the replayed run depends on is not part of the recording",
+				origin.Run,
+			)
+		}
+		chain = append(chain, run)
+		origin = run.Origin
+	}
+	first := chain[len(chain)-1].Origin.StartSolution
+	if first >= len(startSolutions) {
+		cancel()
+		return nil, fmt.Errorf(
+			"start solution %v of the recording is not part of the %v start solutions",
+			first,
+			len(startSolutions),
+		)
+	}
+
+	s.ParallelSolveEvents().Start.Trigger(s, options, 1)
+
+	resultChannel := make(chan SolutionInfo, 1)
+	go func() {
+		iterations := 0
+		var best Solution
+		defer func() {
+			cancel()
+			if dataMap, ok := ctx.Value(run.Data).(*sync.Map); ok {
+				dataMap.Store(Iterations, iterations)
+			}
+			close(resultChannel)
+			s.ParallelSolveEvents().End.Trigger(s, iterations, best)
+		}()
+
+		solution := startSolutions[first].Copy()
+		for idx := len(chain) - 1; idx > 0; idx-- {
+			next := chain[idx-1].Origin.Solution
+			var origin Solution
+			err := s.replayRun(ctx, chain[idx], solution, false, func(index int, reported Solution) bool {
+				if index == next {
+					origin = reported.Copy()
+					return false
+				}
+				return true
+			})
+			if err == nil && origin == nil {
+				err = fmt.Errorf("replayed run %v did not report solution %v", chain[idx].Run, next)
+			}
+			if err != nil {
+				resultChannel <- SolutionInfo{Error: err}
+				return
+			}
+			solution = origin
+		}
+
+		err := s.replayRun(ctx, chain[0], solution, true, func(_ int, reported Solution) bool {
+			if best == nil || reported.Score() < best.Score() {
+				best = reported.Copy()
+			}
+			resultChannel <- SolutionInfo{Solution: reported.Copy()}
+			s.progression = append(s.progression, ProgressionEntry{
+				ElapsedSeconds: time.Since(start).Seconds(),
+				Value:          reported.Score(),
+			})
+			return true
+		})
+		if err != nil {
+			resultChannel <- SolutionInfo{Error: err}
+		}
+		if recorded := s.recorder.Recording(); len(recorded.Runs) > 0 {
+			iterations = recorded.Runs[0].Iterations
+		}
+	}()
+
+	return resultChannel, nil
+}
+
+// replayRun replays the recorded run starting from the solution. The
+// solutions reported by the run are passed to report, the run stops if
+// report returns false. If record is true the replayed run is recorded and
+// triggers the events of the parallel solver.
+func (s *parallelSolverImpl) replayRun(
+	ctx context.Context,
+	recorded *SolveRecordingRun,
+	solution Solution,
+	record bool,
+	report func(index int, solution Solution) bool,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := seedSolution(solution, recorded.Seed); err != nil {
+		return err
+	}
+	information := newParallelSolveInformation(recorded.Cycle, recorded.Run, solution.Random())
+	solver, err := s.solverFactory(information, solution)
+	if err != nil {
+		return err
+	}
+	options, err := s.solveOptionsFactory(information)
+	if err != nil {
+		return err
+	}
+	options.Iterations = recorded.Iterations
+	options.progressIterations = recorded.ProgressIterations
+
+	var recording *SolveRecordingRun
+	if record {
+		s.RegisterEvents(solver.SolveEvents())
+		recording = s.recorder.startRun(*recorded, solver)
+		s.ParallelSolveEvents().StartSolver.Trigger(information, solver, options, solution)
+	}
+
+	solutions, err := solver.Solve(context.WithValue(ctx, runKey{}, recorded.Run), options, solution)
+	if err != nil {
+		return err
+	}
+	index := 0
+	stopped := false
+	for reported := range solutions {
+		if reported.Error != nil {
+			return reported.Error
+		}
+		if stopped {
+			continue
+		}
+		if record {
+			s.recorder.solution(recording, reported.Solution)
+			s.ParallelSolveEvents().NewSolution.Trigger(information, reported.Solution)
+		}
+		if !report(index, reported.Solution) {
+			stopped = true
+			cancel()
+		}
+		index++
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+	"github.com/nextmv-io/sdk/run"
+)
+
+func recordingSolver(t *testing.T, criterion string) nextroute.ParallelSolver {
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		planSingleStops(),
+		planPairSequences(),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	solver, err := nextroute.NewParallelSolver(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solveOptionsFactory := nextroute.DefaultSolveOptionsFactory()
+	solver.SetSolveOptionsFactory(func(
+		information nextroute.ParallelSolveInformation,
+	) (nextroute.SolveOptions, error) {
+		options, err := solveOptionsFactory(information)
+		options.Acceptance = nextroute.DefaultAcceptanceOptions()
+		options.Acceptance.Criterion = criterion
+		return options, err
+	})
+	return solver
+}
+
+// TestSolveRecordReplay records a solve and expects every run to be replayed
+// identically, also with an acceptance criterion depending on the progress of
+// the solve.
+func TestSolveRecordReplay(t *testing.T) {
+	for _, criterion := range []string{
+		nextroute.AcceptanceNone,
+		nextroute.AcceptanceSimulatedAnnealing,
+		nextroute.AcceptanceThreshold,
+	} {
+		t.Run(criterion, func(t *testing.T) {
+			testSolveRecordReplay(t, criterion)
+		})
+	}
+}
+
+func testSolveRecordReplay(t *testing.T, criterion string) {
+	options := nextroute.ParallelSolveOptions{
+		Iterations:     3000,
+		Duration:       30 * time.Second,
+		ParallelRuns:   1,
+		StartSolutions: 1,
+	}
+
+	solver := recordingSolver(t, criterion)
+	recorder, err := nextroute.NewSolveRecorder(solver, 42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.WithValue(context.Background(), run.Start, time.Now())
+	solutions, err := solver.Solve(ctx, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := solutions.Last(); err != nil {
+		t.Fatal(err)
+	}
+
+	recording := recorder.Recording()
+	if recording.Seed != 42 || len(recording.Runs) < 2 {
+		t.Fatalf("expected a recording of seed 42 with several runs, got %+v", recording)
+	}
+	for _, recorded := range recording.Runs {
+		if recorded.Iterations == 0 {
+			continue
+		}
+		solver := recordingSolver(t, criterion)
+		replayer, err := nextroute.NewSolveReplayer(solver, recording, recorded.Run)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.WithValue(context.Background(), run.Start, time.Now())
+		solutions, err := solver.Solve(ctx, options)
+		if err != nil {
+			t.Fatal(err)
+		}
+		last, err := solutions.Last()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last.Score() != recorded.Score {
+			t.Errorf("run %v: replayed score %v, want %v", recorded.Run, last.Score(), recorded.Score)
+		}
+		replayed := replayer.Recording().Runs
+		if len(replayed) != 1 || !reflect.DeepEqual(replayed[0], recorded) {
+			t.Errorf("run %v: replayed %+v, want %+v", recorded.Run, replayed, recorded)
+		}
+	}
+
+	if _, err := nextroute.NewSolveReplayer(solver, recording, len(recording.Runs)+1); err == nil {
+		t.Error("expected an error replaying a run that is not recorded")
+	}
+}
@@ -0,0 +1,371 @@
+// © 2019-present nextmv.io inc
+
+package nextroute
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RoutePool is a pool of distinct routes collected from solutions. The routes
+// of the pool are recombined into a new solution by solving a set-partitioning
+// problem over them heuristically. A route pool is safe for concurrent use.
+type RoutePool interface {
+	// Add adds the distinct non-empty routes of the solution to the pool.
+	Add(solution Solution)
+	// Best returns the best solution added to the pool, nil if no solution
+	// has been added.
+	Best() Solution
+	// Recombine selects routes of the pool which do not share stops, at most
+	// one route per vehicle, and plans them on a copy of the best solution
+	// added to the pool. It returns the best of the recombined solutions and
+	// the best solution added to the pool.
+	Recombine(ctx context.Context) (Solution, error)
+	// Size returns the number of routes in the pool.
+	Size() int
+}
+
+// NewRoutePool creates a new route pool holding at most size routes. If the
+// pool is full a new route replaces the route with the highest cost per stop
+// if its own cost per stop is lower. The cost of a route is the value of the
+// objective of the model attributable to the vehicle, see
+// [ObjectiveVehicleValuer].
+//
+// Recombine selects routes in two ways, greedily by the lowest cost per stop
+// and starting from the routes of the best solution. Both selections are
+// improved by swapping in routes of the pool which cover more stops, or the
+// same number of stops at a lower cost, than the routes they conflict with.
+// The selected routes are planned in the order of their stops, plan units
+// which are a member of a plan units unit and plan units which can not be
+// planned in the order of the route are planned afterwards by their best
+// move if it does not worsen the solution.
+func NewRoutePool(model Model, size int) (RoutePool, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model cannot be nil")
+	}
+	if size < 1 {
+		return nil, fmt.Errorf(
+			"route pool size %v must be at least 1",
+			size,
+		)
+	}
+	return &routePoolImpl{
+		model:  model,
+		size:   size,
+		routes: make(map[string]*poolRoute, size),
+	}, nil
+}
+
+type routePoolImpl struct {
+	model  Model
+	best   Solution
+	routes map[string]*poolRoute
+	size   int
+	mutex  sync.Mutex
+}
+
+// poolRoute is a route of a vehicle in the pool, the stops include the first
+// and last stop of the vehicle.
+type poolRoute struct {
+	key     string
+	vehicle int
+	stops   ModelStops
+	cost    float64
+}
+
+// newPoolRoute returns the route of the vehicle.
+func newPoolRoute(vehicle SolutionVehicle) *poolRoute {
+	route := &poolRoute{
+		vehicle: vehicle.ModelVehicle().Index(),
+		stops:   make(ModelStops, 0, vehicle.NumberOfStops()+2),
+	}
+	var key strings.Builder
+	key.WriteString(strconv.Itoa(route.vehicle))
+	for _, stop := range vehicle.SolutionStops() {
+		route.stops = append(route.stops, stop.ModelStop())
+		key.WriteByte(',')
+		key.WriteString(strconv.Itoa(stop.ModelStopIndex()))
+	}
+	route.key = key.String()
+	if valuer, ok := vehicle.solution.Model().Objective().(ObjectiveVehicleValuer); ok {
+		route.cost = valuer.VehicleValue(vehicle)
+	} else {
+		route.cost = vehicle.DurationValue()
+	}
+	return route
+}
+
+// visits returns the stops of the route, the first and last stop excluded.
+func (r *poolRoute) visits() ModelStops {
+	return r.stops[1 : len(r.stops)-1]
+}
+
+func (r *poolRoute) costPerStop() float64 {
+	return r.cost / float64(len(r.visits()))
+}
+
+// compare orders routes by their cost per stop, ties are broken by their key
+// so the order does not depend on the order in which routes were added.
+func (r *poolRoute) compare(other *poolRoute) int {
+	if result := cmp.Compare(r.costPerStop(), other.costPerStop()); result != 0 {
+		return result
+	}
+	return strings.Compare(r.key, other.key)
+}
+
+func (p *routePoolImpl) Add(solution Solution) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.best == nil || solution.Score() < p.best.Score() {
+		p.best = solution.Copy()
+	}
+
+	for _, vehicle := range solution.Vehicles() {
+		if vehicle.IsEmpty() {
+			continue
+		}
+		route := newPoolRoute(vehicle)
+		if _, ok := p.routes[route.key]; ok {
+			continue
+		}
+		if len(p.routes) >= p.size {
+			var worst *poolRoute
+			for _, candidate := range p.routes {
+				if worst == nil || candidate.compare(worst) > 0 {
+					worst = candidate
+				}
+			}
+			if route.compare(worst) >= 0 {
+				continue
+			}
+			delete(p.routes, worst.key)
+		}
+		p.routes[route.key] = route
+	}
+}
+
+func (p *routePoolImpl) Best() Solution {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.best
+}
+
+func (p *routePoolImpl) Size() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.routes)
+}
+
+func (p *routePoolImpl) Recombine(ctx context.Context) (Solution, error) {
+	p.mutex.Lock()
+	if p.best == nil {
+		p.mutex.Unlock()
+		return nil, fmt.Errorf("route pool, no solution has been added")
+	}
+	best := p.best.Copy()
+	routes := make([]*poolRoute, 0, len(p.routes))
+	for _, route := range p.routes {
+		routes = append(routes, route)
+	}
+	p.mutex.Unlock()
+
+	slices.SortFunc(routes, func(a, b *poolRoute) int {
+		return a.compare(b)
+	})
+
+	incumbent := newRouteSelection(p.model)
+	for _, vehicle := range best.Vehicles() {
+		if !vehicle.IsEmpty() {
+			incumbent.add(newPoolRoute(vehicle))
+		}
+	}
+	greedy := newRouteSelection(p.model)
+	greedy.fill(routes)
+
+	result := best
+	for _, selection := range []*routeSelection{incumbent, greedy} {
+		if !selection.improve(ctx, routes) && selection == incumbent {
+			continue
+		}
+		solution, err := selection.assemble(ctx, best)
+		if err != nil {
+			return nil, err
+		}
+		if solution.Score() < result.Score() {
+			result = solution
+		}
+	}
+	return result, nil
+}
+
+// routeSelection is a set of routes which do not share stops with at most one
+// route per vehicle.
+type routeSelection struct {
+	// vehicles holds the selected route by model vehicle index.
+	vehicles []*poolRoute
+	// stops holds the selected route visiting the stop by model stop index.
+	stops   []*poolRoute
+	visits  int
+	cost    float64
+	changed bool
+}
+
+func newRouteSelection(model Model) *routeSelection {
+	return &routeSelection{
+		vehicles: make([]*poolRoute, len(model.Vehicles())),
+		stops:    make([]*poolRoute, model.NumberOfStops()),
+	}
+}
+
+func (s *routeSelection) add(route *poolRoute) {
+	s.vehicles[route.vehicle] = route
+	for _, stop := range route.visits() {
+		s.stops[stop.Index()] = route
+	}
+	s.visits += len(route.visits())
+	s.cost += route.cost
+	s.changed = true
+}
+
+func (s *routeSelection) remove(route *poolRoute) {
+	s.vehicles[route.vehicle] = nil
+	for _, stop := range route.visits() {
+		s.stops[stop.Index()] = nil
+	}
+	s.visits -= len(route.visits())
+	s.cost -= route.cost
+	s.changed = true
+}
+
+// conflicts returns the selected routes which use the vehicle or visit a stop
+// of the route.
+func (s *routeSelection) conflicts(route *poolRoute) []*poolRoute {
+	conflicts := make([]*poolRoute, 0, 2)
+	if selected := s.vehicles[route.vehicle]; selected != nil {
+		conflicts = append(conflicts, selected)
+	}
+	for _, stop := range route.visits() {
+		if selected := s.stops[stop.Index()]; selected != nil &&
+			!slices.Contains(conflicts, selected) {
+			conflicts = append(conflicts, selected)
+		}
+	}
+	return conflicts
+}
+
+// fill adds the routes in the given order which do not conflict with the
+// selected routes.
+func (s *routeSelection) fill(routes []*poolRoute) {
+	for _, route := range routes {
+		if len(s.conflicts(route)) == 0 {
+			s.add(route)
+		}
+	}
+}
+
+// improve swaps in routes which visit more stops, or the same number of stops
+// at a lower cost, than the selected routes they conflict with until no such
+// route is left. Returns true if the selection has been changed.
+func (s *routeSelection) improve(ctx context.Context, routes []*poolRoute) bool {
+	s.changed = false
+	for improved := true; improved; {
+		improved = false
+		for _, route := range routes {
+			select {
+			case <-ctx.Done():
+				return s.changed
+			default:
+			}
+			if s.vehicles[route.vehicle] == route {
+				continue
+			}
+			conflicts := s.conflicts(route)
+			visits, cost := len(route.visits()), route.cost
+			for _, conflict := range conflicts {
+				visits -= len(conflict.visits())
+				cost -= conflict.cost
+			}
+			if visits < 0 || visits == 0 && cost >= 0 {
+				continue
+			}
+			for _, conflict := range conflicts {
+				s.remove(conflict)
+			}
+			s.add(route)
+			s.fill(routes)
+			improved = true
+		}
+	}
+	return s.changed
+}
+
+// assemble plans the selected routes on a copy of the solution from which
+// all plan units which are not fixed have been un-planned. A plan unit which
+// cannot be planned in the order of its route is skipped, the plan units
+// which have not been planned by their route are planned by their best move
+// if it does not worsen the solution.
+func (s *routeSelection) assemble(ctx context.Context, base Solution) (Solution, error) {
+	solution := base.Copy().(*solutionImpl)
+	for _, planUnit := range solution.PlannedPlanUnits().SolutionPlanUnits() {
+		if planUnit.IsFixed() {
+			continue
+		}
+		if _, err := planUnit.UnPlan(); err != nil {
+			return nil, err
+		}
+	}
+
+	vehicles := make(map[int]SolutionVehicle, len(solution.vehicles))
+	for _, vehicle := range solution.Vehicles() {
+		vehicles[vehicle.ModelVehicle().Index()] = vehicle
+	}
+
+	for _, route := range s.vehicles {
+		if route == nil {
+			continue
+		}
+		vehicle, ok := vehicles[route.vehicle]
+		if !ok {
+			continue
+		}
+		var tried ModelPlanStopsUnits
+		for _, stop := range route.visits() {
+			if !stop.HasPlanStopsUnit() || solution.SolutionStop(stop).IsPlanned() {
+				continue
+			}
+			if _, isMemberOf := stop.PlanStopsUnit().PlanUnitsUnit(); isMemberOf {
+				continue
+			}
+			if slices.Contains(tried, stop.PlanStopsUnit()) {
+				continue
+			}
+			tried = append(tried, stop.PlanStopsUnit())
+			_, err := planSequence(ctx, vehicle, route.stops, ModelPlanStopsUnits{stop.PlanStopsUnit()})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, planUnit := range solution.UnPlannedPlanUnits().SolutionPlanUnits() {
+		select {
+		case <-ctx.Done():
+			return solution, nil
+		default:
+		}
+		move := solution.BestMove(ctx, planUnit)
+		if !move.IsExecutable() || move.Value() > 0 {
+			continue
+		}
+		if _, err := move.Execute(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return solution, nil
+}
@@ -0,0 +1,170 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestRoutePool(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		lon := -74.07
+		if idx >= 3 {
+			lon = -74.02
+		}
+		return Location{Lon: lon + 0.002*float64(idx%3), Lat: 4.70}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	unplannedPenalty := nextroute.NewStopExpression("unplanned_penalty", 100000)
+	if _, err := model.Objective().NewTerm(1, nextroute.NewUnPlannedObjective(unplannedPenalty)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := nextroute.NewRoutePool(model, 0); err == nil {
+		t.Error("expected an error for a size of 0")
+	}
+	pool, err := nextroute.NewRoutePool(model, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pool.Recombine(context.Background()); err == nil {
+		t.Error("expected an error for an empty pool")
+	}
+
+	// Each solution visits one cluster with a different vehicle, the other
+	// cluster is un-planned.
+	solutions := make(nextroute.Solutions, 2)
+	for idx, routes := range [][][]int{{{0, 1, 2}}, {{}, {3, 4, 5}}} {
+		solution, err := nextroute.NewSolution(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		planRoutes(t, solution, routes)
+		solutions[idx] = solution
+		pool.Add(solution)
+		pool.Add(solution)
+	}
+	if pool.Size() != 2 {
+		t.Errorf("expected 2 distinct routes, got %v", pool.Size())
+	}
+	if pool.Best().Score() != min(solutions[0].Score(), solutions[1].Score()) {
+		t.Errorf("expected the best solution added, got score %v", pool.Best().Score())
+	}
+
+	recombined, err := pool.Recombine(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recombined.Score() >= pool.Best().Score() {
+		t.Errorf("expected score %v to improve on %v", recombined.Score(), pool.Best().Score())
+	}
+	if recombined.UnPlannedPlanUnits().Size() != 0 {
+		t.Errorf("expected all stops to be planned, got %v unplanned", recombined.UnPlannedPlanUnits().Size())
+	}
+	for vehicleIndex, vehicle := range recombined.Vehicles() {
+		for _, stop := range vehicle.SolutionStops() {
+			if stop.IsFirst() || stop.IsLast() {
+				continue
+			}
+			if (stop.ModelStopIndex() >= 3) != (vehicleIndex == 1) {
+				t.Errorf("expected vehicle %v to keep its route, got %v", vehicleIndex, vehicle.SolutionStops())
+			}
+		}
+	}
+}
+
+// blockStop is a constraint which, once enabled, is violated by planning the
+// stop with the model index.
+type blockStop struct {
+	index   int
+	enabled bool
+}
+
+func (b *blockStop) EstimateIsViolated(
+	move nextroute.SolutionMoveStops,
+) (bool, nextroute.StopPositionsHint) {
+	if !b.enabled {
+		return false, nextroute.NoPositionsHint()
+	}
+	for _, stop := range move.PlanStopsUnit().ModelPlanStopsUnit().Stops() {
+		if stop.Index() == b.index {
+			return true, nextroute.NoPositionsHint()
+		}
+	}
+	return false, nextroute.NoPositionsHint()
+}
+
+// TestRoutePoolSkip expects the stops of a route following a stop which can
+// no longer be planned to be planned in the order of the route.
+func TestRoutePoolSkip(t *testing.T) {
+	stops := stopsAt(6, func(idx int) Location {
+		if idx >= 4 {
+			return Location{Lon: -74.06 - 0.005*float64(idx-4), Lat: 4.686293}
+		}
+		return Location{Lon: -74.04 + 0.005*float64(idx), Lat: 4.686293}
+	})
+	model, err := createModel(input(
+		vehicleTypes("truck"),
+		vehicles("truck", depot(), 2),
+		stops,
+		nil,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := model.Objective().NewTerm(1, nextroute.NewTravelDurationObjective()); err != nil {
+		t.Fatal(err)
+	}
+	unplannedPenalty := nextroute.NewStopExpression("unplanned_penalty", 100000)
+	if _, err := model.Objective().NewTerm(1, nextroute.NewUnPlannedObjective(unplannedPenalty)); err != nil {
+		t.Fatal(err)
+	}
+	block := &blockStop{index: 0}
+	if err := model.AddConstraint(block); err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := nextroute.NewRoutePool(model, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first route does not visit its stops in the best order.
+	for _, routes := range [][][]int{{{0, 3, 1, 2}}, {{}, {4, 5}}} {
+		solution, err := nextroute.NewSolution(model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		planRoutes(t, solution, routes)
+		pool.Add(solution)
+	}
+
+	block.enabled = true
+	recombined, err := pool.Recombine(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var route []int
+	for _, stop := range recombined.Vehicles()[0].SolutionStops() {
+		if !stop.IsFirst() && !stop.IsLast() {
+			route = append(route, stop.ModelStopIndex())
+		}
+	}
+	if !slices.Equal(route, []int{3, 1, 2}) {
+		t.Errorf("expected route [3 1 2], got %v", route)
+	}
+}
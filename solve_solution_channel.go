@@ -2,6 +2,15 @@
 
 package nextroute
 
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/nextmv-io/sdk/run"
+)
+
 // SolutionInfo contains solutions and error if one raised.
 type SolutionInfo struct {
 	Solution
@@ -11,6 +20,22 @@ type SolutionInfo struct {
 // SolutionChannel is a channel of solutions.
 type SolutionChannel <-chan SolutionInfo
 
+// StreamOptions are the options to stream solutions while solving.
+type StreamOptions struct {
+	Path     string        `json:"path" usage:"stream improving solutions as newline-delimited JSON to this file, - for stdout"`
+	Interval time.Duration `json:"interval" usage:"minimum time between streamed solutions, 0 streams every improving solution"`
+}
+
+// SolutionRecord is a solution streamed by [SolutionChannel.Stream].
+type SolutionRecord struct {
+	// ElapsedSeconds is the time since the start of the run.
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	// Score is the score of the solution.
+	Score float64 `json:"score"`
+	// Solution is the solution in the format of the output.
+	Solution any `json:"solution"`
+}
+
 // All returns all solutions in the channel.
 func (solutions SolutionChannel) All() ([]Solution, error) {
 	solutionArray := make([]Solution, 0)
@@ -34,3 +59,84 @@ func (solutions SolutionChannel) Last() (Solution, error) {
 	}
 	return solution, nil
 }
+
+// Stream writes the solutions in the channel as newline-delimited JSON
+// [SolutionRecord] to the writer and returns the last solution. The solution
+// of a record is mapped with toSolutionOutputFn. If the interval is positive,
+// at most one solution is written per interval, the latest one received. The
+// last solution is always written. The elapsed time is measured from the
+// start of the run if the context holds it. If writing fails or the channel
+// holds an error the remaining solutions are discarded in the background, so
+// the solver sending them does not block.
+func (solutions SolutionChannel) Stream(
+	ctx context.Context,
+	writer io.Writer,
+	interval time.Duration,
+	toSolutionOutputFn func(Solution) any,
+) (Solution, error) {
+	start := time.Now()
+	if runStart, ok := ctx.Value(run.Start).(time.Time); ok {
+		start = runStart
+	}
+	encoder := json.NewEncoder(writer)
+	write := func(solution Solution) error {
+		return encoder.Encode(SolutionRecord{
+			ElapsedSeconds: time.Since(start).Seconds(),
+			Score:          solution.Score(),
+			Solution:       toSolutionOutputFn(solution),
+		})
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	fail := func(err error) (Solution, error) {
+		solutions.drain()
+		return nil, err
+	}
+
+	var last, pending Solution
+	for {
+		select {
+		case s, ok := <-solutions:
+			if !ok {
+				if pending != nil {
+					if err := write(pending); err != nil {
+						return nil, err
+					}
+				}
+				return last, nil
+			}
+			if s.Error != nil {
+				return fail(s.Error)
+			}
+			last = s.Solution
+			if tick != nil {
+				pending = last
+				continue
+			}
+			if err := write(last); err != nil {
+				return fail(err)
+			}
+		case <-tick:
+			if pending != nil {
+				if err := write(pending); err != nil {
+					return fail(err)
+				}
+				pending = nil
+			}
+		}
+	}
+}
+
+// drain discards the remaining solutions in the channel in the background.
+func (solutions SolutionChannel) drain() {
+	go func() {
+		for range solutions {
+		}
+	}()
+}
@@ -0,0 +1,142 @@
+// © 2019-present nextmv.io inc
+
+package nextroute_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/nextmv-io/nextroute"
+)
+
+func TestSolutionChannelStream(t *testing.T) {
+	model, err := createModel(singleVehiclePlanSingleStopsModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	channel := func(infos ...nextroute.SolutionInfo) nextroute.SolutionChannel {
+		c := make(chan nextroute.SolutionInfo, len(infos))
+		for _, info := range infos {
+			c <- info
+		}
+		close(c)
+		return c
+	}
+	toOutput := func(s nextroute.Solution) any {
+		return s.Vehicles()[0].NumberOfStops()
+	}
+	infos := []nextroute.SolutionInfo{
+		{Solution: solution},
+		{Solution: solution.Copy()},
+		{Solution: solution.Copy()},
+	}
+
+	tests := []struct {
+		interval time.Duration
+		records  int
+	}{
+		{0, 3},
+		{time.Hour, 1},
+	}
+	for _, test := range tests {
+		var buffer bytes.Buffer
+		last, err := channel(infos...).Stream(
+			context.Background(),
+			&buffer,
+			test.interval,
+			toOutput,
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if last != infos[2].Solution {
+			t.Errorf("expected the last solution to be returned")
+		}
+		decoder := json.NewDecoder(&buffer)
+		records := 0
+		for decoder.More() {
+			var record nextroute.SolutionRecord
+			if err := decoder.Decode(&record); err != nil {
+				t.Fatal(err)
+			}
+			if record.Score != solution.Score() || record.Solution != 0.0 {
+				t.Errorf("unexpected record %v", record)
+			}
+			records++
+		}
+		if records != test.records {
+			t.Errorf(
+				"interval %v: expected %v records, got %v",
+				test.interval,
+				test.records,
+				records,
+			)
+		}
+	}
+
+	expected := errors.New("solve failed")
+	_, err = channel(infos[0], nextroute.SolutionInfo{Error: expected}).Stream(
+		context.Background(),
+		&bytes.Buffer{},
+		0,
+		toOutput,
+	)
+	if !errors.Is(err, expected) {
+		t.Errorf("expected the error of the channel, got %v", err)
+	}
+}
+
+// failingWriter fails every write like a closed pipe.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestSolutionChannelStreamWriteError(t *testing.T) {
+	model, err := createModel(singleVehiclePlanSingleStopsModel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := nextroute.NewSolution(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The sender blocks on the unbuffered channel unless the solutions are
+	// drained after the write error.
+	channel := make(chan nextroute.SolutionInfo)
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		defer close(channel)
+		for i := 0; i < 10; i++ {
+			channel <- nextroute.SolutionInfo{Solution: solution}
+		}
+	}()
+
+	_, err = nextroute.SolutionChannel(channel).Stream(
+		context.Background(),
+		failingWriter{},
+		0,
+		func(s nextroute.Solution) any { return nil },
+	)
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("expected the error of the writer, got %v", err)
+	}
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the remaining solutions to be drained")
+	}
+}
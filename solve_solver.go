@@ -5,6 +5,7 @@ package nextroute
 import (
 	"context"
 	"fmt"
+	"math"
 	"math/rand"
 	"slices"
 	"time"
@@ -25,15 +26,27 @@ type IntParameterOptions struct {
 
 // SolverOptions are the options for the solver and it's operators.
 type SolverOptions struct {
-	Unplan  IntParameterOptions `json:"unplan"  usage:"unplan parameter"`
-	Plan    IntParameterOptions `json:"plan"  usage:"plan parameter"`
-	Restart IntParameterOptions `json:"restart"  usage:"restart parameter"`
+	Unplan     IntParameterOptions `json:"unplan"  usage:"unplan parameter"`
+	Plan       IntParameterOptions `json:"plan"  usage:"plan parameter"`
+	Restart    IntParameterOptions `json:"restart"  usage:"restart parameter"`
+	Regret     int                 `json:"regret"  usage:"number of vehicles compared by regret-k insertion of the plan operator, less than 2 plans greedily" default:"0"`
+	Acceptance AcceptanceOptions   `json:"acceptance"`
 }
 
 // SolveOptions holds the options for the solve process.
 type SolveOptions struct {
 	Iterations int           `json:"iterations"  usage:"maximum number of iterations, -1 assumes no limit" default:"-1"`
 	Duration   time.Duration `json:"duration"  usage:"maximum duration of solver in seconds" default:"30s"`
+	// Acceptance selects the acceptance criterion of the solve, if the
+	// criterion is empty the acceptance criterion of the solver is used, see
+	// AcceptanceSolver.SetAcceptanceCriterion.
+	Acceptance AcceptanceOptions `json:"acceptance"`
+	// progressIterations, if not 0, is the number of iterations the progress
+	// passed to the acceptance criterion is based on, instead of the elapsed
+	// time. It is set by recorded and replayed runs, so that the acceptance
+	// does not depend on the wall clock. A negative number of iterations
+	// keeps the progress at 0.
+	progressIterations int
 }
 
 // Solver is the interface for the Adaptive Local Neighborhood Search algorithm
@@ -71,6 +84,16 @@ type Solver interface {
 	WorkSolution() Solution
 }
 
+// AcceptanceSolver is a solver whose acceptance criterion of the work
+// solution can be set. The solvers created by [NewSkeletonSolver] implement
+// it.
+type AcceptanceSolver interface {
+	// SetAcceptanceCriterion sets the acceptance criterion of the work
+	// solution, nil accepts every work solution. The acceptance criterion
+	// selected by the solve options takes precedence.
+	SetAcceptanceCriterion(AcceptanceCriterion)
+}
+
 // NewSkeletonSolver creates a new solver for the given model.
 func NewSkeletonSolver(model Model) (Solver, error) {
 	solver := &solveImpl{
@@ -90,6 +113,10 @@ type solveImpl struct {
 	solveOperators SolveOperators
 	parameters     SolveParameters
 	progression    []ProgressionEntry
+	acceptance     AcceptanceCriterion
+	// currentSolution is the last work solution accepted by the acceptance
+	// criterion.
+	currentSolution Solution
 }
 
 func (s *solveImpl) OnImprovement(solveInformation SolveInformation) {
@@ -124,6 +151,10 @@ func (s *solveImpl) AddSolveOperators(solveOperators ...SolveOperator) {
 	}
 }
 
+func (s *solveImpl) SetAcceptanceCriterion(acceptance AcceptanceCriterion) {
+	s.acceptance = acceptance
+}
+
 func (s *solveImpl) Random() *rand.Rand {
 	return s.random
 }
@@ -241,9 +272,23 @@ func (s *solveImpl) Solve(
 		)
 	}
 
+	acceptance := s.acceptance
+	if solveOptions.Acceptance.Criterion != "" {
+		var err error
+		acceptance, err = NewAcceptanceCriterion(solveOptions.Acceptance)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	newWorkSolution := startSolutions[0].Copy()
 	s.bestSolution = startSolutions[0].Copy()
 	s.workSolution = newWorkSolution
+	s.currentSolution = nil
+	if acceptance != nil {
+		acceptance.Start(newWorkSolution.Score())
+		s.currentSolution = newWorkSolution.Copy()
+	}
 	s.random = rand.New(rand.NewSource(newWorkSolution.Random().Int63()))
 
 	start := time.Now()
@@ -308,6 +353,10 @@ func (s *solveImpl) Solve(
 				}
 			}
 
+			if acceptance != nil {
+				s.accept(acceptance, solveInformation, solveOptions)
+			}
+
 			for _, parameter := range s.parameters {
 				parameter.Update(solveInformation)
 			}
@@ -318,3 +367,64 @@ func (s *solveImpl) Solve(
 
 	return solutions, err
 }
+
+// accept accepts the work solution as the current solution if the
+// acceptance criterion accepts it, otherwise the work solution is reset to
+// the current solution. Neither is copied if the work solution has the
+// routes of the current solution.
+func (s *solveImpl) accept(
+	acceptance AcceptanceCriterion,
+	solveInformation *solveInformationImpl,
+	solveOptions SolveOptions,
+) {
+	progress := 0.0
+	if solveOptions.progressIterations != 0 {
+		if solveOptions.progressIterations > 0 {
+			progress = float64(solveInformation.iteration+1) /
+				float64(solveOptions.progressIterations)
+		}
+	} else {
+		if solveOptions.Duration > 0 {
+			progress = time.Since(solveInformation.start).Seconds() /
+				solveOptions.Duration.Seconds()
+		}
+		if solveOptions.Iterations > 0 {
+			progress = math.Max(
+				progress,
+				float64(solveInformation.iteration+1)/float64(solveOptions.Iterations),
+			)
+		}
+	}
+	accepted := acceptance.Accept(AcceptanceInformation{
+		SolveInformation: solveInformation,
+		Progress:         math.Min(progress, 1),
+		Current:          s.currentSolution.Score(),
+		Candidate:        s.workSolution.Score(),
+		Best:             s.bestSolution.Score(),
+	})
+	if sameRoutes(s.workSolution, s.currentSolution) {
+		return
+	}
+	if accepted {
+		s.currentSolution = s.workSolution.Copy()
+		return
+	}
+	s.workSolution = s.currentSolution.Copy()
+}
+
+// sameRoutes returns true if both solutions plan the same stops in the same
+// order on the same vehicles and have the same score.
+func sameRoutes(a, b Solution) bool {
+	x, ok := a.(*solutionImpl)
+	if !ok {
+		return false
+	}
+	y, ok := b.(*solutionImpl)
+	if !ok {
+		return false
+	}
+	return x.Score() == y.Score() &&
+		slices.Equal(x.next, y.next) &&
+		slices.Equal(x.inVehicle, y.inVehicle) &&
+		slices.Equal(x.stop, y.stop)
+}
@@ -19,6 +19,10 @@ import (
 // Iterations is the key for the iterations performed.
 const Iterations string = "iterations"
 
+// runKey is the key of the context of the solve of a run of the parallel
+// solver holding the run, see [ParallelSolveInformation].
+type runKey struct{}
+
 // ParallelSolveOptions holds the options for the parallel solver.
 type ParallelSolveOptions struct {
 	Iterations           int           `json:"iterations"  usage:"maximum number of iterations, -1 assumes no limit; iterations are counted after start solutions are generated" default:"-1"`
@@ -26,6 +30,7 @@ type ParallelSolveOptions struct {
 	ParallelRuns         int           `json:"parallel_runs" usage:"maximum number of parallel runs, -1 results in using all available resources" default:"-1"`
 	StartSolutions       int           `json:"start_solutions" usage:"number of solutions to generate on top of those passed in; one solution generated with sweep algorithm, the rest generated randomly" default:"-1"`
 	RunDeterministically bool          `json:"run_deterministically"  usage:"run the parallel solver deterministically"`
+	RoutePool            int           `json:"route_pool" usage:"maximum number of distinct routes of the runs recombined into the start solution of each cycle, 0 disables the recombination" default:"0"`
 }
 
 // ParallelSolver is the interface for parallel solver. The parallel solver will
@@ -136,6 +141,7 @@ type parallelSolverImpl struct {
 	parallelSolveEvents ParallelSolveEvents
 	solveOptionsFactory SolveOptionsFactory
 	solverFactory       SolverFactory
+	recorder            *solveRecorderImpl
 }
 
 func (s *parallelSolverImpl) ParallelSolveEvents() ParallelSolveEvents {
@@ -158,6 +164,14 @@ type solutionContainer struct {
 	Solution   Solution
 	Error      error
 	Iterations int
+	// Run is the run that reported the solution and Index the index of the
+	// solution in the solutions reported by the run.
+	Run   int
+	Index int
+}
+
+func (s *parallelSolverImpl) setRecorder(recorder *solveRecorderImpl) {
+	s.recorder = recorder
 }
 
 func (s *parallelSolverImpl) SetSolverFactory(
@@ -199,6 +213,7 @@ func (s *parallelSolverImpl) Solve(
 		ParallelRuns:         options.ParallelRuns,
 		StartSolutions:       options.StartSolutions,
 		RunDeterministically: options.RunDeterministically,
+		RoutePool:            options.RoutePool,
 	}
 
 	if interpretedParallelSolveOptions.ParallelRuns == -1 {
@@ -230,6 +245,19 @@ func (s *parallelSolverImpl) Solve(
 		}
 	}
 
+	// The routes of all solutions reported by the runs are collected in the
+	// route pool and recombined at the start of each cycle. A recorded solve
+	// does not recombine routes as the recombined solution can not be
+	// replayed.
+	var routePool RoutePool
+	if interpretedParallelSolveOptions.RoutePool > 0 && s.recorder == nil {
+		pool, err := NewRoutePool(s.model, interpretedParallelSolveOptions.RoutePool)
+		if err != nil {
+			return nil, err
+		}
+		routePool = pool
+	}
+
 	start := time.Now()
 
 	if ctx.Value(run.Start) != nil {
@@ -241,6 +269,13 @@ func (s *parallelSolverImpl) Solve(
 		start.Add(interpretedParallelSolveOptions.Duration),
 	)
 
+	if s.recorder != nil {
+		s.recorder.start(interpretedParallelSolveOptions)
+		if s.recorder.replay != nil {
+			return s.replay(ctx, cancel, start, options, startSolutions)
+		}
+	}
+
 	solutions := make([]Solution, len(startSolutions))
 	copy(solutions, startSolutions)
 
@@ -257,14 +292,21 @@ func (s *parallelSolverImpl) Solve(
 	)
 
 	bestSolution := solutions[0]
+	bestOrigin := SolveRecordingOrigin{StartSolution: 0}
 
-	for _, solution := range solutions {
+	for idx, solution := range solutions {
 		if solution.Score() < bestSolution.Score() {
 			bestSolution = solution
+			bestOrigin = SolveRecordingOrigin{StartSolution: idx}
 		}
 	}
 
 	bestSolution = bestSolution.Copy()
+	var bestMutex sync.Mutex
+
+	if routePool != nil {
+		routePool.Add(bestSolution)
+	}
 
 	parallelCount := make(chan struct{}, parallelRuns)
 
@@ -303,6 +345,29 @@ func (s *parallelSolverImpl) Solve(
 		runCount := 0
 	Loop:
 		for {
+			if routePool != nil && runCount > 0 {
+				recombined, err := routePool.Recombine(ctx)
+				if err != nil {
+					syncResultChannel <- solutionContainer{
+						Error:      err,
+						Iterations: int(totalIterations.Load()),
+					}
+					waitGroup.Wait()
+					break Loop
+				}
+				bestMutex.Lock()
+				improved := recombined.Score() < bestSolution.Score()
+				bestMutex.Unlock()
+				if improved {
+					solutionsMutex.Lock()
+					solutions = append(solutions, recombined)
+					solutionsMutex.Unlock()
+					syncResultChannel <- solutionContainer{
+						Solution:   recombined.Copy(),
+						Iterations: int(totalIterations.Load()),
+					}
+				}
+			}
 			for i := 0; i < parallelRuns; i++ {
 				select {
 				case <-ctx.Done():
@@ -318,19 +383,35 @@ func (s *parallelSolverImpl) Solve(
 							waitGroup.Done()
 						}()
 
+						bestMutex.Lock()
 						solution := bestSolution.Copy()
+						origin := bestOrigin
+						bestMutex.Unlock()
 
 						if len(solutions) > 0 {
 							solutionsMutex.Lock()
 							if len(solutions) > 0 {
 								solution = solutions[len(solutions)-1]
 								solutions = solutions[:len(solutions)-1]
+								origin = SolveRecordingOrigin{StartSolution: len(solutions)}
 							}
 							solutionsMutex.Unlock()
 						}
 
 						cycle := (r-1)/parallelRuns + 1
 
+						recorded := SolveRecordingRun{
+							Cycle:  cycle,
+							Run:    r,
+							Origin: origin,
+						}
+						if s.recorder != nil {
+							recorded.Seed = s.recorder.seed(r)
+							if err := seedSolution(solution, recorded.Seed); err != nil {
+								panic(err)
+							}
+						}
+
 						metaSolveInformation := newParallelSolveInformation(
 							cycle,
 							r,
@@ -347,6 +428,11 @@ func (s *parallelSolverImpl) Solve(
 
 						s.RegisterEvents(solver.SolveEvents())
 
+						var recording *SolveRecordingRun
+						if s.recorder != nil {
+							recording = s.recorder.startRun(recorded, solver)
+						}
+
 						solver.SolveEvents().Iterated.Register(func(_ SolveInformation) {
 							if totalIterations.Add(1) >= int64(interpretedParallelSolveOptions.Iterations) {
 								cancel()
@@ -368,6 +454,9 @@ func (s *parallelSolverImpl) Solve(
 						if updatedIterations < 0 {
 							opt.Iterations = int(updatedIterations + int64(opt.Iterations))
 						}
+						if s.recorder != nil {
+							opt = s.recorder.iterationProgress(recording, opt)
+						}
 
 						s.ParallelSolveEvents().StartSolver.Trigger(
 							metaSolveInformation,
@@ -377,26 +466,36 @@ func (s *parallelSolverImpl) Solve(
 						)
 
 						solutionChannel, err := solver.Solve(
-							ctx,
+							context.WithValue(ctx, runKey{}, r),
 							opt,
 							solution,
 						)
 						if err != nil {
 							panic(err)
 						}
+						index := 0
 						for sol := range solutionChannel {
 							if sol.Solution != nil {
 								s.ParallelSolveEvents().NewSolution.Trigger(
 									metaSolveInformation,
 									sol.Solution,
 								)
+								if recording != nil {
+									s.recorder.solution(recording, sol.Solution)
+								}
+								if routePool != nil {
+									routePool.Add(sol.Solution)
+								}
 							}
 
 							syncResultChannel <- solutionContainer{
 								Solution:   sol,
 								Error:      sol.Error,
 								Iterations: int(totalIterations.Load()),
+								Run:        r,
+								Index:      index,
 							}
+							index++
 						}
 					}(runCount)
 				}
@@ -433,7 +532,14 @@ func (s *parallelSolverImpl) Solve(
 				continue
 			}
 
+			bestMutex.Lock()
 			bestSolution = solverResult.Solution.Copy()
+			bestOrigin = SolveRecordingOrigin{
+				StartSolution: -1,
+				Run:           solverResult.Run,
+				Solution:      solverResult.Index,
+			}
+			bestMutex.Unlock()
 
 			reportBestSolution(solutionContainer{
 				Solution:   solverResult.Solution.Copy(),
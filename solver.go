@@ -51,7 +51,7 @@ func NewSolver(
 		return nil,
 			fmt.Errorf("options.Plan: %w", err)
 	}
-	plan, err := NewSolveOperatorPlan(groupSize)
+	plan, err := newPlanOperator(groupSize, options.Regret)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +77,14 @@ func NewSolver(
 		plan,
 		restart,
 	)
+	acceptance, err := NewAcceptanceCriterion(options.Acceptance)
+	if err != nil {
+		return nil,
+			fmt.Errorf("options.Acceptance: %w", err)
+	}
+	if acceptanceSolver, ok := solver.(AcceptanceSolver); ok {
+		acceptanceSolver.SetAcceptanceCriterion(acceptance)
+	}
 	solverWrapper := solverWrapperImpl{
 		solver: solver,
 	}
@@ -100,6 +108,7 @@ func (s *solverWrapperImpl) Solve(
 	interpretedSolveOptions := SolveOptions{
 		Iterations: solveOptions.Iterations,
 		Duration:   solveOptions.Duration,
+		Acceptance: solveOptions.Acceptance,
 	}
 	if interpretedSolveOptions.Iterations == -1 {
 		interpretedSolveOptions.Iterations = math.MaxInt
@@ -150,12 +159,74 @@ func (s *solverWrapperImpl) Reset(
 	s.solver.Reset(solution, solveInformation)
 }
 
+func (s *solverWrapperImpl) SetAcceptanceCriterion(acceptance AcceptanceCriterion) {
+	if acceptanceSolver, ok := s.solver.(AcceptanceSolver); ok {
+		acceptanceSolver.SetAcceptanceCriterion(acceptance)
+	}
+}
+
 func (s *solverWrapperImpl) SolveOperators() SolveOperators {
 	return s.solver.SolveOperators()
 }
 
-// DefaultSolverFactory creates a new SolverFactory.
+// SolverFactoryOptions are the options of the solvers created by
+// [NewSolverFactory].
+type SolverFactoryOptions struct {
+	IntraRoute         float64           `json:"intra_route" usage:"probability of the intra-route operator (2-opt and or-opt) in an iteration, 0 disables it" default:"0"`
+	InterRoute         float64           `json:"inter_route" usage:"probability of the inter-route operator (relocate, swap and cross-exchange) in an iteration, 0 disables it" default:"0"`
+	SegmentLength      int               `json:"segment_length" usage:"maximum number of stops of a segment moved by the inter-route operator" default:"3"`
+	UnplanStrings      float64           `json:"unplan_strings" usage:"weight of un-planning strings of stops (SISR) relative to the default un-plan operator, 0 disables it" default:"0"`
+	StringLength       int               `json:"string_length" usage:"average number of stops of a string un-planned by the string removal operator" default:"5"`
+	StringRemovals     int               `json:"string_removals" usage:"average number of stops un-planned by the string removal operator" default:"10"`
+	UnplanWorst        float64           `json:"unplan_worst" usage:"weight of un-planning the plan units with the largest cost saving relative to the default un-plan operator, 0 disables it" default:"0"`
+	WorstRandomization float64           `json:"worst_randomization" usage:"randomization exponent of the worst removal operator, at least 1" default:"3"`
+	UnplanShaw         float64           `json:"unplan_shaw" usage:"weight of un-planning related plan units (Shaw removal) relative to the default un-plan operator, 0 disables it" default:"0"`
+	ShawRandomization  float64           `json:"shaw_randomization" usage:"randomization exponent of the Shaw removal operator, at least 1" default:"6"`
+	ShawRelatedness    ShawRelatedness   `json:"shaw_relatedness"`
+	Regret             int               `json:"regret" usage:"number of vehicles compared by regret-k insertion of the plan operator, less than 2 plans greedily" default:"0"`
+	Adaptive           bool              `json:"adaptive" usage:"adapt the weights of the un-plan operators to their success (ALNS), requires an alternative un-plan operator"`
+	AdaptiveOptions    AdaptiveOptions   `json:"adaptive_options"`
+	Acceptance         AcceptanceOptions `json:"acceptance"`
+}
+
+// DefaultSolverFactoryOptions returns the options of the solvers created by
+// [DefaultSolverFactory].
+func DefaultSolverFactoryOptions() SolverFactoryOptions {
+	return SolverFactoryOptions{
+		IntraRoute:     0,
+		InterRoute:     0,
+		SegmentLength:  3,
+		UnplanStrings:  0,
+		StringLength:   5,
+		StringRemovals: 10,
+
+		UnplanWorst:        0,
+		WorstRandomization: 3,
+		UnplanShaw:         0,
+		ShawRandomization:  6,
+		ShawRelatedness:    DefaultShawRelatedness(),
+
+		Regret: 0,
+
+		Adaptive:        false,
+		AdaptiveOptions: DefaultAdaptiveOptions(),
+
+		Acceptance: DefaultAcceptanceOptions(),
+	}
+}
+
+// DefaultSolverFactory creates a new SolverFactory with the default options,
+// see [NewSolverFactory].
 func DefaultSolverFactory() SolverFactory {
+	return NewSolverFactory(DefaultSolverFactoryOptions())
+}
+
+// NewSolverFactory creates a new SolverFactory. The solvers un-plan and plan
+// a number of plan units each iteration and improve routes with the
+// intra-route operator, see [NewSolveOperatorIntraRoute], and the inter-route
+// operator, see [NewSolveOperatorInterRoute], if their probability is larger
+// than 0.
+func NewSolverFactory(factoryOptions SolverFactoryOptions) SolverFactory {
 	return func(
 		_ ParallelSolveInformation,
 		solution Solution,
@@ -177,19 +248,29 @@ func DefaultSolverFactory() SolverFactory {
 			},
 			Plan: IntParameterOptions{
 				StartValue:               2,
-				DeltaAfterIterations:     1000000000,
+				DeltaAfterIterations:     neverChange,
 				Delta:                    0,
 				MinValue:                 2,
 				MaxValue:                 2,
 				SnapBackAfterImprovement: true,
 				Zigzag:                   true,
 			},
+			Regret:     factoryOptions.Regret,
+			Acceptance: factoryOptions.Acceptance,
 		}
 
 		solver, err := NewSkeletonSolver(solution.Model())
 		if err != nil {
 			return nil, err
 		}
+		acceptance, err := NewAcceptanceCriterion(options.Acceptance)
+		if err != nil {
+			return nil,
+				fmt.Errorf("options.Acceptance: %w", err)
+		}
+		if acceptanceSolver, ok := solver.(AcceptanceSolver); ok {
+			acceptanceSolver.SetAcceptanceCriterion(acceptance)
+		}
 		numberOfUnits, err := NewSolveParameter(
 			options.Unplan.StartValue,
 			options.Unplan.DeltaAfterIterations,
@@ -203,7 +284,7 @@ func DefaultSolverFactory() SolverFactory {
 			return nil,
 				fmt.Errorf("options.Unplan: %w", err)
 		}
-		unplan, err := NewSolveOperatorUnPlan(numberOfUnits)
+		unplan, err := newUnPlanOperator(numberOfUnits, options.Unplan, factoryOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -220,7 +301,7 @@ func DefaultSolverFactory() SolverFactory {
 			return nil,
 				fmt.Errorf("options.Plan: %w", err)
 		}
-		plan, err := NewSolveOperatorPlan(groupSize)
+		plan, err := newPlanOperator(groupSize, options.Regret)
 		if err != nil {
 			return nil, err
 		}
@@ -228,6 +309,169 @@ func DefaultSolverFactory() SolverFactory {
 			unplan,
 			plan,
 		)
+		if factoryOptions.IntraRoute > 0 {
+			intraRoute, err := NewSolveOperatorIntraRoute(factoryOptions.IntraRoute)
+			if err != nil {
+				return nil, err
+			}
+			solver.AddSolveOperators(intraRoute)
+		}
+		if factoryOptions.InterRoute > 0 {
+			interRoute, err := NewSolveOperatorInterRoute(
+				factoryOptions.InterRoute,
+				factoryOptions.SegmentLength,
+			)
+			if err != nil {
+				return nil, err
+			}
+			solver.AddSolveOperators(interRoute)
+		}
 		return solver, nil
 	}
 }
+
+// neverChange is the number of iterations after which a solve-parameter
+// changes its value, for solve-parameters that keep their value during a
+// solve.
+const neverChange = 1000000000
+
+// newPlanOperator returns the plan operator for the group size, regret-k
+// insertion if regret is at least 2, greedy insertion otherwise.
+func newPlanOperator(groupSize SolveParameter, regret int) (SolveOperator, error) {
+	if regret >= 2 {
+		return NewSolveOperatorPlanRegret(groupSize, regret)
+	}
+	return NewSolveOperatorPlan(groupSize)
+}
+
+// newUnPlanOperator returns the un-plan operator of the solvers created by
+// [NewSolverFactory]. If any of the alternative un-plan operators is enabled
+// by the options the returned operator selects between the default un-plan
+// operator and the enabled alternatives, weighted by the options. The
+// weights adapt to the success of the operators if adaptive is enabled, see
+// [NewSolverOperatorAdaptive], which requires at least one alternative.
+func newUnPlanOperator(
+	numberOfUnits SolveParameter,
+	unplanOptions IntParameterOptions,
+	factoryOptions SolverFactoryOptions,
+) (SolveOperator, error) {
+	unplan, err := NewSolveOperatorUnPlan(numberOfUnits)
+	if err != nil {
+		return nil, err
+	}
+	operators := SolveOperators{unplan}
+	if factoryOptions.UnplanStrings > 0 {
+		unplanStrings, err := newUnPlanStrings(factoryOptions)
+		if err != nil {
+			return nil, err
+		}
+		operators = append(operators, unplanStrings)
+	}
+	if factoryOptions.UnplanWorst > 0 {
+		numberOfUnits, err := newUnPlanParameter(unplanOptions)
+		if err != nil {
+			return nil, err
+		}
+		unplanWorst, err := NewSolveOperatorUnPlanWorst(
+			factoryOptions.UnplanWorst,
+			numberOfUnits,
+			factoryOptions.WorstRandomization,
+		)
+		if err != nil {
+			return nil, err
+		}
+		operators = append(operators, unplanWorst)
+	}
+	if factoryOptions.UnplanShaw > 0 {
+		numberOfUnits, err := newUnPlanParameter(unplanOptions)
+		if err != nil {
+			return nil, err
+		}
+		unplanShaw, err := NewSolveOperatorUnPlanShaw(
+			factoryOptions.UnplanShaw,
+			numberOfUnits,
+			factoryOptions.ShawRandomization,
+			factoryOptions.ShawRelatedness,
+		)
+		if err != nil {
+			return nil, err
+		}
+		operators = append(operators, unplanShaw)
+	}
+	if len(operators) == 1 {
+		if factoryOptions.Adaptive {
+			return nil, fmt.Errorf(
+				"adaptive operator selection requires at least one alternative " +
+					"un-plan operator, enable unplan strings, unplan worst or unplan shaw",
+			)
+		}
+		return unplan, nil
+	}
+	if factoryOptions.Adaptive {
+		return NewSolverOperatorAdaptive(1, operators, factoryOptions.AdaptiveOptions)
+	}
+	return NewSolverOperatorOr(1, operators)
+}
+
+// newUnPlanParameter returns a new solve-parameter for the number of units
+// to un-plan. Each operator needs its own parameter as the solver updates the
+// parameters of each operator.
+func newUnPlanParameter(unplanOptions IntParameterOptions) (SolveParameter, error) {
+	return NewSolveParameter(
+		unplanOptions.StartValue,
+		unplanOptions.DeltaAfterIterations,
+		unplanOptions.Delta,
+		unplanOptions.MinValue,
+		unplanOptions.MaxValue,
+		unplanOptions.SnapBackAfterImprovement,
+		unplanOptions.Zigzag,
+	)
+}
+
+// newUnPlanStrings returns the string removal operator of the solvers
+// created by [NewSolverFactory], weighted by the options.
+func newUnPlanStrings(
+	factoryOptions SolverFactoryOptions,
+) (SolveOperator, error) {
+	if factoryOptions.StringLength < 1 {
+		return nil, fmt.Errorf(
+			"string length %v must be at least 1",
+			factoryOptions.StringLength,
+		)
+	}
+	if factoryOptions.StringRemovals < 1 {
+		return nil, fmt.Errorf(
+			"string removals %v must be at least 1",
+			factoryOptions.StringRemovals,
+		)
+	}
+	numberOfUnits, err := NewSolveParameter(
+		factoryOptions.StringRemovals,
+		neverChange,
+		0,
+		factoryOptions.StringRemovals,
+		factoryOptions.StringRemovals,
+		true,
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	stringLength, err := NewSolveParameter(
+		factoryOptions.StringLength,
+		neverChange,
+		0,
+		factoryOptions.StringLength,
+		factoryOptions.StringLength,
+		true,
+		true,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return NewSolveOperatorUnPlanStrings(
+		factoryOptions.UnplanStrings,
+		numberOfUnits,
+		stringLength,
+	)
+}
@@ -42,7 +42,15 @@ func DefaultSolveOptionsFactory() SolveOptionsFactory {
 }
 
 type parallelSolverWrapperImpl struct {
-	solver ParallelSolver
+	solver   ParallelSolver
+	recorder *solveRecorderImpl
+}
+
+func (p *parallelSolverWrapperImpl) setRecorder(recorder *solveRecorderImpl) {
+	p.recorder = recorder
+	if r, ok := p.solver.(recordable); ok {
+		r.setRecorder(recorder)
+	}
 }
 
 func (p *parallelSolverWrapperImpl) ParallelSolveEvents() ParallelSolveEvents {
@@ -74,6 +82,11 @@ func (p *parallelSolverWrapperImpl) Solve(
 	solveOptions ParallelSolveOptions,
 	startSolutions ...Solution,
 ) (SolutionChannel, error) {
+	if p.recorder != nil {
+		solveOptions = p.recorder.options(solveOptions)
+		p.recorder.seedModel(p.solver.Model())
+	}
+
 	start := ctx.Value(run.Start).(time.Time)
 	ctx, _ = context.WithDeadline(
 		ctx,
@@ -86,6 +99,7 @@ func (p *parallelSolverWrapperImpl) Solve(
 		ParallelRuns:         solveOptions.ParallelRuns,
 		StartSolutions:       solveOptions.StartSolutions,
 		RunDeterministically: solveOptions.RunDeterministically,
+		RoutePool:            solveOptions.RoutePool,
 	}
 
 	if interpretedParallelSolveOptions.ParallelRuns == -1 {